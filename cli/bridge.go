@@ -0,0 +1,116 @@
+/*
+Copyright © 2021 CELLA, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"github.com/yomorun/yomo"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/pkg/bridge/mqtt"
+	wfconfig "github.com/yomorun/yomo/pkg/config"
+	"github.com/yomorun/yomo/pkg/log"
+)
+
+var bridgeZipperAddr string
+var bridgeName string
+
+// bridgeCmd represents the bridge command
+var bridgeCmd = &cobra.Command{
+	Use:   "bridge",
+	Short: "Run a bridge to YoMo-Zipper",
+	Long:  "Run a bridge to YoMo-Zipper",
+}
+
+// bridgeMQTTCmd represents the bridge mqtt command
+var bridgeMQTTCmd = &cobra.Command{
+	Use:   "mqtt",
+	Short: "Bridge an MQTT broker to YoMo-Zipper",
+	Long:  "Bridge an MQTT broker to YoMo-Zipper, the messages of topics are written as the data of tags, the results are published back",
+	Run: func(cmd *cobra.Command, args []string) {
+		cfg, err := wfconfig.LoadMQTTBridgeConfig(config)
+		if err != nil {
+			log.FailureStatusEvent(os.Stdout, err.Error())
+			return
+		}
+		bridge := mqtt.New(cfg)
+		conn, err := dialSource(bridgeName, bridgeZipperAddr, cfg, bridge)
+		if err != nil {
+			log.FailureStatusEvent(os.Stdout, err.Error())
+			return
+		}
+		if err := bridge.Start(conn); err != nil {
+			log.FailureStatusEvent(os.Stdout, err.Error())
+			conn.Close()
+			return
+		}
+		defer bridge.Close()
+
+		log.InfoStatusEvent(os.Stdout, "Running MQTT bridge to YoMo-Zipper %s...", bridgeZipperAddr)
+		ch := make(chan os.Signal, 1)
+		signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM)
+		<-ch
+	},
+}
+
+// sourceConn connects the standalone bridge to zipper by a source.
+type sourceConn struct {
+	source yomo.Source
+}
+
+// dialSource connects the bridge as a source observing the backflow of the published tags. Only the results
+// of the data written by the bridge are backflowed to a source, publishing all the data of a tag requires
+// the bridge embedded in the zipper.
+func dialSource(name string, addr string, cfg *wfconfig.MQTTBridge, bridge *mqtt.Bridge) (*sourceConn, error) {
+	for _, pub := range cfg.Publish {
+		if !pub.Backflow {
+			return nil, fmt.Errorf("mqtt: publishing all the data of tag %#x requires the bridge embedded in zipper, set `backflow: true`", pub.Tag)
+		}
+	}
+	opts := []yomo.Option{yomo.WithZipperAddr(addr), yomo.WithObserveDataTags(cfg.PublishTags()...)}
+	if cfg.Credential != "" {
+		opts = append(opts, yomo.WithCredential(cfg.Credential))
+	}
+	source := yomo.NewSource(name, opts...)
+	source.SetReceiveHandler(func(tag frame.Tag, data []byte) {
+		bridge.Handle(tag, data, true)
+	})
+	if err := source.Connect(); err != nil {
+		return nil, err
+	}
+	return &sourceConn{source: source}, nil
+}
+
+func (c *sourceConn) Write(tag frame.Tag, data []byte) error {
+	return c.source.WriteWithTag(tag, data)
+}
+
+func (c *sourceConn) Close() error {
+	return c.source.Close()
+}
+
+func init() {
+	rootCmd.AddCommand(bridgeCmd)
+	bridgeCmd.AddCommand(bridgeMQTTCmd)
+
+	bridgeMQTTCmd.Flags().StringVarP(&config, "config", "c", "mqtt.yaml", "MQTT bridge config file")
+	bridgeMQTTCmd.Flags().StringVarP(&bridgeZipperAddr, "zipper", "z", "localhost:9000", "YoMo-Zipper endpoint addr")
+	bridgeMQTTCmd.Flags().StringVarP(&bridgeName, "name", "n", "yomo-mqtt-bridge", "The name of the bridge")
+}
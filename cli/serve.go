@@ -17,15 +17,42 @@ package cli
 
 import (
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/yomorun/yomo"
+	"github.com/yomorun/yomo/core"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/trace"
+	"github.com/yomorun/yomo/pkg/deadletter"
 	"github.com/yomorun/yomo/pkg/log"
 )
 
 var meshConfURL string
+var meshInterval time.Duration
+var maxHops int
+var adminAddr string
+var adminToken string
+var ingressAddr string
+var egressAddr string
+var sendQueueSize int
+var sendQueuePolicy string
+var deadLetterFile string
+var deadLetterFileMaxSize int
+var deadLetterFileMaxBackups int
+var deadLetterRing int
+var deadLetterTag uint32
+var storeDir string
+var storeMaxSize int64
+var storeMaxAge time.Duration
+var traceStdout bool
+var tcpAddr string
+var websocketAddr string
+var websocketPath string
 var v *viper.Viper
 
 // serveCmd represents the serve command
@@ -46,6 +73,7 @@ var serveCmd = &cobra.Command{
 		if err != nil {
 			log.FailureStatusEvent(os.Stdout, err.Error())
 		}
+		var zipperOpts []yomo.Option
 		// auth
 		auth := v.GetString("auth")
 		if len(auth) > 0 {
@@ -56,14 +84,73 @@ var serveCmd = &cobra.Command{
 				args := auth[idx:]
 				authArgs := strings.Split(args, ",")
 				// log.InfoStatusEvent(os.Stdout, "authName=%s, authArgs=%s, idx=%d", authName, authArgs, idx)
-				zipper.InitOptions(yomo.WithAuth(authName, authArgs...))
+				zipperOpts = append(zipperOpts, yomo.WithAuth(authName, authArgs...))
 			}
 		}
+		// admin
+		if adminAddr != "" {
+			zipperOpts = append(zipperOpts, yomo.WithAdminAddr(adminAddr), yomo.WithAdminToken(adminToken))
+		}
+		// HTTP ingress
+		if ingressAddr != "" {
+			zipperOpts = append(zipperOpts, yomo.WithIngressAddr(ingressAddr))
+		}
+		// WebSocket/SSE egress
+		if egressAddr != "" {
+			zipperOpts = append(zipperOpts, yomo.WithEgressAddr(egressAddr))
+		}
+		// send queue
+		if sendQueueSize > 0 {
+			policy, err := core.ParseQueuePolicy(sendQueuePolicy)
+			if err != nil {
+				log.FailureStatusEvent(os.Stdout, err.Error())
+				return
+			}
+			zipperOpts = append(zipperOpts, yomo.WithSendQueue(sendQueueSize, policy))
+		}
+		// dead letters
+		if deadLetterFile != "" {
+			zipperOpts = append(zipperOpts, yomo.WithDeadLetterHandler(deadletter.NewFile(deadLetterFile, deadLetterFileMaxSize, deadLetterFileMaxBackups).Handle))
+		}
+		if deadLetterRing > 0 {
+			zipperOpts = append(zipperOpts, yomo.WithDeadLetterRing(deadLetterRing))
+		}
+		if deadLetterTag > 0 {
+			zipperOpts = append(zipperOpts, yomo.WithDeadLetterTag(frame.Tag(deadLetterTag)))
+		}
+		// store-and-forward
+		if storeDir != "" {
+			zipperOpts = append(zipperOpts, yomo.WithStoreAndForward(storeDir, storeMaxSize<<20, storeMaxAge))
+		}
+		// tracing
+		if traceStdout {
+			zipperOpts = append(zipperOpts, yomo.WithTraceExporter(trace.NewStdoutExporter()))
+		}
+		// fallback transports
+		if tcpAddr != "" {
+			zipperOpts = append(zipperOpts, yomo.WithTCPAddr(tcpAddr))
+		}
+		if websocketAddr != "" {
+			zipperOpts = append(zipperOpts, yomo.WithWebSocketAddr(websocketAddr, websocketPath))
+		}
+		// mesh refresh
+		if meshInterval > 0 {
+			zipperOpts = append(zipperOpts, yomo.WithMeshConfigInterval(meshInterval))
+		}
+		if maxHops > 0 {
+			zipperOpts = append(zipperOpts, yomo.WithMaxHops(maxHops))
+		}
+		if len(zipperOpts) > 0 {
+			zipper.InitOptions(zipperOpts...)
+		}
 		// mesh
 		err = zipper.ConfigMesh(meshConfURL)
 		if err != nil {
 			log.FailureStatusEvent(os.Stdout, err.Error())
 		}
+		if meshConfURL != "" {
+			go refreshMeshOnSignal(zipper)
+		}
 
 		log.InfoStatusEvent(os.Stdout, "Running YoMo-Zipper...")
 		err = zipper.ListenAndServe()
@@ -74,11 +161,43 @@ var serveCmd = &cobra.Command{
 	},
 }
 
+// refreshMeshOnSignal re-loads the mesh config of zipper on SIGHUP.
+func refreshMeshOnSignal(zipper yomo.Zipper) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+	for range ch {
+		log.InfoStatusEvent(os.Stdout, "Reloading mesh config...")
+		if err := zipper.RefreshMesh(); err != nil {
+			log.FailureStatusEvent(os.Stdout, err.Error())
+		}
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(serveCmd)
 
 	serveCmd.Flags().StringVarP(&config, "config", "c", "workflow.yaml", "Workflow config file")
-	serveCmd.Flags().StringVarP(&meshConfURL, "mesh-config", "m", "", "The URL of mesh config")
+	serveCmd.Flags().StringVarP(&meshConfURL, "mesh-config", "m", "", "The URL or file path of mesh config")
+	serveCmd.Flags().DurationVar(&meshInterval, "mesh-interval", 0, "The interval of re-loading the mesh config, disabled if it is 0")
+	serveCmd.Flags().IntVar(&maxHops, "max-hops", core.DefaultMaxHops, "The maximum times a broadcast DataFrame is forwarded across zippers")
+	serveCmd.Flags().StringVar(&adminAddr, "admin-addr", "", "The listening address of the admin HTTP API, eg: `localhost:9091`, disabled if empty")
+	serveCmd.Flags().StringVar(&adminToken, "admin-token", "", "The bearer token required by the DELETE endpoints of the admin HTTP API, they are disabled if empty")
+	serveCmd.Flags().StringVar(&ingressAddr, "ingress-addr", "", "The listening address of the HTTP ingress writing requests as DataFrames, eg: `0.0.0.0:9092`, disabled if empty")
+	serveCmd.Flags().StringVar(&egressAddr, "egress-addr", "", "The listening address of the WebSocket/SSE egress for browsers to subscribe data tags, eg: `0.0.0.0:9093`, disabled if empty")
+	serveCmd.Flags().IntVar(&sendQueueSize, "send-queue-size", 0, "The size of the send queue of each connection, frames are written synchronously if it is 0")
+	serveCmd.Flags().StringVar(&sendQueuePolicy, "send-queue-policy", "block", "The policy when the send queue is full: block, drop-oldest, drop-newest or disconnect")
+	serveCmd.Flags().StringVar(&deadLetterFile, "dead-letter-file", "", "The file to write the DataFrames failed to be delivered, disabled if empty")
+	serveCmd.Flags().IntVar(&deadLetterFileMaxSize, "dead-letter-file-max-size", 100, "The size in megabytes of the dead letter file before it is rotated")
+	serveCmd.Flags().IntVar(&deadLetterFileMaxBackups, "dead-letter-file-max-backups", 3, "The number of the rotated dead letter files to keep")
+	serveCmd.Flags().IntVar(&deadLetterRing, "dead-letter-ring", 0, "The number of the latest dead letters kept in memory and exposed by the admin API, disabled if it is 0")
+	serveCmd.Flags().Uint32Var(&deadLetterTag, "dead-letter-tag", 0, "The data tag to route the dead letters to stream functions, disabled if it is 0")
+	serveCmd.Flags().StringVar(&storeDir, "store-dir", "", "The directory to buffer the data of offline stream functions enabled `store_and_forward`, disabled if empty")
+	serveCmd.Flags().Int64Var(&storeMaxSize, "store-max-size", 1024, "The size limit in MB of the buffer of each stream function, no limit if it is 0")
+	serveCmd.Flags().DurationVar(&storeMaxAge, "store-max-age", 24*time.Hour, "The age limit of the buffered data, no limit if it is 0")
+	serveCmd.Flags().BoolVar(&traceStdout, "trace", false, "Export the tracing spans of data to stdout in JSON")
+	serveCmd.Flags().StringVar(&tcpAddr, "tcp-addr", "", "The listening address of TCP+TLS for the networks blocking UDP, eg: `0.0.0.0:9000`, disabled if empty")
+	serveCmd.Flags().StringVar(&websocketAddr, "websocket-addr", "", "The listening address of WebSocket over TLS, eg: `0.0.0.0:9443`, disabled if empty")
+	serveCmd.Flags().StringVar(&websocketPath, "websocket-path", "/yomo", "The path of WebSocket")
 	// auth string
 	serveCmd.Flags().StringP("auth", "a", "", "authentication name and arguments, eg: `token:yomo`")
 	v = viper.New()
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"sync"
+
+	"github.com/yomorun/yomo/core/frame"
+)
+
+// AnyIdentity is the identity of the permission applied to the identities without their own.
+const AnyIdentity = "*"
+
+// Identifier is implemented by the Authentication which can tell the identity of a credential,
+// e.g. the subject of a JWT or the common name of a certificate.
+type Identifier interface {
+	// Identity returns the identity of the credential payload.
+	Identity(payload string) string
+}
+
+// Identify returns the identity of the Object, It is the auth payload
+// unless the Authentication of the Object implements Identifier.
+func Identify(auths map[string]Authentication, obj Object) string {
+	if obj == nil {
+		return ""
+	}
+	if a, ok := auths[obj.AuthName()]; ok {
+		if i, ok := a.(Identifier); ok {
+			return i.Identity(obj.AuthPayload())
+		}
+	}
+	return obj.AuthPayload()
+}
+
+// Permission is the data tags an identity may publish and observe.
+type Permission struct {
+	Publish []frame.Tag
+	Observe []frame.Tag
+}
+
+// ACL maps identities to their permissions, the identity without permission
+// falls back to the permission of `AnyIdentity`, if neither exists, everything is denied.
+type ACL struct {
+	mu    sync.RWMutex
+	perms map[string]Permission
+}
+
+// NewACL creates an ACL with the permissions keyed by identity.
+func NewACL(perms map[string]Permission) *ACL {
+	acl := &ACL{}
+	acl.Update(perms)
+	return acl
+}
+
+// Update replaces all the permissions of ACL.
+func (a *ACL) Update(perms map[string]Permission) {
+	if perms == nil {
+		perms = make(map[string]Permission)
+	}
+	a.mu.Lock()
+	a.perms = perms
+	a.mu.Unlock()
+}
+
+// CanPublish returns true if the identity may publish the tag.
+func (a *ACL) CanPublish(identity string, tag frame.Tag) bool {
+	perm, ok := a.permission(identity)
+	return ok && containsTag(perm.Publish, tag)
+}
+
+// CanObserve returns true if the identity may observe all the tags.
+func (a *ACL) CanObserve(identity string, tags []frame.Tag) bool {
+	perm, ok := a.permission(identity)
+	if !ok {
+		return len(tags) == 0
+	}
+	for _, tag := range tags {
+		if !containsTag(perm.Observe, tag) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a *ACL) permission(identity string) (Permission, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if perm, ok := a.perms[identity]; ok {
+		return perm, true
+	}
+	perm, ok := a.perms[AnyIdentity]
+	return perm, ok
+}
+
+func containsTag(tags []frame.Tag, tag frame.Tag) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+)
+
+// subjectAuth implements `Identifier`, the identity is the payload with a prefix.
+type subjectAuth struct{ mockAuth }
+
+func (auth subjectAuth) Identity(payload string) string { return "sub:" + payload }
+
+func TestIdentify(t *testing.T) {
+	obj := frame.NewHandshakeFrame("", "", byte(1), []frame.Tag{}, "mock", "team-a")
+
+	assert.Equal(t, "team-a", Identify(nil, obj))
+	assert.Equal(t, "team-a", Identify(map[string]Authentication{"mock": mockAuth{authed: true}}, obj))
+	assert.Equal(t, "sub:team-a", Identify(map[string]Authentication{"mock": subjectAuth{}}, obj))
+	assert.Equal(t, "", Identify(nil, nil))
+}
+
+func TestACL(t *testing.T) {
+	acl := NewACL(map[string]Permission{
+		"team-a": {Publish: []frame.Tag{1}, Observe: []frame.Tag{1, 2}},
+	})
+
+	assert.True(t, acl.CanPublish("team-a", 1))
+	assert.False(t, acl.CanPublish("team-a", 2))
+	assert.True(t, acl.CanObserve("team-a", []frame.Tag{1, 2}))
+	assert.False(t, acl.CanObserve("team-a", []frame.Tag{1, 3}))
+
+	// no permission
+	assert.False(t, acl.CanPublish("team-b", 1))
+	assert.False(t, acl.CanObserve("team-b", []frame.Tag{1}))
+	assert.True(t, acl.CanObserve("team-b", []frame.Tag{}))
+
+	// fall back to any identity
+	acl.Update(map[string]Permission{
+		AnyIdentity: {Publish: []frame.Tag{3}, Observe: []frame.Tag{3}},
+	})
+	assert.False(t, acl.CanPublish("team-a", 1))
+	assert.True(t, acl.CanPublish("team-b", 3))
+	assert.True(t, acl.CanObserve("team-b", []frame.Tag{3}))
+}
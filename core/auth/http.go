@@ -0,0 +1,22 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+)
+
+// HeaderCredential is the HTTP header of the client credential, e.g. `token:yomo`.
+const HeaderCredential = "X-Yomo-Credential"
+
+// NewHTTPCredential reads the client credential of the HTTP request, from the header `X-Yomo-Credential`,
+// `Authorization: Bearer <token>` for the token authentication, or the query `credential` for the
+// browsers which cannot set the headers of WebSocket and EventSource.
+func NewHTTPCredential(r *http.Request) *Credential {
+	if v := r.Header.Get(HeaderCredential); v != "" {
+		return NewCredential(v)
+	}
+	if v := r.Header.Get("Authorization"); strings.HasPrefix(v, "Bearer ") {
+		return NewCredential("token:" + strings.TrimPrefix(v, "Bearer "))
+	}
+	return NewCredential(r.URL.Query().Get("credential"))
+}
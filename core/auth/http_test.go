@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHTTPCredential(t *testing.T) {
+	tests := []struct {
+		name        string
+		target      string
+		header      string
+		value       string
+		wantName    string
+		wantPayload string
+	}{
+		{"header", "/", HeaderCredential, "token:yomo", "token", "yomo"},
+		{"bearer", "/", "Authorization", "Bearer yomo", "token", "yomo"},
+		{"query", "/?credential=token:yomo", "", "", "token", "yomo"},
+		{"none", "/", "", "", "none", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", tt.target, nil)
+			if tt.header != "" {
+				r.Header.Set(tt.header, tt.value)
+			}
+			got := NewHTTPCredential(r)
+
+			assert.Equal(t, tt.wantName, got.Name())
+			assert.Equal(t, tt.wantPayload, got.Payload())
+		})
+	}
+}
@@ -11,8 +11,12 @@ import (
 	"time"
 
 	"github.com/lucas-clemente/quic-go"
+	"github.com/yomorun/yomo/core/compress"
+	"github.com/yomorun/yomo/core/envelope"
 	"github.com/yomorun/yomo/core/frame"
 	"github.com/yomorun/yomo/core/log"
+	"github.com/yomorun/yomo/core/metrics"
+	"github.com/yomorun/yomo/core/trace"
 	"github.com/yomorun/yomo/core/yerr"
 	"github.com/yomorun/yomo/pkg/id"
 )
@@ -28,6 +32,7 @@ type Client struct {
 	clientType ClientType                 // type of the connection
 	conn       quic.Connection            // quic connection
 	fs         frame.ReadWriter           // yomo abstract stream
+	streams    *tagStreams                // streams of tags, nil in single stream mode
 	state      ConnState                  // state of the connection
 	processor  func(*frame.DataFrame)     // function to invoke when data arrived
 	receiver   func(*frame.BackflowFrame) // function to invoke when data is processed
@@ -38,6 +43,13 @@ type Client struct {
 	opts       *clientOptions
 	localAddr  string // client local addr, it will be changed on reconnect
 	logger     log.Logger
+	tracer     *trace.Tracer
+	keyring    *envelope.Keyring
+	keyMu      sync.RWMutex
+	keyID      string // the id of the key to seal the DataFrames written by the client
+	advMu      sync.RWMutex
+	advertised []frame.Tag // the data tags advertised by the downstream zipper
+	advertises bool        // true if the downstream zipper advertised the data tags
 	errc       chan error
 }
 
@@ -57,6 +69,8 @@ func NewClient(appName string, connType ClientType, opts ...ClientOption) *Clien
 		opts:       option,
 		errc:       make(chan error),
 		logger:     option.logger,
+		tracer:     trace.NewTracer(appName, option.traceExporter),
+		keyring:    envelope.NewKeyring(),
 	}
 }
 
@@ -83,8 +97,8 @@ func (c *Client) connect(ctx context.Context, addr string) error {
 	c.addr = addr
 	c.state = ConnStateConnecting
 
-	// create quic connection
-	conn, err := quic.DialAddrContext(ctx, addr, c.opts.tlsConfig, c.opts.quicConfig)
+	// create quic connection, or the connection of the fallback transports
+	conn, err := c.dial(ctx, addr)
 	if err != nil {
 		c.state = ConnStateDisconnected
 		return err
@@ -108,16 +122,35 @@ func (c *Client) connect(ctx context.Context, addr string) error {
 		c.opts.credential.Name(),
 		c.opts.credential.Payload(),
 	)
+	// the client decompresses the carriage by all the registered algorithms.
+	handshake.Compressions = compress.Names()
+	handshake.StreamPerTag = c.opts.streamPerTag
+	// the upstream zipper asks the downstream zipper for the data tags observed by it.
+	handshake.AdvertiseTags = c.clientType == ClientTypeUpstreamZipper
+	c.setAdvertisedTags(nil, false)
 	if err := c.fs.WriteFrame(handshake); err != nil {
 		c.state = ConnStateDisconnected
 		return err
 	}
 
-	if _, err := frame.ReadUntil(c.fs, frame.TagOfHandshakeAckFrame, 10*time.Second); err != nil {
+	ack, err := frame.ReadUntil(c.fs, frame.TagOfHandshakeAckFrame, 10*time.Second)
+	if err != nil {
 		c.state = ConnStateDisconnected
 		return err
 	}
 
+	// the zipper not supporting streams of tags acks without it, then the handshake stream is used.
+	c.streams = nil
+	if v, ok := ack.(*frame.HandshakeAckFrame); ok && v.StreamPerTag {
+		c.streams = newTagStreams(func() (io.WriteCloser, error) {
+			return conn.OpenUniStream()
+		})
+		go c.acceptDataStreams(conn)
+	}
+	if supportsDatagrams(conn) {
+		go c.receiveDatagrams(conn)
+	}
+
 	c.state = ConnStateConnected
 	c.localAddr = c.conn.LocalAddr().String()
 
@@ -155,6 +188,23 @@ func (c *Client) connect(ctx context.Context, addr string) error {
 	return nil
 }
 
+// dial dials the zipper by the transports in order, the next one is tried if the previous one fails.
+func (c *Client) dial(ctx context.Context, addr string) (quic.Connection, error) {
+	var err error
+	for _, t := range c.opts.transports {
+		dctx, cancel := context.WithTimeout(ctx, dialTimeout)
+		var conn quic.Connection
+		conn, err = t.Dial(dctx, addr, c.opts.tlsConfig, c.opts.quicConfig)
+		cancel()
+		if err == nil {
+			c.logger.Debugf("%s[%s] dial %s by %s", ClientLogPrefix, c.name, addr, t.Name())
+			return conn, nil
+		}
+		c.logger.Warnf("%s[%s] dial %s by %s error: %v", ClientLogPrefix, c.name, addr, t.Name(), err)
+	}
+	return nil, err
+}
+
 // handleFrame handles the logic when receiving frame from server.
 func (c *Client) handleFrame() (bool, bool, error) {
 	for {
@@ -169,7 +219,7 @@ func (c *Client) handleFrame() (bool, bool, error) {
 			} else if e, ok := err.(*quic.IdleTimeoutError); ok {
 				return false, false, e
 			} else if e, ok := err.(*quic.ApplicationError); ok {
-				return false, e.ErrorCode == yerr.ErrorCodeGoaway.To() || e.ErrorCode == yerr.ErrorCodeRejected.To(), e
+				return false, e.ErrorCode == yerr.ErrorCodeGoaway.To() || e.ErrorCode == yerr.ErrorCodeRejected.To() || e.ErrorCode == yerr.ErrorCodeForbidden.To(), e
 			} else if errors.Is(err, net.ErrClosed) {
 				return false, false, err
 			}
@@ -183,6 +233,9 @@ func (c *Client) handleFrame() (bool, bool, error) {
 		switch frameType {
 		case frame.TagOfRejectedFrame:
 			if v, ok := f.(*frame.RejectedFrame); ok {
+				if code := v.Code(); code != 0 {
+					return true, true, yerr.New(yerr.ErrorCode(code), errors.New(v.Message()))
+				}
 				return true, true, errors.New(v.Message())
 			}
 		case frame.TagOfGoawayFrame:
@@ -191,15 +244,18 @@ func (c *Client) handleFrame() (bool, bool, error) {
 			}
 		case frame.TagOfDataFrame: // DataFrame carries user's data
 			if v, ok := f.(*frame.DataFrame); ok {
-				if c.processor == nil {
-					c.logger.Warnf("%sprocessor is nil", ClientLogPrefix)
-				} else {
-					c.processor(v)
-				}
+				c.handleDataFrame(v)
+			}
+		case frame.TagOfObservedTagsFrame:
+			if v, ok := f.(*frame.ObservedTagsFrame); ok {
+				c.logger.Debugf("%sthe downstream zipper observes data tags: %v", ClientLogPrefix, v.Tags)
+				c.setAdvertisedTags(v.Tags, true)
 			}
 		case frame.TagOfBackflowFrame:
 			if v, ok := f.(*frame.BackflowFrame); ok {
-				if c.receiver == nil {
+				if err := c.openBackflowFrame(v); err != nil {
+					c.logger.Errorf("%sopen BackflowFrame error: %v", ClientLogPrefix, err)
+				} else if c.receiver == nil {
 					c.logger.Warnf("%sreceiver is nil", ClientLogPrefix)
 				} else {
 					c.receiver(v)
@@ -211,6 +267,109 @@ func (c *Client) handleFrame() (bool, bool, error) {
 	}
 }
 
+// AdvertisedTags returns the data tags observed by the downstream zipper connected by the upstream zipper,
+// ok is false if the downstream zipper does not advertise them.
+func (c *Client) AdvertisedTags() (tags []frame.Tag, ok bool) {
+	c.advMu.RLock()
+	defer c.advMu.RUnlock()
+
+	return c.advertised, c.advertises
+}
+
+func (c *Client) setAdvertisedTags(tags []frame.Tag, ok bool) {
+	c.advMu.Lock()
+	c.advertised = tags
+	c.advertises = ok
+	c.advMu.Unlock()
+}
+
+// handleDataFrame handles the DataFrame received from server.
+func (c *Client) handleDataFrame(f *frame.DataFrame) {
+	metrics.ClientFramesReceived.Inc(c.name, tagLabel(f.GetDataTag()))
+	metrics.ClientBytesReceived.Add(int64(len(f.GetCarriage())), c.name, tagLabel(f.GetDataTag()))
+	if err := c.openDataFrame(f); err != nil {
+		c.logger.Errorf("%sopen DataFrame error: %v", ClientLogPrefix, err)
+		return
+	}
+	if c.processor == nil {
+		c.logger.Warnf("%sprocessor is nil", ClientLogPrefix)
+	} else {
+		c.processor(f)
+	}
+}
+
+// acceptDataStreams accepts the unidirectional streams opened by server for the DataFrames of each tag.
+func (c *Client) acceptDataStreams(conn quic.Connection) {
+	for {
+		stream, err := conn.AcceptUniStream(conn.Context())
+		if err != nil {
+			// the connection is closed, it is reconnected by the handshake stream.
+			return
+		}
+		go func() {
+			for {
+				f, err := ParseFrame(stream)
+				if err != nil {
+					c.logger.Debugf("%sdata stream [%d] is closed: %v", ClientLogPrefix, stream.StreamID(), err)
+					return
+				}
+				if v, ok := f.(*frame.DataFrame); ok {
+					c.handleDataFrame(v)
+				} else {
+					c.logger.Warnf("%sunexpected frame %#x on data stream", ClientLogPrefix, f.Type())
+				}
+			}
+		}()
+	}
+}
+
+// receiveDatagrams receives the DataFrames sent as datagrams by server.
+func (c *Client) receiveDatagrams(conn quic.Connection) {
+	for {
+		f, err := receiveDatagram(conn)
+		if err != nil {
+			return
+		}
+		c.handleDataFrame(f)
+	}
+}
+
+// isDatagram returns true if the DataFrame should be sent as a datagram.
+func (c *Client) isDatagram(f *frame.DataFrame) bool {
+	if f.IsDatagram() {
+		return true
+	}
+	for _, tag := range c.opts.datagramTags {
+		if tag == f.GetDataTag() {
+			return true
+		}
+	}
+	return false
+}
+
+// writeDatagram sends the DataFrame as a datagram, It returns false if the DataFrame should be
+// written to the stream, e.g. it is too large or datagrams are not supported by the zipper.
+func (c *Client) writeDatagram(f *frame.DataFrame) bool {
+	if !supportsDatagrams(c.conn) {
+		return false
+	}
+	tag := tagLabel(f.GetDataTag())
+	err := sendDatagram(c.conn, f)
+	if errors.Is(err, errDatagramOversized) {
+		metrics.ClientDatagramsOversized.Inc(c.name, tag)
+		return false
+	}
+	if err != nil {
+		// datagrams are unreliable, the failure is not an error of the write.
+		metrics.ClientDatagramsDropped.Inc(c.name, tag)
+		c.logger.Debugf("%ssend datagram error: %v", ClientLogPrefix, err)
+		return true
+	}
+	metrics.ClientFramesSent.Inc(c.name, tag)
+	metrics.ClientBytesSent.Add(int64(len(f.GetCarriage())), c.name, tag)
+	return true
+}
+
 // Close the client.
 func (c *Client) Close() error {
 	c.mu.Lock()
@@ -245,10 +404,119 @@ func (c *Client) WriteFrame(frm frame.Frame) error {
 		return errors.New("client connection isn't connected")
 	}
 
-	if err := c.fs.WriteFrame(frm); err != nil {
+	// the sealed DataFrames are written as is, e.g. the ones dispatched to downstream zippers.
+	// The DataFrame may be shared by the writers, so a copy of it is compressed or sealed.
+	if f, ok := frm.(*frame.DataFrame); ok && !envelope.Sealed(f) {
+		if keyID := c.EncryptionKeyID(); keyID != "" {
+			sealed, err := c.Seal(f, keyID)
+			if err != nil {
+				return err
+			}
+			frm = sealed
+		} else {
+			frm = c.compress(f)
+		}
+	}
+
+	written := false
+	if f, ok := frm.(*frame.DataFrame); ok && c.isDatagram(f) {
+		if c.writeDatagram(f) {
+			return nil
+		}
+	}
+	if f, ok := frm.(*frame.DataFrame); ok && c.streams != nil {
+		var err error
+		if written, err = c.streams.Write(f); err != nil {
+			return err
+		}
+	}
+	if !written {
+		if err := c.fs.WriteFrame(frm); err != nil {
+			return err
+		}
+	}
+
+	if f, ok := frm.(*frame.DataFrame); ok {
+		metrics.ClientFramesSent.Inc(c.name, tagLabel(f.GetDataTag()))
+		metrics.ClientBytesSent.Add(int64(len(f.GetCarriage())), c.name, tagLabel(f.GetDataTag()))
+	}
+
+	return nil
+}
+
+// SetEncryptionKey adds the key to the client, the carriage of DataFrames written by the client
+// is sealed by it, and the DataFrames sealed by it can be opened.
+func (c *Client) SetEncryptionKey(keyID string, key []byte) error {
+	if err := c.keyring.Add(keyID, key); err != nil {
+		return err
+	}
+	c.keyMu.Lock()
+	c.keyID = keyID
+	c.keyMu.Unlock()
+	return nil
+}
+
+// AddDecryptionKey adds the key to the client to open the DataFrames sealed by it.
+func (c *Client) AddDecryptionKey(keyID string, key []byte) error {
+	return c.keyring.Add(keyID, key)
+}
+
+// Seal returns a copy of the DataFrame whose carriage is compressed if the compression is enabled,
+// then sealed by the key of the id, the DataFrame is not changed. The sealed DataFrame is written as is by WriteFrame.
+func (c *Client) Seal(f *frame.DataFrame, keyID string) (*frame.DataFrame, error) {
+	sealed := c.compress(f)
+	if sealed == f {
+		sealed = f.Clone()
+	}
+	if err := c.keyring.Seal(sealed, keyID); err != nil {
+		return nil, err
+	}
+	return sealed, nil
+}
+
+// EncryptionKeyID returns the id of the key sealing the DataFrames written by the client, It is empty if not set.
+func (c *Client) EncryptionKeyID() string {
+	c.keyMu.RLock()
+	defer c.keyMu.RUnlock()
+
+	return c.keyID
+}
+
+// compress returns a copy of the DataFrame with the compressed carriage if the compression is enabled,
+// the DataFrame is not changed. It returns the DataFrame as is if it is not compressed or it fails.
+func (c *Client) compress(f *frame.DataFrame) *frame.DataFrame {
+	if c.opts.compression == "" || f.Compression() != "" || len(f.GetCarriage()) < c.opts.compressionThreshold {
+		return f
+	}
+	compressed := f.Clone()
+	if err := compress.CompressFrame(compressed, c.opts.compression, c.opts.compressionThreshold); err != nil {
+		c.logger.Warnf("%scompress DataFrame error: %v", ClientLogPrefix, err)
+		return f
+	}
+	return compressed
+}
+
+// openDataFrame opens the sealed carriage then decompresses it.
+func (c *Client) openDataFrame(f *frame.DataFrame) error {
+	if err := c.keyring.Open(f); err != nil {
 		return err
 	}
+	return compress.DecompressFrame(f)
+}
 
+// openBackflowFrame opens the sealed carriage of the result then decompresses it.
+func (c *Client) openBackflowFrame(f *frame.BackflowFrame) error {
+	if f.KeyID == "" {
+		return nil
+	}
+	df := frame.NewDataFrame()
+	df.SetCarriage(f.Tag, f.Carriage)
+	df.SetCompression(f.Compression)
+	df.GetMetaFrame().SetKeyID(f.KeyID)
+	if err := c.openDataFrame(df); err != nil {
+		return err
+	}
+	f.SetCarriage(df.GetCarriage()).SetKeyID("", "")
 	return nil
 }
 
@@ -278,8 +546,10 @@ func (c *Client) reconnect(ctx context.Context, addr string) {
 			if c.errorfn != nil && err != nil {
 				c.errorfn(err)
 			}
-			if !ok && c.closefn != nil {
-				c.closefn()
+			if !ok {
+				if c.closefn != nil {
+					c.closefn()
+				}
 				return
 			}
 		case <-t.C:
@@ -288,6 +558,7 @@ func (c *Client) reconnect(ctx context.Context, addr string) {
 			c.mu.Unlock()
 			if state == ConnStateDisconnected {
 				c.logger.Printf("%s[%s][%s](%s) is reconnecting to YoMo-Zipper %s...", ClientLogPrefix, c.name, c.clientID, c.localAddr, addr)
+				metrics.ClientReconnects.Inc(c.name)
 				err := c.connect(ctx, addr)
 				if err != nil {
 					c.logger.Errorf("%s[%s][%s](%s) reconnect error:%v", ClientLogPrefix, c.name, c.clientID, c.localAddr, err)
@@ -311,6 +582,12 @@ func (c *Client) Logger() log.Logger {
 	return c.logger
 }
 
+// Tracer get client's tracer, you can enable tracing using `yomo.WithTraceExporter`,
+// It returns nil if tracing is disabled.
+func (c *Client) Tracer() *trace.Tracer {
+	return c.tracer
+}
+
 // SetErrorHandler set error handler
 func (c *Client) SetErrorHandler(fn func(err error)) {
 	c.errorfn = fn
@@ -8,6 +8,7 @@ import (
 	"github.com/yomorun/yomo/core/auth"
 	"github.com/yomorun/yomo/core/frame"
 	"github.com/yomorun/yomo/core/log"
+	"github.com/yomorun/yomo/core/trace"
 	"github.com/yomorun/yomo/pkg/logger"
 	pkgtls "github.com/yomorun/yomo/pkg/tls"
 )
@@ -19,6 +20,17 @@ type clientOptions struct {
 	tlsConfig       *tls.Config
 	credential      *auth.Credential
 	logger          log.Logger
+	traceExporter   trace.Exporter
+	// compression is the algorithm to compress the carriage of DataFrames written by the client,
+	// the carriage smaller than compressionThreshold bytes is not compressed.
+	compression          string
+	compressionThreshold int
+	// streamPerTag writes the DataFrames of each tag to their own stream.
+	streamPerTag bool
+	// datagramTags are the tags of DataFrames sent as unreliable datagrams.
+	datagramTags []frame.Tag
+	// transports dial the zipper in order until one succeeds.
+	transports []Transport
 }
 
 func defaultClientOption() *clientOptions {
@@ -33,6 +45,7 @@ func defaultClientOption() *clientOptions {
 		HandshakeIdleTimeout:           time.Second * 3,
 		InitialStreamReceiveWindow:     1024 * 1024 * 2,
 		InitialConnectionReceiveWindow: 1024 * 1024 * 2,
+		EnableDatagrams:                true,
 		TokenStore:                     quic.NewLRUTokenStore(10, 5),
 	}
 
@@ -42,6 +55,7 @@ func defaultClientOption() *clientOptions {
 		tlsConfig:       pkgtls.MustCreateClientTLSConfig(),
 		credential:      auth.NewCredential(""),
 		logger:          logger,
+		transports:      []Transport{NewQUICTransport()},
 	}
 
 	if opts.credential != nil {
@@ -87,3 +101,46 @@ func WithLogger(logger log.Logger) ClientOption {
 		o.logger = logger
 	}
 }
+
+// WithClientTraceExporter sets the exporter of tracing spans for the client, tracing is disabled if it is nil.
+func WithClientTraceExporter(exporter trace.Exporter) ClientOption {
+	return func(o *clientOptions) {
+		o.traceExporter = exporter
+	}
+}
+
+// WithCompression compresses the carriage of DataFrames written by the client with the algorithm,
+// e.g. `gzip` or `zstd`, the carriage smaller than threshold bytes is sent uncompressed.
+func WithCompression(name string, threshold int) ClientOption {
+	return func(o *clientOptions) {
+		o.compression = name
+		o.compressionThreshold = threshold
+	}
+}
+
+// WithStreamPerTag writes the DataFrames of each tag to their own QUIC stream, so a large frame
+// of one tag does not delay the others, It falls back to a single stream if the zipper does not support it.
+func WithStreamPerTag() ClientOption {
+	return func(o *clientOptions) {
+		o.streamPerTag = true
+	}
+}
+
+// WithDatagramTags sends the DataFrames of the tags as unreliable QUIC datagrams, the ones too large
+// for a datagram are sent on the stream, It requires `EnableDatagrams` of the quic config.
+func WithDatagramTags(tags ...frame.Tag) ClientOption {
+	return func(o *clientOptions) {
+		o.datagramTags = tags
+	}
+}
+
+// WithTransports sets the transports dialing the zipper in order, the next one is tried if the previous
+// one fails, e.g. `NewQUICTransport(), NewTCPTransport(""), NewWebSocketTransport(url)` falls back
+// to TCP+TLS and WebSocket in the networks blocking UDP. It is QUIC only by default.
+func WithTransports(transports ...Transport) ClientOption {
+	return func(o *clientOptions) {
+		if len(transports) > 0 {
+			o.transports = transports
+		}
+	}
+}
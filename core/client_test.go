@@ -136,3 +136,32 @@ func (w *mockFrameWriter) assertEqual(t *testing.T, frm frame.Frame) {
 
 	assert.Equal(t, w.buf.Bytes(), frm.Encode())
 }
+
+func TestClientSealCopiesDataFrame(t *testing.T) {
+	client := NewClient("source", ClientTypeSource, WithCompression("gzip", 0))
+	assert.NoError(t, client.SetEncryptionKey("key-1", bytes.Repeat([]byte{1}, 32)))
+
+	// the DataFrame may be dispatched to several downstream zippers, it is not changed by sealing.
+	f := frame.NewDataFrame()
+	f.SetCarriage(frame.Tag(1), []byte("hello yomo"))
+	sealed, err := client.Seal(f, "key-1")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "key-1", sealed.GetMetaFrame().KeyID())
+	assert.Equal(t, "gzip", sealed.Compression())
+	assert.Equal(t, "", f.GetMetaFrame().KeyID())
+	assert.Equal(t, "", f.Compression())
+	assert.Equal(t, []byte("hello yomo"), f.GetCarriage())
+}
+
+func TestClientCompressCopiesDataFrame(t *testing.T) {
+	client := NewClient("source", ClientTypeSource, WithCompression("gzip", 0))
+
+	f := frame.NewDataFrame()
+	f.SetCarriage(frame.Tag(1), []byte("hello yomo"))
+	compressed := client.compress(f)
+
+	assert.Equal(t, "gzip", compressed.Compression())
+	assert.Equal(t, "", f.Compression())
+	assert.Equal(t, []byte("hello yomo"), f.GetCarriage())
+}
@@ -0,0 +1,128 @@
+// Package compress compresses the carriage of DataFrames.
+//
+// The algorithm is recorded in the PayloadFrame of each DataFrame, so the zipper forwards
+// the compressed carriage as is, and the receiver decompresses it by the algorithm.
+// The clients declare the algorithms they accept in the HandshakeFrame.
+package compress
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/yomorun/yomo/core/frame"
+)
+
+// Built-in algorithms.
+const (
+	Gzip = "gzip"
+	Zstd = "zstd"
+)
+
+// Compressor compresses and decompresses the carriage, It must be safe for concurrent use.
+type Compressor interface {
+	// Name returns the name of the algorithm, It is recorded in the DataFrames.
+	Name() string
+	// Compress returns the compressed data.
+	Compress(data []byte) ([]byte, error)
+	// Decompress returns the decompressed data.
+	Decompress(data []byte) ([]byte, error)
+}
+
+var (
+	mu          sync.RWMutex
+	compressors = make(map[string]Compressor)
+)
+
+func init() {
+	Register(&gzipCompressor{})
+	Register(newZstdCompressor())
+}
+
+// Register registers the compressor.
+func Register(c Compressor) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	compressors[c.Name()] = c
+}
+
+// Get returns the compressor by name.
+func Get(name string) (Compressor, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	c, ok := compressors[name]
+	return c, ok
+}
+
+// Names returns the names of the registered compressors in order.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	names := make([]string, 0, len(compressors))
+	for name := range compressors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CompressFrame compresses the carriage of DataFrame in place, It does nothing
+// if the carriage is smaller than threshold or the DataFrame is already compressed.
+func CompressFrame(f *frame.DataFrame, name string, threshold int) error {
+	if f.Compression() != "" || len(f.GetCarriage()) < threshold {
+		return nil
+	}
+	c, ok := Get(name)
+	if !ok {
+		return fmt.Errorf("compress: unknown algorithm: %s", name)
+	}
+	data, err := c.Compress(f.GetCarriage())
+	if err != nil {
+		return err
+	}
+	f.SetCarriage(f.GetDataTag(), data)
+	f.SetCompression(name)
+	return nil
+}
+
+// DecompressFrame decompresses the carriage of DataFrame in place.
+func DecompressFrame(f *frame.DataFrame) error {
+	if f.Compression() == "" {
+		return nil
+	}
+	data, err := Carriage(f)
+	if err != nil {
+		return err
+	}
+	f.SetCarriage(f.GetDataTag(), data)
+	return nil
+}
+
+// Carriage returns the decompressed carriage of DataFrame, the DataFrame is not changed.
+func Carriage(f *frame.DataFrame) ([]byte, error) {
+	name := f.Compression()
+	if name == "" {
+		return f.GetCarriage(), nil
+	}
+	c, ok := Get(name)
+	if !ok {
+		return nil, fmt.Errorf("compress: unknown algorithm: %s", name)
+	}
+	return c.Decompress(f.GetCarriage())
+}
+
+// Accepts returns true if the algorithm is in the accepted ones, uncompressed is always accepted.
+func Accepts(accepted []string, name string) bool {
+	if name == "" {
+		return true
+	}
+	for _, v := range accepted {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
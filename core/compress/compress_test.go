@@ -0,0 +1,73 @@
+package compress
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+)
+
+func TestCompressors(t *testing.T) {
+	assert.Equal(t, []string{Gzip, Zstd}, Names())
+
+	data := bytes.Repeat([]byte(`{"noise":42.1,"time":1670000000}`), 10)
+	for _, name := range Names() {
+		t.Run(name, func(t *testing.T) {
+			c, ok := Get(name)
+			assert.True(t, ok)
+
+			compressed, err := c.Compress(data)
+			assert.NoError(t, err)
+			assert.Less(t, len(compressed), len(data))
+
+			decompressed, err := c.Decompress(compressed)
+			assert.NoError(t, err)
+			assert.Equal(t, data, decompressed)
+		})
+	}
+}
+
+func TestCompressFrame(t *testing.T) {
+	data := bytes.Repeat([]byte("yomo"), 100)
+
+	t.Run("compressed", func(t *testing.T) {
+		f := frame.NewDataFrame()
+		f.SetCarriage(1, data)
+		assert.NoError(t, CompressFrame(f, Zstd, 64))
+		assert.Equal(t, Zstd, f.Compression())
+		assert.NotEqual(t, data, f.GetCarriage())
+
+		// the compressed carriage is kept after encoding.
+		received, err := frame.DecodeToDataFrame(f.Encode())
+		assert.NoError(t, err)
+		carriage, err := Carriage(received)
+		assert.NoError(t, err)
+		assert.Equal(t, data, carriage)
+
+		assert.NoError(t, DecompressFrame(received))
+		assert.Empty(t, received.Compression())
+		assert.Equal(t, data, received.GetCarriage())
+	})
+
+	t.Run("smaller than threshold", func(t *testing.T) {
+		f := frame.NewDataFrame()
+		f.SetCarriage(1, []byte("yomo"))
+		assert.NoError(t, CompressFrame(f, Gzip, 64))
+		assert.Empty(t, f.Compression())
+		assert.Equal(t, []byte("yomo"), f.GetCarriage())
+	})
+
+	t.Run("unknown algorithm", func(t *testing.T) {
+		f := frame.NewDataFrame()
+		f.SetCarriage(1, data)
+		assert.Error(t, CompressFrame(f, "lz4", 0))
+		assert.Empty(t, f.Compression())
+	})
+}
+
+func TestAccepts(t *testing.T) {
+	assert.True(t, Accepts(nil, ""))
+	assert.True(t, Accepts([]string{Gzip, Zstd}, Zstd))
+	assert.False(t, Accepts(nil, Gzip))
+}
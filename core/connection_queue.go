@@ -0,0 +1,253 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metrics"
+	"github.com/yomorun/yomo/core/yerr"
+	"github.com/yomorun/yomo/pkg/logger"
+)
+
+// QueuePolicy decides what to do with the DataFrame when the send queue of a connection is full,
+// the control frames are never dropped.
+type QueuePolicy uint8
+
+const (
+	// QueuePolicyBlock blocks the writer until the queue has space.
+	QueuePolicyBlock QueuePolicy = iota
+	// QueuePolicyDropOldest drops the oldest DataFrame in the queue.
+	QueuePolicyDropOldest
+	// QueuePolicyDropNewest drops the DataFrame being written.
+	QueuePolicyDropNewest
+	// QueuePolicyDisconnect closes the connection.
+	QueuePolicyDisconnect
+)
+
+var queuePolicyStringMap = map[QueuePolicy]string{
+	QueuePolicyBlock:      "block",
+	QueuePolicyDropOldest: "drop-oldest",
+	QueuePolicyDropNewest: "drop-newest",
+	QueuePolicyDisconnect: "disconnect",
+}
+
+func (p QueuePolicy) String() string {
+	if s, ok := queuePolicyStringMap[p]; ok {
+		return s
+	}
+	return "unknown"
+}
+
+// ParseQueuePolicy parses the policy from string, It accepts
+// `block`, `drop-oldest`, `drop-newest` and `disconnect`.
+func ParseQueuePolicy(s string) (QueuePolicy, error) {
+	for p, name := range queuePolicyStringMap {
+		if strings.EqualFold(s, name) {
+			return p, nil
+		}
+	}
+	return QueuePolicyBlock, fmt.Errorf("unknown queue policy: %s", s)
+}
+
+// errQueueClosed be returned when writing to a closed queue.
+var errQueueClosed = errors.New("send queue is closed")
+
+// queuedConnection wraps a Connection with a bounded send queue,
+// frames are written to the underlying connection by its own goroutine,
+// so a slow peer does not block the writer.
+//
+// The policy applies to the DataFrames only, the control frames, e.g. GoawayFrame and BackflowFrame,
+// are never dropped, they wait in their own queue and are written before the queued DataFrames.
+type queuedConnection struct {
+	Connection
+	connID     string
+	policy     QueuePolicy
+	queue      chan frame.Frame
+	control    chan frame.Frame
+	disconnect func(code yerr.ErrorCode, msg string)
+	deadLetter func(f *frame.DataFrame, reason DeadLetterReason, err error)
+	mu         sync.Mutex
+	done       chan struct{}
+	closeOnce  sync.Once
+}
+
+// newQueuedConnection creates the queued connection, disconnect closes the connection of the client
+// and removes it from server when the queue is full with QueuePolicyDisconnect, the underlying
+// connection is closed if it is nil. deadLetter receives the DataFrames dropped by the queue
+// or failed to be written by the writer goroutine, It can be nil.
+func newQueuedConnection(
+	connID string,
+	conn Connection,
+	size int,
+	policy QueuePolicy,
+	disconnect func(code yerr.ErrorCode, msg string),
+	deadLetter func(f *frame.DataFrame, reason DeadLetterReason, err error),
+) *queuedConnection {
+	c := &queuedConnection{
+		Connection: conn,
+		connID:     connID,
+		policy:     policy,
+		queue:      make(chan frame.Frame, size),
+		control:    make(chan frame.Frame, size),
+		disconnect: disconnect,
+		deadLetter: deadLetter,
+		done:       make(chan struct{}),
+	}
+	go c.run()
+	return c
+}
+
+// Write puts the frame to the send queue, It handles the full queue according to the policy.
+func (c *queuedConnection) Write(f frame.Frame) error {
+	select {
+	case <-c.done:
+		return errQueueClosed
+	default:
+	}
+	// the control frames are never dropped.
+	df, ok := f.(*frame.DataFrame)
+	if !ok {
+		select {
+		case c.control <- f:
+			return nil
+		case <-c.done:
+			return errQueueClosed
+		}
+	}
+	// the DataFrame may be changed by the writer after it returns, e.g. the one dispatched to downstream zippers.
+	f = df.Clone()
+
+	switch c.policy {
+	case QueuePolicyBlock:
+		select {
+		case c.queue <- f:
+		case <-c.done:
+			return errQueueClosed
+		}
+	case QueuePolicyDropOldest:
+		// the lock keeps concurrent writers from dropping frames of each other.
+		c.mu.Lock()
+		for {
+			select {
+			case c.queue <- f:
+				c.mu.Unlock()
+				c.updateDepth()
+				return nil
+			default:
+			}
+			select {
+			case old := <-c.queue:
+				c.dropped(old)
+			default:
+			}
+		}
+	case QueuePolicyDropNewest:
+		select {
+		case c.queue <- f:
+		default:
+			c.dropped(f)
+			return nil
+		}
+	case QueuePolicyDisconnect:
+		select {
+		case c.queue <- f:
+		default:
+			c.countDropped()
+			logger.Warnf("%s[%s](%s) send queue is full, disconnect it", ServerLogPrefix, c.Name(), c.connID)
+			c.stop()
+			err := yerr.New(yerr.ErrorCodeQueueFull, fmt.Errorf("send queue of [%s] is full", c.Name()))
+			// the underlying connection may be blocked on writing, close it asynchronously.
+			if c.disconnect != nil {
+				go c.disconnect(yerr.ErrorCodeQueueFull, err.Error())
+			} else {
+				go c.Connection.Close()
+			}
+			return err
+		}
+	}
+	c.updateDepth()
+	return nil
+}
+
+// QueueLen returns the number of frames waiting in the send queue.
+func (c *queuedConnection) QueueLen() int {
+	return len(c.queue)
+}
+
+// Close stops the writer goroutine and closes the underlying connection,
+// the frames remained in the queue are discarded.
+func (c *queuedConnection) Close() error {
+	c.stop()
+	return c.Connection.Close()
+}
+
+// stop stops the writer goroutine, the queue will not accept frames anymore.
+func (c *queuedConnection) stop() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		metrics.ServerQueueDepth.DeleteMatch(metrics.LabelConnID, c.connID)
+	})
+}
+
+func (c *queuedConnection) run() {
+	for {
+		// the control frames are written first.
+		select {
+		case <-c.done:
+			return
+		case f := <-c.control:
+			c.write(f)
+			continue
+		default:
+		}
+		select {
+		case <-c.done:
+			return
+		case f := <-c.control:
+			c.write(f)
+		case f := <-c.queue:
+			c.updateDepth()
+			c.write(f)
+		}
+	}
+}
+
+// write writes the frame to the underlying connection, the DataFrames are counted as sent after written.
+func (c *queuedConnection) write(f frame.Frame) {
+	if err := c.Connection.Write(f); err != nil {
+		logger.Warnf("%s[%s](%s) write from send queue error: %v", ServerLogPrefix, c.Name(), c.connID, err)
+		metrics.ServerWriteErrors.Inc(c.connID, c.Name())
+		c.deadLetterOf(f, DeadLetterWriteFailed, err)
+		return
+	}
+	if df, ok := f.(*frame.DataFrame); ok {
+		tag := tagLabel(df.GetDataTag())
+		metrics.ServerFramesSent.Inc(c.connID, c.Name(), tag)
+		metrics.ServerBytesSent.Add(int64(len(df.GetCarriage())), c.connID, c.Name(), tag)
+	}
+}
+
+// dropped counts the frame dropped by the full queue and hands it to the dead letters.
+func (c *queuedConnection) dropped(f frame.Frame) {
+	c.countDropped()
+	c.deadLetterOf(f, DeadLetterQueueFull, errors.New("send queue is full"))
+}
+
+func (c *queuedConnection) countDropped() {
+	logger.Debugf("%s[%s](%s) send queue is full, drop a frame, policy=%s", ServerLogPrefix, c.Name(), c.connID, c.policy)
+	metrics.ServerQueueDropped.Inc(c.connID, c.Name())
+}
+
+// deadLetterOf hands the DataFrame failed to be delivered to the dead letters, other frames are discarded.
+func (c *queuedConnection) deadLetterOf(f frame.Frame, reason DeadLetterReason, err error) {
+	if df, ok := f.(*frame.DataFrame); ok && c.deadLetter != nil {
+		c.deadLetter(df, reason, err)
+	}
+}
+
+func (c *queuedConnection) updateDepth() {
+	metrics.ServerQueueDepth.Set(int64(len(c.queue)), c.connID, c.Name())
+}
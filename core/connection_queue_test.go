@@ -0,0 +1,211 @@
+package core
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metrics"
+	"github.com/yomorun/yomo/core/yerr"
+)
+
+// blockingStream blocks writing until it is released.
+type blockingStream struct {
+	mu      sync.Mutex
+	buf     *bytes.Buffer
+	release chan struct{}
+	closed  bool
+}
+
+func newBlockingStream() *blockingStream {
+	return &blockingStream{buf: bytes.NewBuffer(nil), release: make(chan struct{})}
+}
+
+func (s *blockingStream) Read(p []byte) (int, error) { return 0, nil }
+
+func (s *blockingStream) Write(p []byte) (int, error) {
+	<-s.release
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.Write(p)
+}
+
+func (s *blockingStream) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+func (s *blockingStream) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+func (s *blockingStream) bytes() []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.Bytes()
+}
+
+// failingStream fails all the writes.
+type failingStream struct{}
+
+func (failingStream) Read(p []byte) (int, error)  { return 0, io.EOF }
+func (failingStream) Write(p []byte) (int, error) { return 0, io.ErrClosedPipe }
+func (failingStream) Close() error                { return nil }
+
+func newTestDataFrame(tid string) *frame.DataFrame {
+	f := frame.NewDataFrame()
+	f.SetTransactionID(tid)
+	f.SetCarriage(frame.Tag(1), []byte(tid))
+	return f
+}
+
+func TestQueuedConnection(t *testing.T) {
+	tests := []struct {
+		policy  QueuePolicy
+		wantErr bool
+		want    []string
+		dropped []string
+	}{
+		{policy: QueuePolicyDropNewest, want: []string{"1", "2", "3"}, dropped: []string{"4"}},
+		{policy: QueuePolicyDropOldest, want: []string{"1", "3", "4"}, dropped: []string{"2"}},
+		{policy: QueuePolicyDisconnect, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			stream := newBlockingStream()
+			disconnected := make(chan yerr.ErrorCode, 1)
+			disconnect := func(code yerr.ErrorCode, msg string) { disconnected <- code }
+			var dropped []string
+			deadLetter := func(f *frame.DataFrame, reason DeadLetterReason, err error) {
+				assert.Equal(t, DeadLetterQueueFull, reason)
+				dropped = append(dropped, f.TransactionID())
+			}
+			connID := "conn-" + tt.policy.String()
+			conn := newQueuedConnection(connID, newConnection("sfn-1", "sfn-id-1", ClientTypeStreamFunction, nil, stream, nil), 2, tt.policy, disconnect, deadLetter)
+			defer conn.Close()
+
+			// the first frame is taken by the writer goroutine and blocked on the stream.
+			assert.NoError(t, conn.Write(newTestDataFrame("1")))
+			require.Eventually(t, func() bool { return conn.QueueLen() == 0 }, time.Second, time.Millisecond)
+
+			assert.NoError(t, conn.Write(newTestDataFrame("2")))
+			assert.NoError(t, conn.Write(newTestDataFrame("3")))
+			assert.Equal(t, 2, conn.QueueLen())
+
+			err := conn.Write(newTestDataFrame("4"))
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Equal(t, yerr.ErrorCodeQueueFull, err.(yerr.YomoError).ErrorCode())
+				assert.Error(t, conn.Write(newTestDataFrame("5")), "queue should not accept frames after disconnected")
+
+				select {
+				case code := <-disconnected:
+					assert.Equal(t, yerr.ErrorCodeQueueFull, code)
+				case <-time.After(time.Second):
+					t.Fatal("the client is not disconnected")
+				}
+				close(stream.release)
+				return
+			}
+			assert.NoError(t, err)
+			// the dropped DataFrames are handed to the dead letters.
+			assert.Equal(t, tt.dropped, dropped)
+
+			close(stream.release)
+
+			expected := []frame.Frame{}
+			for _, tid := range tt.want {
+				expected = append(expected, newTestDataFrame(tid))
+			}
+			require.Eventually(t, func() bool {
+				return bytes.Equal(composeFrametoBytes(expected...), stream.bytes())
+			}, time.Second, time.Millisecond)
+			assert.Equal(t, 0, conn.QueueLen())
+			// the dropped DataFrames are not counted as sent.
+			assert.Equal(t, int64(len(tt.want)), metrics.ServerFramesSent.Get(connID, "sfn-1", "1"))
+		})
+	}
+}
+
+func TestQueuedConnectionControlFrame(t *testing.T) {
+	stream := newBlockingStream()
+	var dropped []string
+	deadLetter := func(f *frame.DataFrame, reason DeadLetterReason, err error) {
+		dropped = append(dropped, f.TransactionID())
+	}
+	conn := newQueuedConnection("conn-control", newConnection("sfn-1", "sfn-id-1", ClientTypeStreamFunction, nil, stream, nil), 1, QueuePolicyDropNewest, nil, deadLetter)
+	defer conn.Close()
+
+	assert.NoError(t, conn.Write(newTestDataFrame("1")))
+	require.Eventually(t, func() bool { return conn.QueueLen() == 0 }, time.Second, time.Millisecond)
+	assert.NoError(t, conn.Write(newTestDataFrame("2")))
+
+	// the control frame is not dropped even though the queue is full, and written before the queued DataFrames.
+	goaway := frame.NewGoawayFrame("bye")
+	assert.NoError(t, conn.Write(goaway))
+	assert.NoError(t, conn.Write(newTestDataFrame("3")))
+	assert.Equal(t, []string{"3"}, dropped)
+
+	close(stream.release)
+
+	require.Eventually(t, func() bool {
+		return bytes.Equal(composeFrametoBytes(newTestDataFrame("1"), goaway, newTestDataFrame("2")), stream.bytes())
+	}, time.Second, time.Millisecond)
+}
+
+func TestQueuedConnectionCopiesDataFrame(t *testing.T) {
+	stream := newBlockingStream()
+	conn := newQueuedConnection("conn-1", newConnection("sfn-1", "sfn-id-1", ClientTypeStreamFunction, nil, stream, nil), 2, QueuePolicyBlock, nil, nil)
+	defer conn.Close()
+
+	// the DataFrame changed by the writer after queued is written as it was.
+	f := newTestDataFrame("1")
+	assert.NoError(t, conn.Write(f))
+	f.SetTransactionID("2")
+	f.SetCarriage(frame.Tag(1), []byte("2"))
+	close(stream.release)
+
+	require.Eventually(t, func() bool {
+		return bytes.Equal(newTestDataFrame("1").Encode(), stream.bytes())
+	}, time.Second, time.Millisecond)
+}
+
+func TestQueuedConnectionWriteFailed(t *testing.T) {
+	inner := newConnection("sfn-1", "sfn-id-1", ClientTypeStreamFunction, nil, failingStream{}, nil)
+
+	failed := make(chan string, 1)
+	deadLetter := func(f *frame.DataFrame, reason DeadLetterReason, err error) {
+		assert.Equal(t, DeadLetterWriteFailed, reason)
+		assert.Error(t, err)
+		failed <- f.TransactionID()
+	}
+	conn := newQueuedConnection("conn-1", inner, 2, QueuePolicyBlock, nil, deadLetter)
+	defer conn.Close()
+
+	assert.NoError(t, conn.Write(newTestDataFrame("1")))
+	select {
+	case tid := <-failed:
+		assert.Equal(t, "1", tid)
+	case <-time.After(time.Second):
+		t.Fatal("the DataFrame failed to be written is not handed to the dead letters")
+	}
+}
+
+func TestParseQueuePolicy(t *testing.T) {
+	policy, err := ParseQueuePolicy("drop-oldest")
+	assert.NoError(t, err)
+	assert.Equal(t, QueuePolicyDropOldest, policy)
+
+	_, err = ParseQueuePolicy("unknown")
+	assert.EqualError(t, err, "unknown queue policy: unknown")
+}
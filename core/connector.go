@@ -19,6 +19,8 @@ type Connector interface {
 	Get(connID string) Connection
 	// GetSnapshot gets the snapshot of all connections.
 	GetSnapshot() map[string]string
+	// GetConns gets all the connections, keyed by connection id.
+	GetConns() map[string]Connection
 	// GetSourceConns gets the connections by source observe tag.
 	GetSourceConns(sourceID string, tag frame.Tag) []Connection
 	// Clean the connector.
@@ -82,6 +84,16 @@ func (c *connector) GetSnapshot() map[string]string {
 	return result
 }
 
+// GetConns gets all the connections, keyed by connection id.
+func (c *connector) GetConns() map[string]Connection {
+	result := make(map[string]Connection)
+	c.conns.Range(func(key interface{}, val interface{}) bool {
+		result[key.(string)] = val.(Connection)
+		return true
+	})
+	return result
+}
+
 // Clean the connector.
 func (c *connector) Clean() {
 	c.conns.Range(func(key, value any) bool {
@@ -28,7 +28,7 @@ type Context struct {
 	mu sync.RWMutex
 }
 
-func newContext(conn quic.Connection, stream quic.Stream) (ctx *Context) {
+func newContext(conn quic.Connection, stream io.ReadWriteCloser) (ctx *Context) {
 	v := ctxPool.Get()
 	if v == nil {
 		ctx = new(Context)
@@ -67,13 +67,34 @@ func (c *Context) reset() {
 // CloseWithError closes the stream and cleans the context.
 func (c *Context) CloseWithError(code yerr.ErrorCode, msg string) {
 	logger.Debugf("%sconn[%s] context close, errCode=%#x, msg=%s", ServerLogPrefix, c.connID, code, msg)
-	if c.Stream != nil {
-		c.Stream.Close()
+	c.closer()(code, msg)
+	c.Clean()
+}
+
+// closer returns the function closing the stream and the connection of the context with the error code,
+// It can be called after the context is cleaned and reused by another connection.
+func (c *Context) closer() func(code yerr.ErrorCode, msg string) {
+	stream, conn := c.Stream, c.Conn
+	return func(code yerr.ErrorCode, msg string) {
+		if stream != nil {
+			stream.Close()
+		}
+		if conn != nil {
+			conn.CloseWithError(quic.ApplicationErrorCode(code), msg)
+		}
 	}
-	if c.Conn != nil {
-		c.Conn.CloseWithError(quic.ApplicationErrorCode(code), msg)
+}
+
+// clone returns a copy of the context which is not reused by the next frame of the stream.
+func (c *Context) clone() *Context {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	copied := &Context{Conn: c.Conn, connID: c.connID, Stream: c.Stream, Frame: c.Frame}
+	for k, v := range c.Keys {
+		copied.Set(k, v)
 	}
-	c.Clean()
+	return copied
 }
 
 // ConnID get quic connection id
@@ -0,0 +1,109 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metrics"
+	"github.com/yomorun/yomo/pkg/logger"
+)
+
+// maxDatagramSize is the max size of the encoded DataFrame sent as a datagram, It fits in
+// the smallest QUIC packet, so the datagram is not dropped by the MTU of the path.
+const maxDatagramSize = 1100
+
+// errDatagramOversized is returned when the encoded DataFrame is larger than maxDatagramSize.
+var errDatagramOversized = errors.New("DataFrame is too large to be sent as a datagram")
+
+// supportsDatagrams returns true if both sides of the connection enable datagrams.
+func supportsDatagrams(qconn quic.Connection) bool {
+	return qconn != nil && qconn.ConnectionState().SupportsDatagrams
+}
+
+// sendDatagram sends the DataFrame as an unreliable datagram, It returns errDatagramOversized
+// if the DataFrame is too large, then the caller sends it on the stream.
+func sendDatagram(qconn quic.Connection, f *frame.DataFrame) error {
+	buf := f.Encode()
+	if len(buf) > maxDatagramSize {
+		return errDatagramOversized
+	}
+	return qconn.SendMessage(buf)
+}
+
+// receiveDatagram receives a DataFrame sent as a datagram, the frames of other types are skipped.
+func receiveDatagram(qconn quic.Connection) (*frame.DataFrame, error) {
+	for {
+		msg, err := qconn.ReceiveMessage()
+		if err != nil {
+			return nil, err
+		}
+		f, err := ParseFrame(bytes.NewReader(msg))
+		if err != nil {
+			logger.Warnf("%sparse datagram error: %v", ServerLogPrefix, err)
+			continue
+		}
+		df, ok := f.(*frame.DataFrame)
+		if !ok {
+			logger.Warnf("%sunexpected frame %#x in datagram", ServerLogPrefix, f.Type())
+			continue
+		}
+		df.SetDatagram(true)
+		return df, nil
+	}
+}
+
+// datagramConnection wraps a Connection, the DataFrames received as datagrams are forwarded as datagrams,
+// other frames and the oversized DataFrames are written to the underlying connection.
+type datagramConnection struct {
+	Connection
+	connID string
+	qconn  quic.Connection
+}
+
+func newDatagramConnection(connID string, conn Connection, qconn quic.Connection) *datagramConnection {
+	return &datagramConnection{
+		Connection: conn,
+		connID:     connID,
+		qconn:      qconn,
+	}
+}
+
+// Write sends the DataFrame as a datagram if it is received as a datagram.
+func (c *datagramConnection) Write(f frame.Frame) error {
+	df, ok := f.(*frame.DataFrame)
+	if !ok || !df.IsDatagram() {
+		return c.Connection.Write(f)
+	}
+	err := sendDatagram(c.qconn, df)
+	if err == nil {
+		return nil
+	}
+	tag := tagLabel(df.GetDataTag())
+	if errors.Is(err, errDatagramOversized) {
+		metrics.ServerDatagramsOversized.Inc(c.connID, c.Name(), tag)
+		return c.Connection.Write(f)
+	}
+	// datagrams are unreliable, the failure is not an error of the connection.
+	metrics.ServerDatagramsDropped.Inc(c.connID, c.Name(), tag)
+	logger.Debugf("%s[%s](%s) send datagram error: %v", ServerLogPrefix, c.Name(), c.connID, err)
+	return nil
+}
+
+// receiveDatagrams handles the DataFrames sent as datagrams by the client, they are handled
+// as the ones on the handshake stream of the connection.
+func (s *Server) receiveDatagrams(qconn quic.Connection) {
+	for {
+		f, err := receiveDatagram(qconn)
+		if err != nil {
+			// the connection is closed, it is cleaned up by the handshake stream.
+			return
+		}
+		c := newContext(qconn, nil).WithFrame(f)
+		if !s.handleFrame(c) {
+			return
+		}
+		c.Clean()
+	}
+}
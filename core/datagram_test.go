@@ -0,0 +1,45 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+)
+
+// mockDatagramConn records the datagrams sent by SendMessage.
+type mockDatagramConn struct {
+	quic.Connection
+	sent [][]byte
+}
+
+func (c *mockDatagramConn) SendMessage(p []byte) error {
+	c.sent = append(c.sent, p)
+	return nil
+}
+
+func TestDatagramConnection(t *testing.T) {
+	qconn := &mockDatagramConn{}
+	stream := newStreamAssert([]byte{})
+	conn := newDatagramConnection("conn-1", newConnection("sfn-1", "sfn-1-id", ClientTypeStreamFunction, nil, stream, []frame.Tag{1}), qconn)
+
+	small := frame.NewDataFrame()
+	small.SetCarriage(1, []byte("yomo"))
+	small.SetDatagram(true)
+	assert.NoError(t, conn.Write(small))
+	assert.Equal(t, [][]byte{small.Encode()}, qconn.sent)
+
+	// the oversized DataFrame and the ones received on the stream are written to the stream.
+	large := frame.NewDataFrame()
+	large.SetCarriage(1, bytes.Repeat([]byte("yomo"), maxDatagramSize))
+	large.SetDatagram(true)
+	assert.NoError(t, conn.Write(large))
+	reliable := frame.NewDataFrame()
+	reliable.SetCarriage(1, []byte("yomo"))
+	assert.NoError(t, conn.Write(reliable))
+
+	assert.Len(t, qconn.sent, 1)
+	stream.writeEqual(t, composeFrametoBytes(large, reliable))
+}
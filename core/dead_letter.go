@@ -0,0 +1,133 @@
+package core
+
+import (
+	"sync"
+	"time"
+
+	"github.com/yomorun/yomo/core/compress"
+	"github.com/yomorun/yomo/core/envelope"
+	"github.com/yomorun/yomo/core/frame"
+)
+
+// DeadLetterReason is the reason why a DataFrame cannot be delivered.
+type DeadLetterReason string
+
+const (
+	// DeadLetterNoRoute means no stream function observes the data tag.
+	DeadLetterNoRoute DeadLetterReason = "no_route"
+	// DeadLetterWriteFailed means writing to the stream function failed.
+	DeadLetterWriteFailed DeadLetterReason = "write_failed"
+	// DeadLetterQueueFull means the DataFrame is dropped because the send queue of the connection is full.
+	DeadLetterQueueFull DeadLetterReason = "queue_full"
+	// DeadLetterCorrupted means the DataFrame buffered for the offline stream function cannot be decoded,
+	// the carriage of the dead letter is the record read from the buffer.
+	DeadLetterCorrupted DeadLetterReason = "corrupted"
+)
+
+// DeadLetter is a DataFrame the zipper failed to deliver, It carries the MetaFrame
+// and the carriage of the original DataFrame with the reason.
+type DeadLetter struct {
+	Time   time.Time        `json:"time"`
+	Reason DeadLetterReason `json:"reason"`
+	Error  string           `json:"error,omitempty"`
+	// ConnID and Name are the stream function failed to be written.
+	ConnID string `json:"conn_id,omitempty"`
+	Name   string `json:"name,omitempty"`
+	// the original DataFrame.
+	Tag           frame.Tag `json:"tag"`
+	TransactionID string    `json:"tid"`
+	SourceID      string    `json:"source_id"`
+	Broadcast     bool      `json:"broadcast,omitempty"`
+	Metadata      []byte    `json:"metadata,omitempty"`
+	Carriage      []byte    `json:"carriage"`
+	// KeyID is the id of the key sealed the carriage, Compression is the algorithm compressed it,
+	// the carriage is kept as is if it is sealed, otherwise it is decompressed.
+	KeyID       string `json:"key_id,omitempty"`
+	Compression string `json:"compression,omitempty"`
+	// Meta is the encoded MetaFrame of the original DataFrame, It keeps the trace context,
+	// the zippers visited and the origin zipper besides the fields above.
+	Meta []byte `json:"meta,omitempty"`
+}
+
+func newDeadLetter(f *frame.DataFrame, reason DeadLetterReason, connID, name string, err error) *DeadLetter {
+	d := &DeadLetter{
+		Time:          time.Now(),
+		Reason:        reason,
+		ConnID:        connID,
+		Name:          name,
+		Tag:           f.GetDataTag(),
+		TransactionID: f.TransactionID(),
+		SourceID:      f.SourceID(),
+		Broadcast:     f.IsBroadcast(),
+		Metadata:      f.GetMetaFrame().Metadata(),
+		Meta:          f.GetMetaFrame().Encode(),
+	}
+	if err != nil {
+		d.Error = err.Error()
+	}
+	d.Carriage, d.KeyID = f.GetCarriage(), f.GetMetaFrame().KeyID()
+	if envelope.Sealed(f) {
+		d.Compression = f.Compression()
+	} else if carriage, err := compress.Carriage(f); err == nil {
+		d.Carriage = carriage
+	} else {
+		d.Compression = f.Compression()
+	}
+	return d
+}
+
+// Frame rebuilds the original DataFrame, It can be used to redeliver the dead letter.
+func (d *DeadLetter) Frame() *frame.DataFrame {
+	f := frame.NewDataFrame()
+	f.SetCarriage(d.Tag, d.Carriage)
+	// the dead letters without the MetaFrame, e.g. the corrupted ones, are rebuilt from the fields.
+	if meta, err := frame.DecodeToMetaFrame(d.Meta); len(d.Meta) > 0 && err == nil {
+		f.SetMetaFrame(meta)
+	} else {
+		f.SetTransactionID(d.TransactionID)
+		f.SetSourceID(d.SourceID)
+		f.SetBroadcast(d.Broadcast)
+		f.GetMetaFrame().SetMetadata(d.Metadata)
+		f.GetMetaFrame().SetKeyID(d.KeyID)
+	}
+	f.SetCompression(d.Compression)
+	return f
+}
+
+// DeadLetterHandler handles the dead letters, It is called in the frame handling goroutine,
+// or the writer goroutine of the send queue, so it should not block.
+type DeadLetterHandler func(d *DeadLetter)
+
+// deadLetterRing keeps the latest dead letters in memory.
+type deadLetterRing struct {
+	mu      sync.Mutex
+	entries []*DeadLetter
+	next    int
+	full    bool
+}
+
+func newDeadLetterRing(size int) *deadLetterRing {
+	return &deadLetterRing{entries: make([]*DeadLetter, size)}
+}
+
+func (r *deadLetterRing) add(d *DeadLetter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries[r.next] = d
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// list returns the dead letters from the oldest to the latest.
+func (r *deadLetterRing) list() []*DeadLetter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.full {
+		return append([]*DeadLetter{}, r.entries[:r.next]...)
+	}
+	return append(append([]*DeadLetter{}, r.entries[r.next:]...), r.entries[:r.next]...)
+}
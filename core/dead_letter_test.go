@@ -0,0 +1,119 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/core/router"
+	"github.com/yomorun/yomo/pkg/config"
+)
+
+func TestDeadLetterRing(t *testing.T) {
+	ring := newDeadLetterRing(2)
+	assert.Empty(t, ring.list())
+
+	for _, tid := range []string{"tid-1", "tid-2", "tid-3"} {
+		ring.add(&DeadLetter{TransactionID: tid})
+	}
+
+	letters := ring.list()
+	assert.Len(t, letters, 2)
+	assert.Equal(t, "tid-2", letters[0].TransactionID)
+	assert.Equal(t, "tid-3", letters[1].TransactionID)
+}
+
+func TestDeadLetterFrame(t *testing.T) {
+	f := frame.NewDataFrame()
+	f.SetCarriage(frame.Tag(1), []byte("hello yomo"))
+	f.SetSourceID("source-1")
+	f.GetMetaFrame().SetMetadata([]byte("tenant-a"))
+	f.GetMetaFrame().SetTraceID("trace-1")
+	f.GetMetaFrame().SetSpanID("span-1")
+	f.GetMetaFrame().Visit("zipper-1")
+	f.GetMetaFrame().SetOrigin("zipper-1")
+
+	d := newDeadLetter(f, DeadLetterWriteFailed, "conn-1", "sfn-1", errors.New("closed"))
+	assert.Equal(t, "closed", d.Error)
+	assert.Equal(t, f.Encode(), d.Frame().Encode())
+
+	// the dead letter without the MetaFrame is rebuilt from the fields.
+	d.Meta = nil
+	restored := d.Frame()
+	assert.Equal(t, f.TransactionID(), restored.TransactionID())
+	assert.Equal(t, []byte("tenant-a"), restored.GetMetaFrame().Metadata())
+	assert.Empty(t, restored.GetMetaFrame().TraceID())
+}
+
+func TestHandleDeadLetter(t *testing.T) {
+	metadataBuilder := metadata.DefaultBuilder()
+	routers := router.Default([]config.App{{Name: "sfn-dlq"}})
+
+	dlqStream := newStreamAssert([]byte{})
+	sourceStream := newStreamAssert([]byte{})
+	connector := buildMockConnector(routers, metadataBuilder, []mockConnectorArgs{
+		{
+			name:        "sfn-dlq",
+			clientID:    "sfn-dlq-id",
+			clientType:  byte(ClientTypeStreamFunction),
+			obversedTag: 0xF0,
+			connID:      "sfn-dlq-conn-id",
+			stream:      dlqStream,
+		},
+		{
+			name:        "source-1",
+			clientID:    "source-conn-id",
+			clientType:  byte(ClientTypeSource),
+			obversedTag: 2,
+			connID:      "source-conn-id",
+			stream:      sourceStream,
+		},
+	})
+	defer connector.Clean()
+
+	handled := []*DeadLetter{}
+	server := &Server{connector: connector}
+	server.Init(
+		WithDeadLetterRing(10),
+		WithDeadLetterTag(0xF0),
+		WithDeadLetterHandler(func(d *DeadLetter) { handled = append(handled, d) }),
+	)
+	server.ConfigRouter(routers)
+	server.ConfigMetadataBuilder(metadataBuilder)
+
+	t.Run("no route", func(t *testing.T) {
+		dataFrame := frame.NewDataFrame()
+		dataFrame.SetCarriage(1, []byte("hello yomo"))
+		dataFrame.SetSourceID("source-conn-id")
+
+		c := &Context{connID: "source-conn-id", Frame: dataFrame}
+		assert.NoError(t, server.handleDataFrame(c))
+
+		letters := server.DeadLetters()
+		assert.Len(t, letters, 1)
+		assert.Equal(t, DeadLetterNoRoute, letters[0].Reason)
+		assert.Equal(t, dataFrame.TransactionID(), letters[0].TransactionID)
+		assert.Equal(t, letters, handled)
+
+		// the dead letter is routed to the stream function observing the dead letter tag.
+		buf, _ := json.Marshal(letters[0])
+		dlqFrame := frame.NewDataFrame()
+		dlqFrame.SetCarriage(0xF0, buf)
+		dlqFrame.SetTransactionID(dataFrame.TransactionID())
+		dlqFrame.SetSourceID("source-conn-id")
+		dlqStream.writeEqual(t, dlqFrame.Encode())
+	})
+
+	t.Run("backflow is not dead letter", func(t *testing.T) {
+		dataFrame := frame.NewDataFrame()
+		dataFrame.SetCarriage(2, []byte("hello yomo"))
+		dataFrame.SetSourceID("source-conn-id")
+
+		c := &Context{connID: "sfn-dlq-conn-id", Frame: dataFrame}
+		assert.NoError(t, server.handleDataFrame(c))
+		assert.Len(t, server.DeadLetters(), 1)
+	})
+}
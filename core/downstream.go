@@ -0,0 +1,126 @@
+package core
+
+import (
+	"sort"
+
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/pkg/logger"
+)
+
+// TagFilter filters the data tags of the DataFrames forwarded to a downstream zipper.
+type TagFilter struct {
+	// Allow is the tags forwarded, all the tags are forwarded if it is empty.
+	Allow []frame.Tag
+	// Deny is the tags not forwarded, It takes precedence over Allow.
+	Deny []frame.Tag
+}
+
+// Accept returns true if the DataFrame of the tag is forwarded, the nil filter accepts all the tags.
+func (f *TagFilter) Accept(tag frame.Tag) bool {
+	if f == nil {
+		return true
+	}
+	if containsTag(f.Deny, tag) {
+		return false
+	}
+	return len(f.Allow) == 0 || containsTag(f.Allow, tag)
+}
+
+// tagAdvertiser is the downstream advertising the data tags observed by it, ok is false
+// if it does not advertise them, then all the DataFrames are forwarded to it.
+type tagAdvertiser interface {
+	AdvertisedTags() (tags []frame.Tag, ok bool)
+}
+
+func containsTag(tags []frame.Tag, tag frame.Tag) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// ConfigDownstreamFilter sets the tag filter of the downstream zipper of addr, the nil filter removes it.
+func (s *Server) ConfigDownstreamFilter(addr string, filter *TagFilter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.downstreamFilters == nil {
+		s.downstreamFilters = make(map[string]*TagFilter)
+	}
+	if filter == nil {
+		delete(s.downstreamFilters, addr)
+	} else {
+		s.downstreamFilters[addr] = filter
+	}
+}
+
+// forwardsTo returns true if the DataFrame of the tag is forwarded to the downstream zipper,
+// by the tag filter of it and the tags advertised by it.
+func (s *Server) forwardsTo(addr string, ds frame.Writer, tag frame.Tag) bool {
+	s.mu.Lock()
+	filter := s.downstreamFilters[addr]
+	s.mu.Unlock()
+
+	if !filter.Accept(tag) {
+		return false
+	}
+	if a, ok := ds.(tagAdvertiser); ok {
+		if tags, ok := a.AdvertisedTags(); ok {
+			return containsTag(tags, tag)
+		}
+	}
+	return true
+}
+
+// advertisesTags returns true if the server advertises the data tags observed by it to upstream zippers,
+// It does not if it relays the DataFrames to its own downstream zippers, which need all of them.
+func (s *Server) advertisesTags() bool {
+	return s.opts.MaxHops <= 1
+}
+
+// observedTags returns the data tags observed by the stream functions, including the offline
+// ones buffered by store-and-forward, and the local subscribers.
+func (s *Server) observedTags() []frame.Tag {
+	set := make(map[frame.Tag]struct{})
+	for connID, conn := range s.connector.GetConns() {
+		if _, ok := s.subscribers.Load(connID); ok || conn.ClientType() == ClientTypeStreamFunction {
+			for _, tag := range conn.ObserveDataTags() {
+				set[tag] = struct{}{}
+			}
+		}
+	}
+	if s.stores != nil {
+		for _, tag := range s.stores.tags() {
+			set[tag] = struct{}{}
+		}
+	}
+
+	tags := make([]frame.Tag, 0, len(set))
+	for tag := range set {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i] < tags[j] })
+	return tags
+}
+
+// advertiseTags writes the data tags observed by the server to the upstream zippers asked for them,
+// It is called when the stream functions or the subscribers are changed.
+func (s *Server) advertiseTags() {
+	var f *frame.ObservedTagsFrame
+	s.advertisees.Range(func(key, _ interface{}) bool {
+		connID := key.(string)
+		conn := s.connector.Get(connID)
+		if conn == nil {
+			return true
+		}
+		if f == nil {
+			f = frame.NewObservedTagsFrame(s.observedTags())
+		}
+		if err := conn.Write(f); err != nil {
+			logger.Errorf("%sadvertise tags to upstream zipper [%s](%s) error: %v", ServerLogPrefix, conn.Name(), connID, err)
+		}
+		return true
+	})
+}
@@ -0,0 +1,130 @@
+// Package envelope encrypts the carriage of DataFrames end to end, between a source and
+// the stream functions sharing the key with it.
+//
+// The carriage is sealed by AES-GCM with the key shared out of band, the id of the key is
+// recorded in the MetaFrame, so the zippers route the DataFrame by its tag without the key,
+// and the receiver opens it by the key of the id. The data tag and the key id are
+// authenticated, so the sealed carriage cannot be moved to another tag.
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/yomorun/yomo/core/frame"
+)
+
+// ErrUnknownKey is returned when the key of the id is not in the Keyring.
+var ErrUnknownKey = errors.New("envelope: unknown key")
+
+// Keyring holds the keys by id, It is safe for concurrent use.
+type Keyring struct {
+	mu   sync.RWMutex
+	keys map[string]cipher.AEAD
+}
+
+// NewKeyring creates an empty Keyring.
+func NewKeyring() *Keyring {
+	return &Keyring{keys: make(map[string]cipher.AEAD)}
+}
+
+// Add adds the key of the id, the key must be 16, 24 or 32 bytes to select
+// AES-128, AES-192 or AES-256, the key of the same id is replaced.
+func (k *Keyring) Add(keyID string, key []byte) error {
+	if keyID == "" {
+		return errors.New("envelope: empty key id")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	k.keys[keyID] = aead
+	k.mu.Unlock()
+	return nil
+}
+
+// Has returns true if the Keyring has the key of the id.
+func (k *Keyring) Has(keyID string) bool {
+	_, ok := k.get(keyID)
+	return ok
+}
+
+func (k *Keyring) get(keyID string) (cipher.AEAD, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	aead, ok := k.keys[keyID]
+	return aead, ok
+}
+
+// Seal encrypts the carriage of DataFrame in place with the key of the id.
+func (k *Keyring) Seal(f *frame.DataFrame, keyID string) error {
+	if Sealed(f) {
+		return errors.New("envelope: DataFrame is already sealed")
+	}
+	aead, ok := k.get(keyID)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownKey, keyID)
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	sealed := aead.Seal(nonce, nonce, f.GetCarriage(), additionalData(f.GetDataTag(), keyID))
+
+	compression := f.Compression()
+	f.SetCarriage(f.GetDataTag(), sealed)
+	f.SetCompression(compression)
+	f.GetMetaFrame().SetKeyID(keyID)
+	return nil
+}
+
+// Open decrypts the carriage of DataFrame in place, the key id is kept in the MetaFrame,
+// so the DataFrame produced from it can be sealed by the same key.
+func (k *Keyring) Open(f *frame.DataFrame) error {
+	if !Sealed(f) {
+		return nil
+	}
+	keyID := f.GetMetaFrame().KeyID()
+	aead, ok := k.get(keyID)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownKey, keyID)
+	}
+	sealed := f.GetCarriage()
+	if len(sealed) < aead.NonceSize() {
+		return errors.New("envelope: sealed carriage is too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	carriage, err := aead.Open(nil, nonce, ciphertext, additionalData(f.GetDataTag(), keyID))
+	if err != nil {
+		return err
+	}
+
+	compression := f.Compression()
+	f.SetCarriage(f.GetDataTag(), carriage)
+	f.SetCompression(compression)
+	return nil
+}
+
+// Sealed returns true if the DataFrame carries a key id, the carriage of the DataFrame
+// read from the connection is sealed if it is true.
+func Sealed(f *frame.DataFrame) bool {
+	return f.GetMetaFrame().KeyID() != ""
+}
+
+func additionalData(tag frame.Tag, keyID string) []byte {
+	buf := make([]byte, 4, 4+len(keyID))
+	binary.BigEndian.PutUint32(buf, uint32(tag))
+	return append(buf, keyID...)
+}
@@ -0,0 +1,87 @@
+package envelope
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/compress"
+	"github.com/yomorun/yomo/core/frame"
+)
+
+var testKey = bytes.Repeat([]byte{0x01}, 32)
+
+func TestSealAndOpen(t *testing.T) {
+	keyring := NewKeyring()
+	assert.NoError(t, keyring.Add("key-1", testKey))
+	assert.True(t, keyring.Has("key-1"))
+
+	f := frame.NewDataFrame()
+	f.SetCarriage(1, []byte("yomo"))
+	assert.NoError(t, keyring.Seal(f, "key-1"))
+	assert.True(t, Sealed(f))
+	assert.NotEqual(t, []byte("yomo"), f.GetCarriage())
+	assert.Error(t, keyring.Seal(f, "key-1"))
+
+	// the key id is carried to the receiver.
+	received, err := frame.DecodeToDataFrame(f.Encode())
+	assert.NoError(t, err)
+	assert.Equal(t, "key-1", received.GetMetaFrame().KeyID())
+
+	assert.NoError(t, keyring.Open(received))
+	assert.Equal(t, []byte("yomo"), received.GetCarriage())
+	assert.Equal(t, "key-1", received.GetMetaFrame().KeyID())
+}
+
+func TestSealCompressed(t *testing.T) {
+	keyring := NewKeyring()
+	assert.NoError(t, keyring.Add("key-1", testKey))
+
+	data := bytes.Repeat([]byte("yomo"), 100)
+	f := frame.NewDataFrame()
+	f.SetCarriage(1, data)
+	assert.NoError(t, compress.CompressFrame(f, compress.Gzip, 0))
+	assert.NoError(t, keyring.Seal(f, "key-1"))
+	assert.Equal(t, compress.Gzip, f.Compression())
+
+	assert.NoError(t, keyring.Open(f))
+	assert.Equal(t, compress.Gzip, f.Compression())
+	assert.NoError(t, compress.DecompressFrame(f))
+	assert.Equal(t, data, f.GetCarriage())
+}
+
+func TestOpenError(t *testing.T) {
+	keyring := NewKeyring()
+	assert.NoError(t, keyring.Add("key-1", testKey))
+	assert.Error(t, keyring.Add("key-2", []byte("short")))
+
+	t.Run("unknown key", func(t *testing.T) {
+		f := frame.NewDataFrame()
+		f.SetCarriage(1, []byte("yomo"))
+		err := keyring.Seal(f, "key-2")
+		assert.True(t, errors.Is(err, ErrUnknownKey))
+
+		assert.NoError(t, keyring.Seal(f, "key-1"))
+		err = NewKeyring().Open(f)
+		assert.True(t, errors.Is(err, ErrUnknownKey))
+	})
+
+	t.Run("moved to another tag", func(t *testing.T) {
+		f := frame.NewDataFrame()
+		f.SetCarriage(1, []byte("yomo"))
+		assert.NoError(t, keyring.Seal(f, "key-1"))
+
+		moved := frame.NewDataFrame()
+		moved.SetCarriage(2, f.GetCarriage())
+		moved.GetMetaFrame().SetKeyID("key-1")
+		assert.Error(t, keyring.Open(moved))
+	})
+
+	t.Run("not sealed", func(t *testing.T) {
+		f := frame.NewDataFrame()
+		f.SetCarriage(1, []byte("yomo"))
+		assert.NoError(t, keyring.Open(f))
+		assert.Equal(t, []byte("yomo"), f.GetCarriage())
+	})
+}
@@ -9,6 +9,13 @@ import (
 type BackflowFrame struct {
 	Tag      Tag
 	Carriage []byte
+	// TID is the transaction id of the DataFrame the result is for.
+	TID string
+	// KeyID is the id of the key sealed the carriage, it is empty if the carriage is not sealed.
+	KeyID string
+	// Compression is the compression of the sealed carriage, zipper decompresses the carriage
+	// unless it is sealed.
+	Compression string
 }
 
 // NewBackflowFrame creates a new BackflowFrame with a given tag and carriage
@@ -30,6 +37,24 @@ func (f *BackflowFrame) SetCarriage(buf []byte) *BackflowFrame {
 	return f
 }
 
+// SetTransactionID sets the transaction id of the DataFrame the result is for.
+func (f *BackflowFrame) SetTransactionID(tid string) *BackflowFrame {
+	f.TID = tid
+	return f
+}
+
+// TransactionID returns the transaction id of the DataFrame the result is for.
+func (f *BackflowFrame) TransactionID() string {
+	return f.TID
+}
+
+// SetKeyID sets the id of the key sealed the carriage and the compression of it.
+func (f *BackflowFrame) SetKeyID(keyID string, compression string) *BackflowFrame {
+	f.KeyID = keyID
+	f.Compression = compression
+	return f
+}
+
 // Encode to Y3 encoded bytes
 func (f *BackflowFrame) Encode() []byte {
 	tag := y3.NewPrimitivePacketEncoder(byte(TagOfBackflowDataTag))
@@ -41,6 +66,22 @@ func (f *BackflowFrame) Encode() []byte {
 	node := y3.NewNodePacketEncoder(byte(TagOfBackflowFrame))
 	node.AddPrimitivePacket(tag)
 	node.AddPrimitivePacket(carriage)
+	// the transaction id is omitted if empty, so the frame is the same as the one of the former versions.
+	if f.TID != "" {
+		tid := y3.NewPrimitivePacketEncoder(byte(TagOfBackflowTID))
+		tid.SetStringValue(f.TID)
+		node.AddPrimitivePacket(tid)
+	}
+	if f.KeyID != "" {
+		keyID := y3.NewPrimitivePacketEncoder(byte(TagOfBackflowKeyID))
+		keyID.SetStringValue(f.KeyID)
+		node.AddPrimitivePacket(keyID)
+	}
+	if f.Compression != "" {
+		compression := y3.NewPrimitivePacketEncoder(byte(TagOfBackflowCompression))
+		compression.SetStringValue(f.Compression)
+		node.AddPrimitivePacket(compression)
+	}
 	return node.Encode()
 }
 
@@ -75,5 +116,29 @@ func DecodeToBackflowFrame(buf []byte) (*BackflowFrame, error) {
 		payload.Carriage = p.GetValBuf()
 	}
 
+	if p, ok := nodeBlock.PrimitivePackets[byte(TagOfBackflowTID)]; ok {
+		tid, err := p.ToUTF8String()
+		if err != nil {
+			return nil, err
+		}
+		payload.TID = tid
+	}
+
+	if p, ok := nodeBlock.PrimitivePackets[byte(TagOfBackflowKeyID)]; ok {
+		keyID, err := p.ToUTF8String()
+		if err != nil {
+			return nil, err
+		}
+		payload.KeyID = keyID
+	}
+
+	if p, ok := nodeBlock.PrimitivePackets[byte(TagOfBackflowCompression)]; ok {
+		compression, err := p.ToUTF8String()
+		if err != nil {
+			return nil, err
+		}
+		payload.Compression = compression
+	}
+
 	return payload, nil
 }
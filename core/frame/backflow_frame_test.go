@@ -31,3 +31,24 @@ func TestBackflowFrameDecode(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, df, f)
 }
+
+func TestBackflowFrameTransactionID(t *testing.T) {
+	f := NewBackflowFrame(Tag(22), []byte("hello backflow")).SetTransactionID("tid-1")
+
+	df, err := DecodeToBackflowFrame(f.Encode())
+
+	assert.NoError(t, err)
+	assert.Equal(t, "tid-1", df.TransactionID())
+	assert.Equal(t, f, df)
+}
+
+func TestBackflowFrameKeyID(t *testing.T) {
+	f := NewBackflowFrame(Tag(22), []byte("sealed")).SetTransactionID("tid-1").SetKeyID("key-1", "gzip")
+
+	df, err := DecodeToBackflowFrame(f.Encode())
+
+	assert.NoError(t, err)
+	assert.Equal(t, "key-1", df.KeyID)
+	assert.Equal(t, "gzip", df.Compression)
+	assert.Equal(t, f, df)
+}
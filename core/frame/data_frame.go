@@ -11,6 +11,7 @@ import (
 type DataFrame struct {
 	metaFrame    *MetaFrame
 	payloadFrame *PayloadFrame
+	datagram     bool
 }
 
 // String method implements fmt %v
@@ -52,6 +53,27 @@ func (d *DataFrame) GetCarriage() []byte {
 	return d.payloadFrame.Carriage
 }
 
+// SetCompression set the algorithm compressed the carriage, It is reset by SetCarriage.
+func (d *DataFrame) SetCompression(compression string) {
+	d.payloadFrame.Compression = compression
+}
+
+// Compression return the algorithm compressed the carriage, It is empty if not compressed.
+func (d *DataFrame) Compression() string {
+	return d.payloadFrame.Compression
+}
+
+// SetDatagram set the DataFrame to be sent as an unreliable QUIC datagram, It is not encoded,
+// the DataFrame received as a datagram is set too, so it is forwarded as a datagram.
+func (d *DataFrame) SetDatagram(datagram bool) {
+	d.datagram = datagram
+}
+
+// IsDatagram return true if the DataFrame is sent or received as a QUIC datagram.
+func (d *DataFrame) IsDatagram() bool {
+	return d.datagram
+}
+
 // TransactionID return transactionID string
 func (d *DataFrame) TransactionID() string {
 	return d.metaFrame.TransactionID()
@@ -67,6 +89,18 @@ func (d *DataFrame) GetMetaFrame() *MetaFrame {
 	return d.metaFrame
 }
 
+// SetMetaFrame set the MetaFrame.
+func (d *DataFrame) SetMetaFrame(m *MetaFrame) {
+	d.metaFrame = m
+}
+
+// Clone returns a copy of the DataFrame with a copy of MetaFrame, the carriage is shared.
+func (d *DataFrame) Clone() *DataFrame {
+	clone := *d
+	clone.metaFrame = d.metaFrame.Clone()
+	return &clone
+}
+
 // GetDataTag return the Tag of user's data
 func (d *DataFrame) GetDataTag() Tag {
 	return d.payloadFrame.Tag
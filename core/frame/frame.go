@@ -81,13 +81,23 @@ const (
 	TagOfTransactionID Type = 0x01
 	TagOfSourceID      Type = 0x02
 	TagOfBroadcast     Type = 0x04
+	TagOfTraceID       Type = 0x05
+	TagOfSpanID        Type = 0x06
+	TagOfKeyID         Type = 0x07
+	TagOfHops          Type = 0x08
+	TagOfVisited       Type = 0x09
+	TagOfOrigin        Type = 0x0A
 	// PayloadFrame of DataFrame
-	TagOfPayloadFrame     Type = 0x2E
-	TagOfPayloadDataTag   Type = 0x01
-	TagOfPayloadCarriage  Type = 0x02
-	TagOfBackflowFrame    Type = 0x2D
-	TagOfBackflowDataTag  Type = 0x01
-	TagOfBackflowCarriage Type = 0x02
+	TagOfPayloadFrame        Type = 0x2E
+	TagOfPayloadDataTag      Type = 0x01
+	TagOfPayloadCarriage     Type = 0x02
+	TagOfPayloadCompression  Type = 0x03
+	TagOfBackflowFrame       Type = 0x2D
+	TagOfBackflowDataTag     Type = 0x01
+	TagOfBackflowCarriage    Type = 0x02
+	TagOfBackflowTID         Type = 0x03
+	TagOfBackflowKeyID       Type = 0x04
+	TagOfBackflowCompression Type = 0x05
 
 	TagOfTokenFrame Type = 0x3E
 	// HandshakeFrame
@@ -98,18 +108,27 @@ const (
 	TagOfHandshakeAuthName        Type = 0x04
 	TagOfHandshakeAuthPayload     Type = 0x05
 	TagOfHandshakeObserveDataTags Type = 0x06
+	TagOfHandshakeCompressions    Type = 0x07
+	TagOfHandshakeStreamPerTag    Type = 0x08
+	TagOfHandshakeAdvertiseTags   Type = 0x09
 
 	TagOfPingFrame       Type = 0x3C
 	TagOfPongFrame       Type = 0x3B
 	TagOfAcceptedFrame   Type = 0x3A
 	TagOfRejectedFrame   Type = 0x39
+	TagOfRejectedCode    Type = 0x01
 	TagOfRejectedMessage Type = 0x02
 	// GoawayFrame
 	TagOfGoawayFrame   Type = 0x30
 	TagOfGoawayCode    Type = 0x01
 	TagOfGoawayMessage Type = 0x02
 	// TagOfHandshakeAckFrame
-	TagOfHandshakeAckFrame Type = 0x29
+	TagOfHandshakeAckFrame         Type = 0x29
+	TagOfHandshakeAckStreamPerTag  Type = 0x01
+	TagOfHandshakeAckAdvertiseTags Type = 0x02
+	// ObservedTagsFrame
+	TagOfObservedTagsFrame Type = 0x28
+	TagOfObservedTags      Type = 0x01
 )
 
 // Type represents the type of frame.
@@ -156,6 +175,8 @@ func (f Type) String() string {
 		return "HandshakeType"
 	case TagOfHandshakeAckFrame:
 		return "TagOfHandshakeAckFrame"
+	case TagOfObservedTagsFrame:
+		return "ObservedTagsFrame"
 	default:
 		return "UnknownFrame"
 	}
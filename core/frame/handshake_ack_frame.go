@@ -4,7 +4,13 @@ import "github.com/yomorun/y3"
 
 // HandshakeAckFrame is a Y3 encoded bytes,
 // It used to ack handshake.
-type HandshakeAckFrame struct{}
+type HandshakeAckFrame struct {
+	// StreamPerTag is true if the server accepts the DataFrames of each tag on their own
+	// unidirectional stream, and writes them to the client in the same way.
+	StreamPerTag bool
+	// AdvertiseTags is true if the server advertises the data tags observed by it with ObservedTagsFrame.
+	AdvertiseTags bool
+}
 
 // NewHandshakeAckFrame returns a HandshakeAckFrame.
 func NewHandshakeAckFrame() *HandshakeAckFrame {
@@ -19,6 +25,17 @@ func (f *HandshakeAckFrame) Type() Type {
 // Encode encodes HandshakeAckFrame to Y3 encoded bytes.
 func (f *HandshakeAckFrame) Encode() []byte {
 	ack := y3.NewNodePacketEncoder(byte(f.Type()))
+	// the servers not supporting it ack with an empty frame, so the clients fall back to a single stream.
+	if f.StreamPerTag {
+		streamPerTagBlock := y3.NewPrimitivePacketEncoder(byte(TagOfHandshakeAckStreamPerTag))
+		streamPerTagBlock.SetBoolValue(true)
+		ack.AddPrimitivePacket(streamPerTagBlock)
+	}
+	if f.AdvertiseTags {
+		advertiseTagsBlock := y3.NewPrimitivePacketEncoder(byte(TagOfHandshakeAckAdvertiseTags))
+		advertiseTagsBlock.SetBoolValue(true)
+		ack.AddPrimitivePacket(advertiseTagsBlock)
+	}
 
 	return ack.Encode()
 }
@@ -31,5 +48,21 @@ func DecodeToHandshakeAckFrame(buf []byte) (*HandshakeAckFrame, error) {
 		return nil, err
 	}
 
-	return &HandshakeAckFrame{}, nil
+	ack := &HandshakeAckFrame{}
+	if streamPerTagBlock, ok := node.PrimitivePackets[byte(TagOfHandshakeAckStreamPerTag)]; ok {
+		streamPerTag, err := streamPerTagBlock.ToBool()
+		if err != nil {
+			return nil, err
+		}
+		ack.StreamPerTag = streamPerTag
+	}
+	if advertiseTagsBlock, ok := node.PrimitivePackets[byte(TagOfHandshakeAckAdvertiseTags)]; ok {
+		advertiseTags, err := advertiseTagsBlock.ToBool()
+		if err != nil {
+			return nil, err
+		}
+		ack.AdvertiseTags = advertiseTags
+	}
+
+	return ack, nil
 }
@@ -20,3 +20,22 @@ func TestHandshakeAckFrameDecode(t *testing.T) {
 	assert.Equal(t, TagOfHandshakeAckFrame, f.Type())
 	assert.Equal(t, handShakeAckTestBuf, f.Encode())
 }
+
+func TestHandshakeAckFrameStreamPerTag(t *testing.T) {
+	f := NewHandshakeAckFrame()
+	f.StreamPerTag = true
+
+	ack, err := DecodeToHandshakeAckFrame(f.Encode())
+	assert.NoError(t, err)
+	assert.True(t, ack.StreamPerTag)
+}
+
+func TestHandshakeAckFrameAdvertiseTags(t *testing.T) {
+	f := NewHandshakeAckFrame()
+	f.AdvertiseTags = true
+
+	ack, err := DecodeToHandshakeAckFrame(f.Encode())
+	assert.NoError(t, err)
+	assert.True(t, ack.AdvertiseTags)
+	assert.False(t, ack.StreamPerTag)
+}
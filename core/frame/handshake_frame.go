@@ -2,6 +2,7 @@ package frame
 
 import (
 	"encoding/binary"
+	"strings"
 
 	"github.com/yomorun/y3"
 )
@@ -16,6 +17,13 @@ type HandshakeFrame struct {
 	ClientType byte
 	// ObserveDataTags are the client data tag list.
 	ObserveDataTags []Tag
+	// Compressions are the compression algorithms of carriage the client accepts.
+	Compressions []string
+	// StreamPerTag asks to write the DataFrames of each tag on their own unidirectional stream.
+	StreamPerTag bool
+	// AdvertiseTags asks the downstream zipper to advertise the data tags observed by it,
+	// the upstream zipper forwards only the DataFrames of them.
+	AdvertiseTags bool
 	// auth
 	authName    string
 	authPayload string
@@ -69,6 +77,24 @@ func (h *HandshakeFrame) Encode() []byte {
 	handshake.AddPrimitivePacket(observeDataTagsBlock)
 	handshake.AddPrimitivePacket(authNameBlock)
 	handshake.AddPrimitivePacket(authPayloadBlock)
+	// compressions
+	if len(h.Compressions) > 0 {
+		compressionsBlock := y3.NewPrimitivePacketEncoder(byte(TagOfHandshakeCompressions))
+		compressionsBlock.SetStringValue(strings.Join(h.Compressions, ","))
+		handshake.AddPrimitivePacket(compressionsBlock)
+	}
+	// stream per tag
+	if h.StreamPerTag {
+		streamPerTagBlock := y3.NewPrimitivePacketEncoder(byte(TagOfHandshakeStreamPerTag))
+		streamPerTagBlock.SetBoolValue(true)
+		handshake.AddPrimitivePacket(streamPerTagBlock)
+	}
+	// advertise tags
+	if h.AdvertiseTags {
+		advertiseTagsBlock := y3.NewPrimitivePacketEncoder(byte(TagOfHandshakeAdvertiseTags))
+		advertiseTagsBlock.SetBoolValue(true)
+		handshake.AddPrimitivePacket(advertiseTagsBlock)
+	}
 
 	return handshake.Encode()
 }
@@ -127,6 +153,32 @@ func DecodeToHandshakeFrame(buf []byte) (*HandshakeFrame, error) {
 		}
 		handshake.authPayload = authPayload
 	}
+	// compressions
+	if compressionsBlock, ok := node.PrimitivePackets[byte(TagOfHandshakeCompressions)]; ok {
+		compressions, err := compressionsBlock.ToUTF8String()
+		if err != nil {
+			return nil, err
+		}
+		if compressions != "" {
+			handshake.Compressions = strings.Split(compressions, ",")
+		}
+	}
+	// stream per tag
+	if streamPerTagBlock, ok := node.PrimitivePackets[byte(TagOfHandshakeStreamPerTag)]; ok {
+		streamPerTag, err := streamPerTagBlock.ToBool()
+		if err != nil {
+			return nil, err
+		}
+		handshake.StreamPerTag = streamPerTag
+	}
+	// advertise tags
+	if advertiseTagsBlock, ok := node.PrimitivePackets[byte(TagOfHandshakeAdvertiseTags)]; ok {
+		advertiseTags, err := advertiseTagsBlock.ToBool()
+		if err != nil {
+			return nil, err
+		}
+		handshake.AdvertiseTags = advertiseTags
+	}
 
 	return handshake, nil
 }
@@ -29,3 +29,31 @@ func TestHandshakeFrameEncode(t *testing.T) {
 	assert.EqualValues(t, "token", Handshake.AuthName())
 	assert.EqualValues(t, "a", Handshake.AuthPayload())
 }
+
+func TestHandshakeFrameCompressions(t *testing.T) {
+	m := NewHandshakeFrame("1234", "", 0xD3, []Tag{0x01}, "token", "a")
+	m.Compressions = []string{"gzip", "zstd"}
+
+	handshake, err := DecodeToHandshakeFrame(m.Encode())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"gzip", "zstd"}, handshake.Compressions)
+}
+
+func TestHandshakeFrameStreamPerTag(t *testing.T) {
+	m := NewHandshakeFrame("1234", "", 0xD3, []Tag{0x01}, "token", "a")
+	m.StreamPerTag = true
+
+	handshake, err := DecodeToHandshakeFrame(m.Encode())
+	assert.NoError(t, err)
+	assert.True(t, handshake.StreamPerTag)
+}
+
+func TestHandshakeFrameAdvertiseTags(t *testing.T) {
+	m := NewHandshakeFrame("1234", "", 0xD3, []Tag{0x01}, "token", "a")
+	m.AdvertiseTags = true
+
+	handshake, err := DecodeToHandshakeFrame(m.Encode())
+	assert.NoError(t, err)
+	assert.True(t, handshake.AdvertiseTags)
+	assert.False(t, handshake.StreamPerTag)
+}
@@ -2,6 +2,7 @@ package frame
 
 import (
 	"strconv"
+	"strings"
 	"time"
 
 	gonanoid "github.com/matoous/go-nanoid/v2"
@@ -15,6 +16,12 @@ type MetaFrame struct {
 	metadata  []byte
 	sourceID  string
 	broadcast bool
+	traceID   string
+	spanID    string
+	keyID     string
+	hops      uint32
+	visited   []string
+	origin    string
 }
 
 // NewMetaFrame creates a new MetaFrame instance.
@@ -66,6 +73,80 @@ func (m *MetaFrame) IsBroadcast() bool {
 	return m.broadcast
 }
 
+// SetTraceID set the trace ID, it is the same across all the hops of a DataFrame.
+func (m *MetaFrame) SetTraceID(traceID string) {
+	m.traceID = traceID
+}
+
+// TraceID returns the trace ID, it is empty if the DataFrame is not traced.
+func (m *MetaFrame) TraceID() string {
+	return m.traceID
+}
+
+// SetSpanID set the span ID of the last hop, the span of next hop is its child.
+func (m *MetaFrame) SetSpanID(spanID string) {
+	m.spanID = spanID
+}
+
+// SpanID returns the span ID of the last hop.
+func (m *MetaFrame) SpanID() string {
+	return m.spanID
+}
+
+// SetKeyID set the id of the key sealed the carriage.
+func (m *MetaFrame) SetKeyID(keyID string) {
+	m.keyID = keyID
+}
+
+// KeyID returns the id of the key sealed the carriage, it is empty if the carriage is not sealed.
+func (m *MetaFrame) KeyID() string {
+	return m.keyID
+}
+
+// Hops returns how many times the DataFrame is forwarded from a zipper to another.
+func (m *MetaFrame) Hops() uint32 {
+	return m.hops
+}
+
+// Visited returns the names of the zippers the DataFrame is forwarded from.
+func (m *MetaFrame) Visited() []string {
+	return m.visited
+}
+
+// HasVisited returns true if the DataFrame is forwarded from the zipper of the name.
+func (m *MetaFrame) HasVisited(name string) bool {
+	for _, v := range m.visited {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Visit records the DataFrame is forwarded from the zipper of the name, the hops is increased.
+func (m *MetaFrame) Visit(name string) {
+	m.hops++
+	m.visited = append(m.visited, name)
+}
+
+// SetOrigin set the name of the zipper the source is connected to, the results are routed back to it.
+func (m *MetaFrame) SetOrigin(origin string) {
+	m.origin = origin
+}
+
+// Origin returns the name of the zipper the source is connected to, it is empty if the DataFrame
+// is not forwarded across zippers.
+func (m *MetaFrame) Origin() string {
+	return m.origin
+}
+
+// Clone returns a copy of the MetaFrame, the changes of the copy do not affect the original.
+func (m *MetaFrame) Clone() *MetaFrame {
+	clone := *m
+	clone.visited = append([]string(nil), m.visited...)
+	return &clone
+}
+
 // Encode implements Frame.Encode method.
 func (m *MetaFrame) Encode() []byte {
 	meta := y3.NewNodePacketEncoder(byte(TagOfMetaFrame))
@@ -91,6 +172,42 @@ func (m *MetaFrame) Encode() []byte {
 	broadcast.SetBoolValue(m.broadcast)
 	meta.AddPrimitivePacket(broadcast)
 
+	// trace context
+	if m.traceID != "" {
+		traceID := y3.NewPrimitivePacketEncoder(byte(TagOfTraceID))
+		traceID.SetStringValue(m.traceID)
+		meta.AddPrimitivePacket(traceID)
+
+		spanID := y3.NewPrimitivePacketEncoder(byte(TagOfSpanID))
+		spanID.SetStringValue(m.spanID)
+		meta.AddPrimitivePacket(spanID)
+	}
+
+	// key id
+	if m.keyID != "" {
+		keyID := y3.NewPrimitivePacketEncoder(byte(TagOfKeyID))
+		keyID.SetStringValue(m.keyID)
+		meta.AddPrimitivePacket(keyID)
+	}
+
+	// the zippers visited, the names are joined by comma.
+	if m.hops > 0 {
+		hops := y3.NewPrimitivePacketEncoder(byte(TagOfHops))
+		hops.SetUInt32Value(m.hops)
+		meta.AddPrimitivePacket(hops)
+
+		visited := y3.NewPrimitivePacketEncoder(byte(TagOfVisited))
+		visited.SetStringValue(strings.Join(m.visited, ","))
+		meta.AddPrimitivePacket(visited)
+	}
+
+	// origin zipper
+	if m.origin != "" {
+		origin := y3.NewPrimitivePacketEncoder(byte(TagOfOrigin))
+		origin.SetStringValue(m.origin)
+		meta.AddPrimitivePacket(origin)
+	}
+
 	return meta.Encode()
 }
 
@@ -125,6 +242,44 @@ func DecodeToMetaFrame(buf []byte) (*MetaFrame, error) {
 				return nil, err
 			}
 			meta.broadcast = broadcast
+		case byte(TagOfTraceID):
+			traceID, err := v.ToUTF8String()
+			if err != nil {
+				return nil, err
+			}
+			meta.traceID = traceID
+		case byte(TagOfSpanID):
+			spanID, err := v.ToUTF8String()
+			if err != nil {
+				return nil, err
+			}
+			meta.spanID = spanID
+		case byte(TagOfKeyID):
+			keyID, err := v.ToUTF8String()
+			if err != nil {
+				return nil, err
+			}
+			meta.keyID = keyID
+		case byte(TagOfHops):
+			hops, err := v.ToUInt32()
+			if err != nil {
+				return nil, err
+			}
+			meta.hops = hops
+		case byte(TagOfVisited):
+			visited, err := v.ToUTF8String()
+			if err != nil {
+				return nil, err
+			}
+			if visited != "" {
+				meta.visited = strings.Split(visited, ",")
+			}
+		case byte(TagOfOrigin):
+			origin, err := v.ToUTF8String()
+			if err != nil {
+				return nil, err
+			}
+			meta.origin = origin
 		}
 	}
 
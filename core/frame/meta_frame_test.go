@@ -26,3 +26,49 @@ func TestMetaFrameDecode(t *testing.T) {
 	assert.EqualValues(t, true, meta.IsBroadcast())
 	t.Logf("%# x", buf)
 }
+
+func TestMetaFrameTraceContext(t *testing.T) {
+	m := NewMetaFrame()
+	m.SetTraceID("4bf92f3577b34da6a3ce929d0e0e4736")
+	m.SetSpanID("00f067aa0ba902b7")
+
+	meta, err := DecodeToMetaFrame(m.Encode())
+	assert.NoError(t, err)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", meta.TraceID())
+	assert.Equal(t, "00f067aa0ba902b7", meta.SpanID())
+}
+
+func TestMetaFrameKeyID(t *testing.T) {
+	m := NewMetaFrame()
+	m.SetKeyID("key-1")
+
+	meta, err := DecodeToMetaFrame(m.Encode())
+	assert.NoError(t, err)
+	assert.Equal(t, "key-1", meta.KeyID())
+}
+
+func TestMetaFrameVisited(t *testing.T) {
+	m := NewMetaFrame()
+	m.Visit("zipper-1")
+	clone := m.Clone()
+	clone.Visit("zipper-2")
+
+	assert.Equal(t, uint32(1), m.Hops())
+	assert.Equal(t, []string{"zipper-1"}, m.Visited())
+
+	meta, err := DecodeToMetaFrame(clone.Encode())
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(2), meta.Hops())
+	assert.Equal(t, []string{"zipper-1", "zipper-2"}, meta.Visited())
+	assert.True(t, meta.HasVisited("zipper-1"))
+	assert.False(t, meta.HasVisited("zipper-3"))
+}
+
+func TestMetaFrameOrigin(t *testing.T) {
+	m := NewMetaFrame()
+	m.SetOrigin("zipper-1")
+
+	meta, err := DecodeToMetaFrame(m.Encode())
+	assert.NoError(t, err)
+	assert.Equal(t, "zipper-1", meta.Origin())
+}
@@ -0,0 +1,58 @@
+package frame
+
+import (
+	"encoding/binary"
+
+	"github.com/yomorun/y3"
+)
+
+// ObservedTagsFrame is a Y3 encoded bytes, It is written by the downstream zipper to the upstream
+// zippers asked for it, to advertise the data tags observed by its stream functions. It is written
+// again when the tags are changed.
+type ObservedTagsFrame struct {
+	Tags []Tag
+}
+
+// NewObservedTagsFrame creates a new ObservedTagsFrame.
+func NewObservedTagsFrame(tags []Tag) *ObservedTagsFrame {
+	return &ObservedTagsFrame{Tags: tags}
+}
+
+// Type gets the type of Frame.
+func (f *ObservedTagsFrame) Type() Type {
+	return TagOfObservedTagsFrame
+}
+
+// Encode to Y3 encoded bytes.
+func (f *ObservedTagsFrame) Encode() []byte {
+	tagsBlock := y3.NewPrimitivePacketEncoder(byte(TagOfObservedTags))
+	buf := make([]byte, 4*len(f.Tags))
+	for i, tag := range f.Tags {
+		binary.LittleEndian.PutUint32(buf[i*4:], uint32(tag))
+	}
+	tagsBlock.SetBytesValue(buf)
+
+	observed := y3.NewNodePacketEncoder(byte(f.Type()))
+	observed.AddPrimitivePacket(tagsBlock)
+
+	return observed.Encode()
+}
+
+// DecodeToObservedTagsFrame decodes Y3 encoded bytes to ObservedTagsFrame.
+func DecodeToObservedTagsFrame(buf []byte) (*ObservedTagsFrame, error) {
+	node := y3.NodePacket{}
+	_, err := y3.DecodeToNodePacket(buf, &node)
+	if err != nil {
+		return nil, err
+	}
+
+	observed := &ObservedTagsFrame{}
+	if tagsBlock, ok := node.PrimitivePackets[byte(TagOfObservedTags)]; ok {
+		buf := tagsBlock.GetValBuf()
+		for i := 0; i+4 <= len(buf); i += 4 {
+			observed.Tags = append(observed.Tags, Tag(binary.LittleEndian.Uint32(buf[i:i+4])))
+		}
+	}
+
+	return observed, nil
+}
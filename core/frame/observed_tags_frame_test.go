@@ -0,0 +1,19 @@
+package frame
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObservedTagsFrame(t *testing.T) {
+	f := NewObservedTagsFrame([]Tag{0x33, 0x1234})
+
+	decoded, err := DecodeToObservedTagsFrame(f.Encode())
+	assert.NoError(t, err)
+	assert.Equal(t, []Tag{0x33, 0x1234}, decoded.Tags)
+
+	decoded, err = DecodeToObservedTagsFrame(NewObservedTagsFrame(nil).Encode())
+	assert.NoError(t, err)
+	assert.Empty(t, decoded.Tags)
+}
@@ -13,6 +13,8 @@ type Tag uint32
 type PayloadFrame struct {
 	Tag      Tag
 	Carriage []byte
+	// Compression is the algorithm compressed the carriage, It is empty if not compressed.
+	Compression string
 }
 
 // NewPayloadFrame creates a new PayloadFrame with a given TagID of user's data
@@ -40,6 +42,12 @@ func (m *PayloadFrame) Encode() []byte {
 	payload.AddPrimitivePacket(tag)
 	payload.AddPrimitivePacket(carriage)
 
+	if m.Compression != "" {
+		compression := y3.NewPrimitivePacketEncoder(byte(TagOfPayloadCompression))
+		compression.SetStringValue(m.Compression)
+		payload.AddPrimitivePacket(compression)
+	}
+
 	return payload.Encode()
 }
 
@@ -64,5 +72,13 @@ func DecodeToPayloadFrame(buf []byte) (*PayloadFrame, error) {
 		payload.Carriage = p.GetValBuf()
 	}
 
+	if p, ok := nodeBlock.PrimitivePackets[byte(TagOfPayloadCompression)]; ok {
+		compression, err := p.ToUTF8String()
+		if err != nil {
+			return nil, err
+		}
+		payload.Compression = compression
+	}
+
 	return payload, nil
 }
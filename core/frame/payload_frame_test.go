@@ -18,3 +18,13 @@ func TestPayloadFrameDecode(t *testing.T) {
 	assert.EqualValues(t, 0x13, payload.Tag)
 	assert.Equal(t, []byte{0x79, 0x6F, 0x6D, 0x6F}, payload.Carriage)
 }
+
+func TestPayloadFrameCompression(t *testing.T) {
+	f := NewPayloadFrame(0x13).SetCarriage([]byte("yomo"))
+	f.Compression = "gzip"
+
+	payload, err := DecodeToPayloadFrame(f.Encode())
+	assert.NoError(t, err)
+	assert.Equal(t, "gzip", payload.Compression)
+	assert.Equal(t, []byte("yomo"), payload.Carriage)
+}
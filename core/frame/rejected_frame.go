@@ -4,6 +4,7 @@ import "github.com/yomorun/y3"
 
 // RejectedFrame is a Y3 encoded bytes, Tag is a fixed value TYPE_ID_REJECTED_FRAME
 type RejectedFrame struct {
+	code    uint64
 	message string
 }
 
@@ -12,6 +13,17 @@ func NewRejectedFrame(msg string) *RejectedFrame {
 	return &RejectedFrame{message: msg}
 }
 
+// SetCode sets the error code the client is rejected with, e.g. forbidden by access control.
+func (f *RejectedFrame) SetCode(code uint64) *RejectedFrame {
+	f.code = code
+	return f
+}
+
+// Code returns the error code the client is rejected with, It is 0 if not set.
+func (f *RejectedFrame) Code() uint64 {
+	return f.code
+}
+
 // Type gets the type of Frame.
 func (f *RejectedFrame) Type() Type {
 	return TagOfRejectedFrame
@@ -20,6 +32,12 @@ func (f *RejectedFrame) Type() Type {
 // Encode to Y3 encoded bytes
 func (f *RejectedFrame) Encode() []byte {
 	rejected := y3.NewNodePacketEncoder(byte(f.Type()))
+	// code
+	if f.code != 0 {
+		codeBlock := y3.NewPrimitivePacketEncoder(byte(TagOfRejectedCode))
+		codeBlock.SetUInt64Value(f.code)
+		rejected.AddPrimitivePacket(codeBlock)
+	}
 	// message
 	msgBlock := y3.NewPrimitivePacketEncoder(byte(TagOfRejectedMessage))
 	msgBlock.SetStringValue(f.message)
@@ -42,6 +60,14 @@ func DecodeToRejectedFrame(buf []byte) (*RejectedFrame, error) {
 		return nil, err
 	}
 	rejected := &RejectedFrame{}
+	// code
+	if codeBlock, ok := node.PrimitivePackets[byte(TagOfRejectedCode)]; ok {
+		code, err := codeBlock.ToUInt64()
+		if err != nil {
+			return nil, err
+		}
+		rejected.code = code
+	}
 	// message
 	if msgBlock, ok := node.PrimitivePackets[byte(TagOfRejectedMessage)]; ok {
 		msg, err := msgBlock.ToUTF8String()
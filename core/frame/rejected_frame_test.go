@@ -17,3 +17,12 @@ func TestRejectedFrameDecode(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, []byte{0x80 | byte(TagOfRejectedFrame), 0x2, 0x2, 0x0}, ping.Encode())
 }
+
+func TestRejectedFrameCode(t *testing.T) {
+	f := NewRejectedFrame("forbidden").SetCode(0xC9)
+
+	rejected, err := DecodeToRejectedFrame(f.Encode())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0xC9), rejected.Code())
+	assert.Equal(t, "forbidden", rejected.Message())
+}
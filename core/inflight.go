@@ -0,0 +1,84 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// inflightTimeout is how long a DataFrame dispatched to a stream function is in flight at most,
+// the ones without result, e.g. the handler of stream function returns nothing, expire after it.
+var inflightTimeout = 10 * time.Second
+
+// inflight counts the DataFrames dispatched to the stream functions and waiting for their results,
+// It is the load of the `least-inflight` load balancing. The zero value is ready to use.
+type inflight struct {
+	mu    sync.Mutex
+	conns map[string]*inflightConn // connID -> DataFrames in flight
+}
+
+type inflightConn struct {
+	frames map[string]time.Time // tid -> dispatched time
+	swept  time.Time
+}
+
+// add records the DataFrame of the transaction id is dispatched to the connection.
+func (i *inflight) add(connID string, tid string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.conns == nil {
+		i.conns = make(map[string]*inflightConn)
+	}
+	c, ok := i.conns[connID]
+	if !ok {
+		c = &inflightConn{frames: make(map[string]time.Time), swept: time.Now()}
+		i.conns[connID] = c
+	}
+	c.sweep()
+	c.frames[tid] = time.Now()
+}
+
+// done records the result of the transaction id is written by the connection.
+func (i *inflight) done(connID string, tid string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if c, ok := i.conns[connID]; ok {
+		delete(c.frames, tid)
+	}
+}
+
+// remove forgets the DataFrames in flight to the connection.
+func (i *inflight) remove(connID string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	delete(i.conns, connID)
+}
+
+// load returns the number of DataFrames in flight to the connection.
+func (i *inflight) load(connID string) int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	c, ok := i.conns[connID]
+	if !ok {
+		return 0
+	}
+	c.sweep()
+	return len(c.frames)
+}
+
+// sweep removes the expired DataFrames, at most once in inflightTimeout.
+func (c *inflightConn) sweep() {
+	now := time.Now()
+	if now.Sub(c.swept) < inflightTimeout {
+		return
+	}
+	for tid, t := range c.frames {
+		if now.Sub(t) >= inflightTimeout {
+			delete(c.frames, tid)
+		}
+	}
+	c.swept = now
+}
@@ -0,0 +1,31 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInflight(t *testing.T) {
+	var i inflight
+	assert.Equal(t, 0, i.load("conn-1"))
+
+	i.add("conn-1", "tid-1")
+	i.add("conn-1", "tid-2")
+	assert.Equal(t, 2, i.load("conn-1"))
+
+	i.done("conn-1", "tid-1")
+	assert.Equal(t, 1, i.load("conn-1"))
+
+	// the DataFrames without result expire.
+	timeout := inflightTimeout
+	inflightTimeout = 10 * time.Millisecond
+	defer func() { inflightTimeout = timeout }()
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 0, i.load("conn-1"))
+
+	i.add("conn-1", "tid-3")
+	i.remove("conn-1")
+	assert.Equal(t, 0, i.load("conn-1"))
+}
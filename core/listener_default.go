@@ -27,6 +27,7 @@ var DefalutQuicConfig = &quic.Config{
 	HandshakeIdleTimeout:           time.Second * 3,
 	InitialStreamReceiveWindow:     1024 * 1024 * 2,
 	InitialConnectionReceiveWindow: 1024 * 1024 * 2,
+	EnableDatagrams:                true,
 	// DisablePathMTUDiscovery:        true,
 }
 
@@ -0,0 +1,152 @@
+package core
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/lucas-clemente/quic-go"
+	"github.com/yomorun/yomo/pkg/logger"
+)
+
+// netListener is the Listener of the reliable transports for the networks blocking UDP,
+// the connections are accepted and handshaken in background, then delivered to Accept.
+type netListener struct {
+	name      string
+	addr      net.Addr
+	conns     chan quic.Connection
+	done      chan struct{}
+	closeFn   func() error
+	closeOnce sync.Once
+}
+
+var _ Listener = &netListener{}
+
+func newNetListener(name string, addr net.Addr, closeFn func() error) *netListener {
+	return &netListener{
+		name:    name,
+		addr:    addr,
+		conns:   make(chan quic.Connection),
+		done:    make(chan struct{}),
+		closeFn: closeFn,
+	}
+}
+
+// Accept returns the next connection.
+func (l *netListener) Accept(ctx context.Context) (quic.Connection, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+// deliver delivers the connection to Accept, It is closed if the listener is closed.
+func (l *netListener) deliver(conn quic.Connection) {
+	select {
+	case l.conns <- conn:
+	case <-l.done:
+		conn.CloseWithError(0, "listener is closed")
+	}
+}
+
+// Addr returns the listening address.
+func (l *netListener) Addr() net.Addr { return l.addr }
+
+// Close closes the listener.
+func (l *netListener) Close() error {
+	var err error
+	l.closeOnce.Do(func() {
+		close(l.done)
+		err = l.closeFn()
+	})
+	return err
+}
+
+// Name returns the name of the listener.
+func (l *netListener) Name() string { return l.name }
+
+// Versions returns the protocols of the transport.
+func (l *netListener) Versions() []string { return []string{"TLS"} }
+
+// tlsHandshakeTimeout limits the TLS handshake of the connections of the reliable transports.
+const tlsHandshakeTimeout = 10 * time.Second
+
+// newTCPListener listens TCP+TLS on addr, each connection carries a single stream of Y3 frames.
+func newTCPListener(addr string, tlsConfig *tls.Config) (*netListener, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	l := newNetListener("TCP-Server", ln.Addr(), ln.Close)
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				if !errors.Is(err, net.ErrClosed) {
+					logger.Errorf("%sTCP listener accept error: %v", ServerLogPrefix, err)
+				}
+				l.Close()
+				return
+			}
+			go func() {
+				tlsConn := tls.Server(conn, tlsConfig)
+				ctx, cancel := context.WithTimeout(context.Background(), tlsHandshakeTimeout)
+				defer cancel()
+				if err := tlsConn.HandshakeContext(ctx); err != nil {
+					logger.Warnf("%sTLS handshake with %s error: %v", ServerLogPrefix, conn.RemoteAddr(), err)
+					conn.Close()
+					return
+				}
+				l.deliver(newNetConnection(tlsConn, tlsConn.ConnectionState().NegotiatedProtocol))
+			}()
+		}
+	}()
+
+	return l, nil
+}
+
+// newWebSocketListener listens WebSocket over TLS on addr, the clients connect to
+// `wss://addr/path`, each connection carries a single stream of Y3 frames.
+func newWebSocketListener(addr string, path string, tlsConfig *tls.Config) (*netListener, error) {
+	ln, err := tls.Listen("tcp", addr, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	srv := &http.Server{ReadHeaderTimeout: tlsHandshakeTimeout}
+	l := newNetListener("WebSocket-Server", ln.Addr(), srv.Close)
+
+	upgrader := websocket.Upgrader{}
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			logger.Warnf("%sWebSocket upgrade %s error: %v", ServerLogPrefix, r.RemoteAddr, err)
+			return
+		}
+		proto := ""
+		if r.TLS != nil {
+			proto = r.TLS.NegotiatedProtocol
+		}
+		l.deliver(newNetConnection(newWebSocketConn(conn), proto))
+	})
+	srv.Handler = mux
+
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Errorf("%sWebSocket listener serve error: %v", ServerLogPrefix, err)
+		}
+		l.Close()
+	}()
+
+	return l, nil
+}
@@ -0,0 +1,176 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/pkg/id"
+	"github.com/yomorun/yomo/pkg/logger"
+)
+
+// LocalClient is a client connected to the server in process, e.g. by the HTTP ingress of zipper.
+// Its frames go through the same handlers as the ones of a QUIC client: authentication, access control,
+// routing, dispatching to downstream zippers and backflow.
+type LocalClient struct {
+	server *Server
+	connID string
+	name   string
+	stream *localStream
+	once   sync.Once
+}
+
+// ConnectLocal connects a client in process by the HandshakeFrame, the frames written to the client
+// by the server, e.g. BackflowFrame, are passed to receive, It must not block.
+func (s *Server) ConnectLocal(handshake *frame.HandshakeFrame, receive func(frame.Frame)) (*LocalClient, error) {
+	stream := &localStream{}
+	connID := "local-" + id.New()
+	c := &Context{connID: connID, Stream: stream, Frame: handshake}
+
+	var reply frame.Frame
+	stream.receive = func(f frame.Frame) { reply = f }
+	if err := s.handleHandshakeFrame(c); err != nil {
+		s.removeConnection(connID)
+		return nil, err
+	}
+	switch f := reply.(type) {
+	case *frame.HandshakeAckFrame:
+	case *frame.RejectedFrame:
+		return nil, errors.New(f.Message())
+	default:
+		s.removeConnection(connID)
+		return nil, fmt.Errorf("handshake of [%s] is not acknowledged", handshake.Name)
+	}
+	stream.setReceive(receive)
+
+	return &LocalClient{
+		server: s,
+		connID: connID,
+		name:   handshake.Name,
+		stream: stream,
+	}, nil
+}
+
+// Subscribe connects a client in process which subscribes the DataFrames of its observed data tags,
+// e.g. by the WebSocket and SSE egress of zipper. The subscriber receives a copy of the DataFrames
+// of its tenant besides the routed stream functions, it does not take part in routing.
+func (s *Server) Subscribe(handshake *frame.HandshakeFrame, receive func(frame.Frame)) (*LocalClient, error) {
+	c, err := s.ConnectLocal(handshake, receive)
+	if err != nil {
+		return nil, err
+	}
+	s.subscribers.Store(c.connID, true)
+	s.advertiseTags()
+	return c, nil
+}
+
+// publish writes the DataFrame to the subscribers of its tag in the tenant of metadata,
+// It returns the number of the subscribers written.
+func (s *Server) publish(m metadata.Metadata, f *frame.DataFrame) int {
+	n := 0
+	tenant := metadata.TenantOf(m)
+	s.subscribers.Range(func(key, _ interface{}) bool {
+		connID := key.(string)
+		conn := s.connector.Get(connID)
+		if conn == nil || metadata.TenantOf(conn.Metadata()) != tenant || !observes(conn, f.GetDataTag()) {
+			return true
+		}
+		if _, err := s.writeDataFrame(connID, conn, f); err != nil {
+			logger.Warnf("%spublish to [%s](%s) error: %v", ServerLogPrefix, conn.Name(), connID, err)
+			return true
+		}
+		n++
+		return true
+	})
+	return n
+}
+
+func observes(conn Connection, tag frame.Tag) bool {
+	for _, v := range conn.ObserveDataTags() {
+		if v == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// ConnID returns the connection id of the client.
+func (c *LocalClient) ConnID() string {
+	return c.connID
+}
+
+// WriteFrame handles the frame as it is received from the client.
+func (c *LocalClient) WriteFrame(f frame.Frame) error {
+	if c.stream.isClosed() {
+		return io.ErrClosedPipe
+	}
+	ctx := &Context{connID: c.connID, Stream: c.stream, Frame: f}
+	// the stream is closed by the server if the frame is rejected, e.g. by access control.
+	if !c.server.handleFrame(ctx) || c.stream.isClosed() {
+		c.Close()
+		return fmt.Errorf("[%s](%s) frame %#x is rejected", c.name, c.connID, f.Type())
+	}
+	return nil
+}
+
+// Close disconnects the client.
+func (c *LocalClient) Close() error {
+	c.once.Do(func() {
+		c.stream.Close()
+		c.server.removeConnection(c.connID)
+		logger.Debugf("%s💔 [%s](%s) close the local connection", ServerLogPrefix, c.name, c.connID)
+	})
+	return nil
+}
+
+// localStream is the stream of LocalClient, each Write of the server carries an encoded frame.
+type localStream struct {
+	mu      sync.Mutex
+	receive func(frame.Frame)
+	closed  bool
+}
+
+func (s *localStream) setReceive(receive func(frame.Frame)) {
+	s.mu.Lock()
+	s.receive = receive
+	s.mu.Unlock()
+}
+
+// Read is not used, the frames of LocalClient are handled by WriteFrame.
+func (s *localStream) Read(p []byte) (int, error) {
+	return 0, io.EOF
+}
+
+func (s *localStream) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	receive, closed := s.receive, s.closed
+	s.mu.Unlock()
+	if closed {
+		return 0, io.ErrClosedPipe
+	}
+	f, err := ParseFrame(bytes.NewReader(p))
+	if err != nil {
+		return 0, err
+	}
+	if receive != nil {
+		receive(f)
+	}
+	return len(p), nil
+}
+
+func (s *localStream) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *localStream) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
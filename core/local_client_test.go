@@ -0,0 +1,61 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/core/router"
+	"github.com/yomorun/yomo/pkg/config"
+)
+
+func TestConnectLocalRejected(t *testing.T) {
+	server := NewServer("zipper", WithAuth("token", "secret"))
+	server.ConfigMetadataBuilder(metadata.DefaultBuilder())
+	server.ConfigRouter(router.Default([]config.App{{Name: "sfn-1"}}))
+
+	handshake := frame.NewHandshakeFrame("source", "source-id", byte(ClientTypeSource), nil, "token", "yomo")
+	_, err := server.ConnectLocal(handshake, func(frame.Frame) {})
+
+	assert.EqualError(t, err, "handshake authentication fails, client credential name is token")
+	assert.Empty(t, server.connector.GetConns())
+}
+
+func TestSubscribe(t *testing.T) {
+	server := NewServer("zipper")
+	// the tenant is the credential payload.
+	server.ConfigMetadataBuilder(metadata.TenantBuilder(func(f *frame.HandshakeFrame) (string, error) {
+		return f.AuthPayload(), nil
+	}))
+	server.ConfigRouter(router.Tenant([]config.App{{Name: "sfn-1"}}))
+
+	subscribe := func(tenant string, tag frame.Tag) (*LocalClient, chan frame.Frame) {
+		received := make(chan frame.Frame, 10)
+		handshake := frame.NewHandshakeFrame("egress", tenant+"-egress", byte(ClientTypeSource), []frame.Tag{tag}, "token", tenant)
+		c, err := server.Subscribe(handshake, func(f frame.Frame) { received <- f })
+		require.NoError(t, err)
+		return c, received
+	}
+	subA, receivedA := subscribe("tenant-a", 0x33)
+	_, receivedB := subscribe("tenant-b", 0x33)
+
+	handshake := frame.NewHandshakeFrame("source", "source-id", byte(ClientTypeSource), nil, "token", "tenant-a")
+	source, err := server.ConnectLocal(handshake, func(frame.Frame) {})
+	require.NoError(t, err)
+
+	f := frame.NewDataFrame()
+	f.SetCarriage(0x33, []byte("hello"))
+	require.NoError(t, source.WriteFrame(f))
+
+	// the subscribers of other tenants do not receive the data.
+	require.Len(t, receivedA, 1)
+	assert.Equal(t, []byte("hello"), (<-receivedA).(*frame.DataFrame).GetCarriage())
+	assert.Len(t, receivedB, 0)
+
+	// the closed subscriber does not receive the data.
+	subA.Close()
+	require.NoError(t, source.WriteFrame(f))
+	assert.Len(t, receivedA, 0)
+}
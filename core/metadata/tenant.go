@@ -0,0 +1,65 @@
+package metadata
+
+import (
+	"github.com/yomorun/yomo/core/frame"
+)
+
+var _ Metadata = &Tenant{}
+
+// DefaultTenant is the tenant of the applications which do not belong to any tenant.
+const DefaultTenant = ""
+
+// Tenant is an implement of `Metadata`, It stores the tenant of the application,
+// the applications of different tenants are isolated by the tenant router.
+type Tenant struct {
+	name string
+}
+
+// NewTenant returns the Tenant metadata of name.
+func NewTenant(name string) *Tenant {
+	return &Tenant{name: name}
+}
+
+// Tenant returns the tenant name.
+func (m *Tenant) Tenant() string {
+	return m.name
+}
+
+// Encode returns the tenant name.
+func (m *Tenant) Encode() []byte {
+	return []byte(m.name)
+}
+
+// TenantFunc returns the tenant of the application by its handshake frame.
+type TenantFunc func(f *frame.HandshakeFrame) (string, error)
+
+type tenantBuilder struct {
+	tenantOf TenantFunc
+}
+
+// TenantBuilder returns an implement of `Builder`, It builds the Tenant metadata
+// by tenantOf, e.g. resolving the tenant from the credential of the application.
+func TenantBuilder(tenantOf TenantFunc) Builder {
+	return &tenantBuilder{tenantOf: tenantOf}
+}
+
+func (builder *tenantBuilder) Build(f *frame.HandshakeFrame) (Metadata, error) {
+	name, err := builder.tenantOf(f)
+	if err != nil {
+		return nil, err
+	}
+	return NewTenant(name), nil
+}
+
+func (builder *tenantBuilder) Decode(buf []byte) (Metadata, error) {
+	return NewTenant(string(buf)), nil
+}
+
+// TenantOf returns the tenant of the metadata,
+// It returns `DefaultTenant` if the metadata does not carry a tenant.
+func TenantOf(m Metadata) string {
+	if t, ok := m.(interface{ Tenant() string }); ok {
+		return t.Tenant()
+	}
+	return DefaultTenant
+}
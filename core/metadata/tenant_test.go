@@ -0,0 +1,33 @@
+package metadata
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+)
+
+func TestTenant(t *testing.T) {
+	builder := TenantBuilder(func(f *frame.HandshakeFrame) (string, error) {
+		if f.AuthPayload() == "" {
+			return "", errors.New("missing credential")
+		}
+		return "tenant-" + f.AuthPayload(), nil
+	})
+
+	m, err := builder.Build(frame.NewHandshakeFrame("source", "source-id", byte(1), []frame.Tag{}, "token", "a"))
+	assert.NoError(t, err)
+	assert.Equal(t, "tenant-a", TenantOf(m))
+	assert.Equal(t, []byte("tenant-a"), m.Encode())
+
+	de, err := builder.Decode(m.Encode())
+	assert.NoError(t, err)
+	assert.Equal(t, m, de)
+
+	_, err = builder.Build(frame.NewHandshakeFrame("source", "source-id", byte(1), []frame.Tag{}, "token", ""))
+	assert.EqualError(t, err, "missing credential")
+
+	assert.Equal(t, DefaultTenant, TenantOf(&Default{}))
+	assert.Equal(t, DefaultTenant, TenantOf(nil))
+}
@@ -0,0 +1,318 @@
+// Package metrics provides counters of YoMo and exports them in Prometheus text format.
+//
+// The counters are registered to a default registry, `Handler` serves all of them,
+// zipper exposes it on the admin API, source and stream function can serve it by `ListenAndServe`.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Label names.
+const (
+	LabelConnID   = "conn_id"
+	LabelName     = "name"
+	LabelTag      = "tag"
+	LabelAuthName = "auth_name"
+	LabelResult   = "result"
+	LabelReason   = "reason"
+	LabelPolicy   = "policy"
+)
+
+// Handshake results.
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+// Counters of zipper.
+var (
+	// ServerFramesReceived counts the DataFrames received by zipper.
+	ServerFramesReceived = NewCounterVec("yomo_server_frames_received_total", "DataFrames received by zipper.", LabelConnID, LabelName, LabelTag)
+	// ServerFramesSent counts the DataFrames sent by zipper.
+	ServerFramesSent = NewCounterVec("yomo_server_frames_sent_total", "DataFrames sent by zipper.", LabelConnID, LabelName, LabelTag)
+	// ServerBytesReceived counts the carriage bytes received by zipper.
+	ServerBytesReceived = NewCounterVec("yomo_server_bytes_received_total", "Carriage bytes received by zipper.", LabelConnID, LabelName, LabelTag)
+	// ServerBytesSent counts the carriage bytes sent by zipper.
+	ServerBytesSent = NewCounterVec("yomo_server_bytes_sent_total", "Carriage bytes sent by zipper.", LabelConnID, LabelName, LabelTag)
+	// ServerHandshakes counts the handshakes by auth name and result.
+	ServerHandshakes = NewCounterVec("yomo_server_handshakes_total", "Handshakes handled by zipper.", LabelAuthName, LabelResult)
+	// ServerRouteMisses counts the DataFrames which have no stream function to be routed to.
+	ServerRouteMisses = NewCounterVec("yomo_server_route_misses_total", "DataFrames without any route.", LabelTag)
+	// ServerWriteErrors counts the errors of writing frames to connections.
+	ServerWriteErrors = NewCounterVec("yomo_server_write_errors_total", "Errors of writing frames to connections.", LabelConnID, LabelName)
+	// ServerQueueDepth is the number of frames waiting in the send queue of connections.
+	ServerQueueDepth = NewGaugeVec("yomo_server_queue_depth", "Frames waiting in the send queue of connections.", LabelConnID, LabelName)
+	// ServerQueueDropped counts the frames dropped because the send queue of connections is full.
+	ServerQueueDropped = NewCounterVec("yomo_server_queue_dropped_total", "Frames dropped because the send queue is full.", LabelConnID, LabelName)
+	// ServerDeadLetters counts the DataFrames failed to be delivered by reason.
+	ServerDeadLetters = NewCounterVec("yomo_server_dead_letters_total", "DataFrames failed to be delivered.", LabelReason)
+	// ServerRateLimited counts the DataFrames over the rate limits by the policy applied to them.
+	ServerRateLimited = NewCounterVec("yomo_server_rate_limited_total", "DataFrames over the rate limits.", LabelName, LabelTag, LabelPolicy)
+	// ServerMeshDropped counts broadcast DataFrames not forwarded across zippers, by reason: visited, max_hops or no_relay.
+	ServerMeshDropped = NewCounterVec("yomo_server_mesh_dropped_total", "Broadcast DataFrames not forwarded across zippers.", LabelReason)
+	// ServerDatagramsOversized counts the DataFrames too large to be forwarded as datagrams, they are sent on the stream.
+	ServerDatagramsOversized = NewCounterVec("yomo_server_datagrams_oversized_total", "DataFrames too large to be sent as datagrams.", LabelConnID, LabelName, LabelTag)
+	// ServerDatagramsDropped counts the datagrams failed to be sent.
+	ServerDatagramsDropped = NewCounterVec("yomo_server_datagrams_dropped_total", "Datagrams failed to be sent.", LabelConnID, LabelName, LabelTag)
+)
+
+// Counters of source and stream function.
+var (
+	// ClientFramesSent counts the DataFrames sent by client.
+	ClientFramesSent = NewCounterVec("yomo_client_frames_sent_total", "DataFrames sent by client.", LabelName, LabelTag)
+	// ClientFramesReceived counts the DataFrames received by client.
+	ClientFramesReceived = NewCounterVec("yomo_client_frames_received_total", "DataFrames received by client.", LabelName, LabelTag)
+	// ClientBytesSent counts the carriage bytes sent by client.
+	ClientBytesSent = NewCounterVec("yomo_client_bytes_sent_total", "Carriage bytes sent by client.", LabelName, LabelTag)
+	// ClientBytesReceived counts the carriage bytes received by client.
+	ClientBytesReceived = NewCounterVec("yomo_client_bytes_received_total", "Carriage bytes received by client.", LabelName, LabelTag)
+	// ClientReconnects counts the reconnection attempts of client.
+	ClientReconnects = NewCounterVec("yomo_client_reconnects_total", "Reconnection attempts of client.", LabelName)
+	// ClientDatagramsOversized counts the DataFrames too large to be sent as datagrams, they are sent on the stream.
+	ClientDatagramsOversized = NewCounterVec("yomo_client_datagrams_oversized_total", "DataFrames too large to be sent as datagrams.", LabelName, LabelTag)
+	// ClientDatagramsDropped counts the datagrams failed to be sent.
+	ClientDatagramsDropped = NewCounterVec("yomo_client_datagrams_dropped_total", "Datagrams failed to be sent.", LabelName, LabelTag)
+)
+
+// Collector writes metrics in Prometheus text format.
+type Collector interface {
+	// Name returns the metric name.
+	Name() string
+	// WriteTo writes the metric in Prometheus text format.
+	WriteTo(w io.Writer) (int64, error)
+}
+
+// Registry holds the collectors.
+type Registry struct {
+	mu         sync.RWMutex
+	collectors map[string]Collector
+}
+
+// NewRegistry creates a Registry.
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]Collector)}
+}
+
+var defaultRegistry = NewRegistry()
+
+// Default returns the default registry, all the counters of YoMo are registered to it.
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// Register registers a collector, the collector with same name will be replaced.
+func (r *Registry) Register(c Collector) {
+	r.mu.Lock()
+	r.collectors[c.Name()] = c
+	r.mu.Unlock()
+}
+
+// WriteTo writes all the collectors in Prometheus text format, sorted by name.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.collectors))
+	for name := range r.collectors {
+		names = append(names, name)
+	}
+	collectors := make([]Collector, 0, len(names))
+	sort.Strings(names)
+	for _, name := range names {
+		collectors = append(collectors, r.collectors[name])
+	}
+	r.mu.RUnlock()
+
+	var total int64
+	for _, c := range collectors {
+		n, err := c.WriteTo(w)
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// ServeHTTP implements http.Handler interface.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WriteTo(w)
+}
+
+// Handler returns the http.Handler which serves the default registry.
+func Handler() http.Handler {
+	return defaultRegistry
+}
+
+// ListenAndServe serves the default registry on addr with the path `/metrics`.
+func ListenAndServe(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	return http.ListenAndServe(addr, mux)
+}
+
+// CounterVec is a counter partitioned by label values.
+type CounterVec struct {
+	name       string
+	help       string
+	typ        string
+	labelNames []string
+	mu         sync.RWMutex
+	values     map[string]*counter
+}
+
+type counter struct {
+	labelValues []string
+	value       int64
+}
+
+// NewCounterVec creates a CounterVec and registers it to the default registry.
+func NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	c := newVec(name, help, "counter", labelNames)
+	defaultRegistry.Register(c)
+	return c
+}
+
+func newVec(name, help, typ string, labelNames []string) *CounterVec {
+	return &CounterVec{
+		name:       name,
+		help:       help,
+		typ:        typ,
+		labelNames: labelNames,
+		values:     make(map[string]*counter),
+	}
+}
+
+// Name returns the metric name.
+func (c *CounterVec) Name() string {
+	return c.name
+}
+
+// Add adds delta to the counter of the label values,
+// the label values must be in the same order of the label names.
+func (c *CounterVec) Add(delta int64, labelValues ...string) {
+	atomic.AddInt64(&c.lookup(labelValues).value, delta)
+}
+
+func (c *CounterVec) lookup(labelValues []string) *counter {
+	key := strings.Join(labelValues, "\xff")
+
+	c.mu.RLock()
+	v, ok := c.values[key]
+	c.mu.RUnlock()
+
+	if !ok {
+		c.mu.Lock()
+		if v, ok = c.values[key]; !ok {
+			v = &counter{labelValues: labelValues}
+			c.values[key] = v
+		}
+		c.mu.Unlock()
+	}
+	return v
+}
+
+// Inc increments the counter of the label values by 1.
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Get returns the value of the counter of the label values.
+func (c *CounterVec) Get(labelValues ...string) int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if v, ok := c.values[strings.Join(labelValues, "\xff")]; ok {
+		return atomic.LoadInt64(&v.value)
+	}
+	return 0
+}
+
+// DeleteMatch deletes all the counters whose label has the value,
+// It is used to clean the counters of a closed connection.
+func (c *CounterVec) DeleteMatch(label, value string) {
+	index := -1
+	for i, name := range c.labelNames {
+		if name == label {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, v := range c.values {
+		if index < len(v.labelValues) && v.labelValues[index] == value {
+			delete(c.values, key)
+		}
+	}
+}
+
+// WriteTo writes the counter in Prometheus text format.
+func (c *CounterVec) WriteTo(w io.Writer) (int64, error) {
+	c.mu.RLock()
+	lines := make([]string, 0, len(c.values))
+	for _, v := range c.values {
+		lines = append(lines, c.name+c.formatLabels(v.labelValues)+" "+strconv.FormatInt(atomic.LoadInt64(&v.value), 10)+"\n")
+	}
+	c.mu.RUnlock()
+
+	sort.Strings(lines)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# HELP %s %s\n", c.name, c.help)
+	fmt.Fprintf(&b, "# TYPE %s %s\n", c.name, c.typ)
+	for _, line := range lines {
+		b.WriteString(line)
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// GaugeVec is a gauge partitioned by label values.
+type GaugeVec struct {
+	*CounterVec
+}
+
+// NewGaugeVec creates a GaugeVec and registers it to the default registry.
+func NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	g := &GaugeVec{CounterVec: newVec(name, help, "gauge", labelNames)}
+	defaultRegistry.Register(g)
+	return g
+}
+
+// Set sets the gauge of the label values to value.
+func (g *GaugeVec) Set(value int64, labelValues ...string) {
+	atomic.StoreInt64(&g.lookup(labelValues).value, value)
+}
+
+// labelValueEscaper escapes the label value in the Prometheus text format,
+// only the backslash, the double quote and the line feed are escaped.
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func (c *CounterVec) formatLabels(values []string) string {
+	if len(c.labelNames) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(c.labelNames))
+	for i, name := range c.labelNames {
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+		pairs = append(pairs, name+`="`+labelValueEscaper.Replace(value)+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
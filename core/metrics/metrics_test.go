@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCounterVec(t *testing.T) {
+	c := newVec("yomo_test_total", "Test counter.", "counter", []string{LabelConnID, LabelTag})
+
+	c.Inc("conn-1", "1")
+	c.Add(2, "conn-1", "1")
+	c.Inc("conn-2", "2")
+
+	assert.Equal(t, int64(3), c.Get("conn-1", "1"))
+	assert.Equal(t, int64(1), c.Get("conn-2", "2"))
+	assert.Equal(t, int64(0), c.Get("conn-3", "3"))
+
+	buf := bytes.NewBuffer(nil)
+	_, err := c.WriteTo(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, `# HELP yomo_test_total Test counter.
+# TYPE yomo_test_total counter
+yomo_test_total{conn_id="conn-1",tag="1"} 3
+yomo_test_total{conn_id="conn-2",tag="2"} 1
+`, buf.String())
+
+	c.DeleteMatch(LabelConnID, "conn-1")
+	assert.Equal(t, int64(0), c.Get("conn-1", "1"))
+	assert.Equal(t, int64(1), c.Get("conn-2", "2"))
+}
+
+func TestGaugeVec(t *testing.T) {
+	g := &GaugeVec{CounterVec: newVec("yomo_test_depth", "Test gauge.", "gauge", []string{LabelConnID})}
+
+	g.Set(5, "conn-1")
+	g.Set(2, "conn-1")
+	assert.Equal(t, int64(2), g.Get("conn-1"))
+
+	buf := bytes.NewBuffer(nil)
+	_, err := g.WriteTo(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, "# HELP yomo_test_depth Test gauge.\n# TYPE yomo_test_depth gauge\nyomo_test_depth{conn_id=\"conn-1\"} 2\n", buf.String())
+}
+
+func TestFormatLabels(t *testing.T) {
+	c := newVec("yomo_test_labels_total", "Test labels.", "counter", []string{LabelName})
+
+	// only the backslash, the double quote and the line feed are escaped, the others are kept as is.
+	assert.Equal(t, `{name="a\\b\"c\nd`+"\t"+`é"}`, c.formatLabels([]string{"a\\b\"c\nd\té"}))
+}
+
+func TestHandler(t *testing.T) {
+	ClientReconnects.Inc("source-for-test")
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "# TYPE yomo_server_handshakes_total counter\n")
+	assert.Contains(t, w.Body.String(), `yomo_client_reconnects_total{name="source-for-test"} 1`)
+}
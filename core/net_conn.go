@@ -0,0 +1,144 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/lucas-clemente/quic-go"
+)
+
+// errSingleStream is returned by netConnection when the stream other than the only one is asked.
+var errSingleStream = errors.New("the connection carries a single stream")
+
+// netConnection adapts a reliable net.Conn, e.g. TCP+TLS or WebSocket, to quic.Connection,
+// so the server and client handle it as a QUIC connection. It carries a single bidirectional
+// stream of Y3 frames, the streams of tags and the datagrams fall back to it.
+type netConnection struct {
+	conn   net.Conn
+	stream *netStream
+	state  quic.ConnectionState
+	taken  bool
+	mu     sync.Mutex
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+var _ quic.Connection = &netConnection{}
+
+func newNetConnection(conn net.Conn, proto string) *netConnection {
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &netConnection{
+		conn:   conn,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	c.stream = &netStream{Conn: conn, ctx: ctx, close: c.close}
+	c.state.TLS.NegotiatedProtocol = proto
+	return c
+}
+
+// takeStream returns the stream at the first call, the connection is closed with it.
+func (c *netConnection) takeStream() (quic.Stream, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.taken {
+		return nil, errSingleStream
+	}
+	c.taken = true
+	return c.stream, nil
+}
+
+// AcceptStream returns the only stream, then blocks until the connection is closed.
+func (c *netConnection) AcceptStream(ctx context.Context) (quic.Stream, error) {
+	if stream, err := c.takeStream(); err == nil {
+		return stream, nil
+	}
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-c.ctx.Done():
+		return nil, net.ErrClosed
+	}
+}
+
+// AcceptUniStream is not supported.
+func (c *netConnection) AcceptUniStream(context.Context) (quic.ReceiveStream, error) {
+	return nil, errSingleStream
+}
+
+// OpenStream returns the only stream.
+func (c *netConnection) OpenStream() (quic.Stream, error) {
+	return c.takeStream()
+}
+
+// OpenStreamSync returns the only stream.
+func (c *netConnection) OpenStreamSync(context.Context) (quic.Stream, error) {
+	return c.takeStream()
+}
+
+// OpenUniStream is not supported.
+func (c *netConnection) OpenUniStream() (quic.SendStream, error) {
+	return nil, errSingleStream
+}
+
+// OpenUniStreamSync is not supported.
+func (c *netConnection) OpenUniStreamSync(context.Context) (quic.SendStream, error) {
+	return nil, errSingleStream
+}
+
+func (c *netConnection) LocalAddr() net.Addr  { return c.conn.LocalAddr() }
+func (c *netConnection) RemoteAddr() net.Addr { return c.conn.RemoteAddr() }
+
+// CloseWithError closes the connection, the peer reads EOF instead of the error code.
+func (c *netConnection) CloseWithError(code quic.ApplicationErrorCode, msg string) error {
+	return c.close()
+}
+
+func (c *netConnection) close() error {
+	c.cancel()
+	return c.conn.Close()
+}
+
+func (c *netConnection) Context() context.Context { return c.ctx }
+
+// ConnectionState returns the negotiated protocol, datagrams are not supported.
+func (c *netConnection) ConnectionState() quic.ConnectionState { return c.state }
+
+// SendMessage is not supported.
+func (c *netConnection) SendMessage([]byte) error {
+	return errors.New("datagrams are not supported by the connection")
+}
+
+// ReceiveMessage is not supported.
+func (c *netConnection) ReceiveMessage() ([]byte, error) {
+	return nil, errors.New("datagrams are not supported by the connection")
+}
+
+// netStream is the only stream of netConnection, closing it closes the connection.
+type netStream struct {
+	net.Conn
+	ctx   context.Context
+	close func() error
+}
+
+var _ quic.Stream = &netStream{}
+
+func (s *netStream) StreamID() quic.StreamID { return 0 }
+
+func (s *netStream) Read(p []byte) (int, error) {
+	n, err := s.Conn.Read(p)
+	if ne, ok := err.(net.Error); err != nil && !(ok && ne.Timeout()) {
+		// the connection is gone with its only stream.
+		s.close()
+	}
+	return n, err
+}
+
+func (s *netStream) Close() error                     { return s.close() }
+func (s *netStream) CancelRead(quic.StreamErrorCode)  { s.close() }
+func (s *netStream) CancelWrite(quic.StreamErrorCode) { s.close() }
+
+func (s *netStream) Context() context.Context { return s.ctx }
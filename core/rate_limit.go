@@ -0,0 +1,70 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/core/metrics"
+	"github.com/yomorun/yomo/core/ratelimit"
+	"github.com/yomorun/yomo/core/yerr"
+	"github.com/yomorun/yomo/pkg/config"
+	"github.com/yomorun/yomo/pkg/logger"
+)
+
+// ErrDropFrame is returned by the before handlers to drop the frame, the connection is kept.
+var ErrDropFrame = errors.New("yomo: drop the frame")
+
+// delayFrame is returned by the before handlers to handle the frame after the delay,
+// the following frames of the connection are not blocked by it.
+type delayFrame time.Duration
+
+func (d delayFrame) Error() string {
+	return fmt.Sprintf("yomo: delay the frame %v", time.Duration(d))
+}
+
+// RateLimitHandler returns a before handler which limits the DataFrames by the limiter,
+// the DataFrames over the limit are dropped, delayed or the client is disconnected
+// with `ErrorCodeRateLimited`, according to the policy of the rate limit.
+func (s *Server) RateLimitHandler(limiter *ratelimit.Limiter) FrameHandler {
+	return func(c *Context) error {
+		f, ok := c.Frame.(*frame.DataFrame)
+		if !ok {
+			return nil
+		}
+		conn := s.connector.Get(c.ConnID())
+		// the frames from upstream zippers are limited by upstream.
+		if conn == nil || conn.ClientType() == ClientTypeUpstreamZipper {
+			return nil
+		}
+		identity, _ := s.identities.Load(c.ConnID())
+		id, _ := identity.(string)
+		result := limiter.Allow(ratelimit.Subject{
+			Name:     conn.Name(),
+			ClientID: conn.ClientID(),
+			Identity: id,
+			Tenant:   metadata.TenantOf(conn.Metadata()),
+			Tag:      f.GetDataTag(),
+		})
+		if result.Allowed && result.Delay == 0 {
+			return nil
+		}
+
+		if result.Allowed {
+			metrics.ServerRateLimited.Inc(conn.Name(), tagLabel(f.GetDataTag()), config.RateLimitPolicyDelay)
+			logger.Debugf("%s[%s](%s) is over the rate limit by %s, delay %v", ServerLogPrefix, conn.Name(), c.ConnID(), result.By, result.Delay)
+			return delayFrame(result.Delay)
+		}
+		if result.Policy == config.RateLimitPolicyDisconnect {
+			metrics.ServerRateLimited.Inc(conn.Name(), tagLabel(f.GetDataTag()), config.RateLimitPolicyDisconnect)
+			logger.Warnf("%s[%s](%s) is over the rate limit by %s, disconnect it", ServerLogPrefix, conn.Name(), c.ConnID(), result.By)
+			return yerr.New(yerr.ErrorCodeRateLimited, fmt.Errorf("[%s] is over the rate limit by %s", conn.Name(), result.By))
+		}
+		// the frames delayed too long are dropped too.
+		metrics.ServerRateLimited.Inc(conn.Name(), tagLabel(f.GetDataTag()), config.RateLimitPolicyDrop)
+		logger.Debugf("%s[%s](%s) is over the rate limit by %s, drop the frame: %v", ServerLogPrefix, conn.Name(), c.ConnID(), result.By, f)
+		return ErrDropFrame
+	}
+}
@@ -0,0 +1,105 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/core/ratelimit"
+	"github.com/yomorun/yomo/core/router"
+	"github.com/yomorun/yomo/core/yerr"
+	"github.com/yomorun/yomo/pkg/config"
+)
+
+func TestRateLimitHandler(t *testing.T) {
+	metadataBuilder := metadata.DefaultBuilder()
+	routers := router.Default([]config.App{})
+
+	connector := buildMockConnector(routers, metadataBuilder, []mockConnectorArgs{
+		{
+			name:       "source-1",
+			clientID:   "source-1-id",
+			clientType: byte(ClientTypeSource),
+			connID:     "source-1-conn-id",
+			stream:     newStreamAssert([]byte{}),
+		},
+		{
+			name:       "source-2",
+			clientID:   "source-2-id",
+			clientType: byte(ClientTypeSource),
+			connID:     "source-2-conn-id",
+			stream:     newStreamAssert([]byte{}),
+		},
+		{
+			name:       "source-3",
+			clientID:   "source-3-id",
+			clientType: byte(ClientTypeSource),
+			connID:     "source-3-conn-id",
+			stream:     newStreamAssert([]byte{}),
+		},
+	})
+	defer connector.Clean()
+
+	server := &Server{connector: connector}
+	handler := server.RateLimitHandler(ratelimit.New([]config.RateLimit{
+		{By: config.RateLimitByName, Value: "source-1", Rate: 1},
+		{By: config.RateLimitByName, Value: "source-2", Rate: 1, Policy: config.RateLimitPolicyDisconnect},
+		{By: config.RateLimitByName, Value: "source-3", Rate: 1, Burst: 1, Policy: config.RateLimitPolicyDelay},
+	}))
+
+	newContext := func(connID string) *Context {
+		dataFrame := frame.NewDataFrame()
+		dataFrame.SetCarriage(1, []byte("hello yomo"))
+		return &Context{connID: connID, Frame: dataFrame}
+	}
+
+	t.Run("drop", func(t *testing.T) {
+		assert.NoError(t, handler(newContext("source-1-conn-id")))
+		assert.ErrorIs(t, handler(newContext("source-1-conn-id")), ErrDropFrame)
+	})
+
+	t.Run("disconnect", func(t *testing.T) {
+		assert.NoError(t, handler(newContext("source-2-conn-id")))
+		err := handler(newContext("source-2-conn-id"))
+		assert.Error(t, err)
+		assert.Equal(t, yerr.ErrorCodeRateLimited, err.(yerr.YomoError).ErrorCode())
+	})
+
+	t.Run("delay", func(t *testing.T) {
+		assert.NoError(t, handler(newContext("source-3-conn-id")))
+		// the frame is delayed until the token is refilled.
+		d, ok := handler(newContext("source-3-conn-id")).(delayFrame)
+		assert.True(t, ok)
+		assert.InDelta(t, time.Second, time.Duration(d), float64(100*time.Millisecond))
+	})
+
+	t.Run("not data frame", func(t *testing.T) {
+		c := &Context{connID: "source-1-conn-id", Frame: frame.NewGoawayFrame("goaway")}
+		assert.NoError(t, handler(c))
+	})
+}
+
+func TestHandleFrameDelayed(t *testing.T) {
+	server := &Server{}
+	handled := make(chan string, 2)
+	server.SetBeforeHandlers(
+		func(c *Context) error { return delayFrame(50 * time.Millisecond) },
+		func(c *Context) error { handled <- "before"; return nil },
+	)
+	server.SetAfterHandlers(func(c *Context) error { handled <- "after"; return nil })
+
+	// the frame is handled by the rest of handlers after the delay, the caller is not blocked.
+	assert.True(t, server.handleFrame(&Context{connID: "conn-1", Frame: frame.NewGoawayFrame("goaway")}))
+	assert.Len(t, handled, 0)
+
+	for _, want := range []string{"before", "after"} {
+		select {
+		case got := <-handled:
+			assert.Equal(t, want, got)
+		case <-time.After(time.Second):
+			t.Fatalf("the delayed frame is not handled by the %s handler", want)
+		}
+	}
+}
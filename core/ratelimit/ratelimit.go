@@ -0,0 +1,187 @@
+// Package ratelimit limits the DataFrames received by zipper with token buckets.
+//
+// Each rate limit applies to the DataFrames whose key, e.g. the name of client, matches its value,
+// every distinct value has its own token bucket. A DataFrame must be allowed by all the matched
+// rate limits, the one over the limit decides what to do with it by its policy.
+package ratelimit
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/pkg/config"
+)
+
+// anyValue is the value of rate limit which applies to every value of the key.
+const anyValue = "*"
+
+// sweepInterval is the interval to evict the idle token buckets, e.g. the ones of disconnected clients.
+const sweepInterval = time.Minute
+
+// Subject describes the DataFrame and the client sending it.
+type Subject struct {
+	Name     string
+	ClientID string
+	Identity string
+	Tenant   string
+	Tag      frame.Tag
+}
+
+func (s Subject) value(by string) string {
+	switch by {
+	case config.RateLimitByName:
+		return s.Name
+	case config.RateLimitByClientID:
+		return s.ClientID
+	case config.RateLimitByIdentity:
+		return s.Identity
+	case config.RateLimitByTenant:
+		return s.Tenant
+	case config.RateLimitByTag:
+		return strconv.FormatUint(uint64(s.Tag), 10)
+	default:
+		return ""
+	}
+}
+
+// Result is the result of a DataFrame checked by the Limiter.
+type Result struct {
+	// Allowed is true if the DataFrame can be handled, after waiting for Delay.
+	Allowed bool
+	// Delay is the duration to wait before handling the DataFrame.
+	Delay time.Duration
+	// Policy is the policy of the rate limit which denies the DataFrame.
+	Policy string
+	// By is the key of the rate limit which denies or delays the DataFrame.
+	By string
+}
+
+// Limiter limits the DataFrames by the rate limits, It is safe for concurrent use.
+type Limiter struct {
+	mu      sync.Mutex
+	limits  []limit
+	buckets map[string]*bucket // index of limit/value -> bucket
+	swept   time.Time
+	now     func() time.Time
+}
+
+type limit struct {
+	config.RateLimit
+	value string
+}
+
+type bucket struct {
+	limit  int // index of limit
+	tokens float64
+	last   time.Time
+}
+
+// New creates a Limiter with the rate limits.
+func New(limits []config.RateLimit) *Limiter {
+	l := &Limiter{now: time.Now}
+	l.Update(limits)
+	return l
+}
+
+// Update replaces all the rate limits, the token buckets are reset.
+func (l *Limiter) Update(limits []config.RateLimit) {
+	result := make([]limit, 0, len(limits))
+	for _, rl := range limits {
+		if rl.Burst <= 0 {
+			rl.Burst = int(math.Max(1, rl.Rate))
+		}
+		if rl.Policy == "" {
+			rl.Policy = config.RateLimitPolicyDrop
+		}
+		value := rl.Value
+		if rl.By == config.RateLimitByTag && value != "" && value != anyValue {
+			// the tag can be written in hex, e.g. 0x33.
+			if tag, err := strconv.ParseUint(value, 0, 32); err == nil {
+				value = strconv.FormatUint(tag, 10)
+			}
+		}
+		result = append(result, limit{RateLimit: rl, value: value})
+	}
+
+	l.mu.Lock()
+	l.limits = result
+	l.buckets = make(map[string]*bucket)
+	l.swept = l.now()
+	l.mu.Unlock()
+}
+
+// Allow takes a token for the DataFrame from the token bucket of every matched rate limit,
+// the tokens are taken only if all the matched rate limits allow the DataFrame.
+func (l *Limiter) Allow(s Subject) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.swept) >= sweepInterval {
+		l.sweep(now)
+	}
+
+	result := Result{Allowed: true}
+	matched := make([]*bucket, 0, len(l.limits))
+	for i, rl := range l.limits {
+		value := s.value(rl.By)
+		if rl.value != "" && rl.value != anyValue && rl.value != value {
+			continue
+		}
+		key := strconv.Itoa(i) + "/" + value
+		b, ok := l.buckets[key]
+		if !ok {
+			b = &bucket{limit: i, tokens: float64(rl.Burst), last: now}
+			l.buckets[key] = b
+		}
+		b.refill(now, rl.Rate, rl.Burst)
+		delay, ok := b.check(rl.Rate, rl.Burst, rl.Policy == config.RateLimitPolicyDelay)
+		if !ok {
+			return Result{Policy: rl.Policy, By: rl.By}
+		}
+		if delay > result.Delay {
+			result.Delay = delay
+			result.By = rl.By
+		}
+		matched = append(matched, b)
+	}
+	for _, b := range matched {
+		b.tokens--
+	}
+	return result
+}
+
+// sweep evicts the token buckets which have been full for longer than burst/rate,
+// a new bucket is full, so evicting them does not change the rate limits.
+func (l *Limiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		rl := l.limits[b.limit]
+		full := b.last.Add(time.Duration((float64(rl.Burst) - b.tokens) / rl.Rate * float64(time.Second)))
+		if now.Sub(full) > time.Duration(float64(rl.Burst)/rl.Rate*float64(time.Second)) {
+			delete(l.buckets, key)
+		}
+	}
+	l.swept = now
+}
+
+// refill adds the tokens generated since the last refill, up to burst.
+func (b *bucket) refill(now time.Time, rate float64, burst int) {
+	b.tokens = math.Min(float64(burst), b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+}
+
+// check returns true if a token can be taken from the bucket, if there is no token and reserve is true,
+// a token can be reserved and It returns the duration until the token is refilled.
+// At most burst tokens can be reserved, so the delay is no longer than burst/rate.
+func (b *bucket) check(rate float64, burst int, reserve bool) (time.Duration, bool) {
+	if b.tokens >= 1 {
+		return 0, true
+	}
+	if !reserve || b.tokens-1 < -float64(burst) {
+		return 0, false
+	}
+	return time.Duration(-(b.tokens - 1) / rate * float64(time.Second)), true
+}
@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/pkg/config"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Now()
+	l := New([]config.RateLimit{
+		{By: config.RateLimitByName, Value: "source-1", Rate: 1, Burst: 1},
+		{By: config.RateLimitByTag, Value: "0x33", Rate: 1, Policy: config.RateLimitPolicyDisconnect},
+		{By: config.RateLimitByClientID, Rate: 1, Policy: config.RateLimitPolicyDelay},
+	})
+	l.now = func() time.Time { return now }
+
+	t.Run("drop", func(t *testing.T) {
+		s := Subject{Name: "source-1", ClientID: "client-1"}
+		assert.True(t, l.Allow(s).Allowed)
+		assert.Equal(t, Result{Policy: config.RateLimitPolicyDrop, By: config.RateLimitByName}, l.Allow(s))
+		// the other names are not limited by the rate limit.
+		assert.Equal(t, Result{Allowed: true}, l.Allow(Subject{Name: "source-2", ClientID: "client-2"}))
+	})
+
+	t.Run("disconnect", func(t *testing.T) {
+		s := Subject{Name: "source-3", ClientID: "client-3", Tag: 0x33}
+		assert.Equal(t, Result{Allowed: true}, l.Allow(s))
+		assert.Equal(t, Result{Policy: config.RateLimitPolicyDisconnect, By: config.RateLimitByTag}, l.Allow(s))
+	})
+
+	t.Run("delay", func(t *testing.T) {
+		s := Subject{Name: "source-4", ClientID: "client-4"}
+		assert.Equal(t, Result{Allowed: true}, l.Allow(s))
+		assert.Equal(t, Result{Allowed: true, Delay: time.Second, By: config.RateLimitByClientID}, l.Allow(s))
+		// at most burst tokens are reserved.
+		assert.Equal(t, Result{Policy: config.RateLimitPolicyDelay, By: config.RateLimitByClientID}, l.Allow(s))
+	})
+
+	t.Run("refill", func(t *testing.T) {
+		now = now.Add(2 * time.Second)
+		assert.Equal(t, Result{Allowed: true}, l.Allow(Subject{Name: "source-1", ClientID: "client-5"}))
+	})
+
+	t.Run("update", func(t *testing.T) {
+		l.Update(nil)
+		for i := 0; i < 10; i++ {
+			assert.Equal(t, Result{Allowed: true}, l.Allow(Subject{Name: "source-1", ClientID: "client-1"}))
+		}
+	})
+}
+
+func TestLimiterDeniedTakesNoToken(t *testing.T) {
+	now := time.Now()
+	l := New([]config.RateLimit{
+		{By: config.RateLimitByName, Value: "source-1", Rate: 1, Burst: 2},
+		{By: config.RateLimitByTag, Value: "0x33", Rate: 1, Burst: 1},
+	})
+	l.now = func() time.Time { return now }
+
+	s := Subject{Name: "source-1", Tag: 0x33}
+	assert.True(t, l.Allow(s).Allowed)
+	// denied by the tag, the token of the name is not taken.
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, Result{Policy: config.RateLimitPolicyDrop, By: config.RateLimitByTag}, l.Allow(s))
+	}
+	assert.Equal(t, Result{Allowed: true}, l.Allow(Subject{Name: "source-1", Tag: 0x34}))
+}
+
+func TestLimiterSweep(t *testing.T) {
+	now := time.Now()
+	l := New([]config.RateLimit{{By: config.RateLimitByClientID, Rate: 1, Burst: 2}})
+	l.now = func() time.Time { return now }
+
+	assert.True(t, l.Allow(Subject{ClientID: "client-1"}).Allowed)
+	assert.True(t, l.Allow(Subject{ClientID: "client-2"}).Allowed)
+	assert.Len(t, l.buckets, 2)
+
+	// the bucket of client-1 is full after 1s, and idle for longer than burst/rate.
+	now = now.Add(sweepInterval + time.Second)
+	assert.True(t, l.Allow(Subject{ClientID: "client-2"}).Allowed)
+	assert.Len(t, l.buckets, 1)
+	assert.Contains(t, l.buckets, "0/client-2")
+}
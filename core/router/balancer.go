@@ -0,0 +1,126 @@
+package router
+
+import (
+	"hash/crc32"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/yomorun/yomo/pkg/config"
+)
+
+// Load returns the number of frames in flight to the connection,
+// It is used by the `least-inflight` load balancing.
+type Load func(connID string) int
+
+// Balancer picks one instance from the instances of a stream function.
+type Balancer interface {
+	// Pick returns one of the candidates, the candidates are connection ids and never empty,
+	// the slice of candidates is owned by the caller and must not be modified.
+	Pick(candidates []string, key string) string
+}
+
+// newBalancer creates a Balancer by the strategy, It returns nil if the strategy is empty.
+func newBalancer(strategy string, load func() Load) Balancer {
+	switch strategy {
+	case config.LoadBalanceRoundRobin:
+		return &roundRobinBalancer{}
+	case config.LoadBalanceLeastInflight:
+		return &leastInflightBalancer{load: load}
+	case config.LoadBalanceConsistentHash:
+		return &consistentHashBalancer{}
+	default:
+		return nil
+	}
+}
+
+type roundRobinBalancer struct {
+	next uint64
+}
+
+func (b *roundRobinBalancer) Pick(candidates []string, key string) string {
+	candidates = sorted(candidates)
+	n := atomic.AddUint64(&b.next, 1) - 1
+	return candidates[n%uint64(len(candidates))]
+}
+
+// sorted returns a sorted copy of the candidates.
+func sorted(candidates []string) []string {
+	result := make([]string, len(candidates))
+	copy(result, candidates)
+	sort.Strings(result)
+	return result
+}
+
+// leastInflightBalancer picks the instance with the fewest frames in flight,
+// the instances with same load are picked in turn.
+type leastInflightBalancer struct {
+	roundRobinBalancer
+	load func() Load
+}
+
+func (b *leastInflightBalancer) Pick(candidates []string, key string) string {
+	load := b.load()
+	if load == nil {
+		return b.roundRobinBalancer.Pick(candidates, key)
+	}
+
+	least := []string{}
+	min := -1
+	for _, connID := range candidates {
+		n := load(connID)
+		if min < 0 || n < min {
+			min = n
+			least = least[:0]
+		}
+		if n == min {
+			least = append(least, connID)
+		}
+	}
+	return b.roundRobinBalancer.Pick(least, key)
+}
+
+// consistentHashBalancer picks the instance by consistent hashing on the key,
+// the same key is always sent to the same instance as long as the instances do not change.
+type consistentHashBalancer struct {
+	mu     sync.Mutex
+	ringID string
+	hashes []uint32
+	nodes  map[uint32]string
+}
+
+// virtualNodes is the number of virtual nodes of each instance on the hash ring.
+const virtualNodes = 64
+
+func (b *consistentHashBalancer) Pick(candidates []string, key string) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	candidates = sorted(candidates)
+	if ringID := strings.Join(candidates, ","); ringID != b.ringID {
+		b.build(candidates)
+		b.ringID = ringID
+	}
+
+	h := crc32.ChecksumIEEE([]byte(key))
+	i := sort.Search(len(b.hashes), func(i int) bool { return b.hashes[i] >= h })
+	if i == len(b.hashes) {
+		i = 0
+	}
+	return b.nodes[b.hashes[i]]
+}
+
+func (b *consistentHashBalancer) build(candidates []string) {
+	b.hashes = make([]uint32, 0, len(candidates)*virtualNodes)
+	b.nodes = make(map[uint32]string, len(candidates)*virtualNodes)
+	for _, connID := range candidates {
+		for i := 0; i < virtualNodes; i++ {
+			h := crc32.ChecksumIEEE([]byte(connID + "#" + strconv.Itoa(i)))
+			b.hashes = append(b.hashes, h)
+			b.nodes[h] = connID
+		}
+	}
+	sort.Slice(b.hashes, func(i, j int) bool { return b.hashes[i] < b.hashes[j] })
+}
@@ -3,6 +3,7 @@ package router
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 
 	"github.com/yomorun/yomo/core/frame"
@@ -27,6 +28,19 @@ func (r *DefaultRouter) Route(metadata metadata.Metadata) Route {
 	return r.r
 }
 
+// SetLoad sets the load of connections for the `least-inflight` load balancing.
+func (r *DefaultRouter) SetLoad(load Load) {
+	r.r.mu.Lock()
+	r.r.load = load
+	r.r.mu.Unlock()
+}
+
+// Update replaces the stream functions of the router, the routes of removed stream functions
+// and of those whose input disagrees with the new workflow are deleted, It returns the connection ids of them.
+func (r *DefaultRouter) Update(functions []config.App) []string {
+	return r.r.update(functions)
+}
+
 // Clean clean router.
 func (r *DefaultRouter) Clean() {
 	r.r.mu.Lock()
@@ -40,38 +54,138 @@ func (r *DefaultRouter) Clean() {
 type defaultRoute struct {
 	functions []config.App
 	data      map[frame.Tag]map[string]string
+	balancers map[string]Balancer
+	load      Load
 	mu        sync.RWMutex
 }
 
 func newRoute(functions []config.App) *defaultRoute {
-	return &defaultRoute{
+	r := &defaultRoute{
 		functions: functions,
 		data:      make(map[frame.Tag]map[string]string),
 	}
+	r.balancers = r.newBalancers(functions)
+	return r
+}
+
+func (r *defaultRoute) newBalancers(functions []config.App) map[string]Balancer {
+	balancers := make(map[string]Balancer)
+	// the load is read by balancers while the route is locked.
+	load := func() Load { return r.load }
+	for _, v := range functions {
+		if b := newBalancer(v.LoadBalance, load); b != nil {
+			balancers[v.Name] = b
+		}
+	}
+	return balancers
+}
+
+func (r *defaultRoute) update(functions []config.App) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	apps := make(map[string]config.App, len(functions))
+	for _, v := range functions {
+		apps[v.Name] = v
+	}
+
+	// the observed data tags of connections.
+	observed := make(map[string][]frame.Tag)
+	for tag, conns := range r.data {
+		for connID := range conns {
+			observed[connID] = append(observed[connID], tag)
+		}
+	}
+
+	removed := []string{}
+	for _, conns := range r.data {
+		for connID, name := range conns {
+			app, ok := apps[name]
+			// the stream function is removed or its input is changed.
+			if !ok || len(app.Input) > 0 && !sameTags(app.Input, observed[connID]) {
+				delete(conns, connID)
+				removed = append(removed, connID)
+			}
+		}
+	}
+
+	balancers := r.newBalancers(functions)
+	// the stream functions which are no longer load balanced keep one instance,
+	// the others are removed as if they were replaced by it.
+	instances := make(map[string][]string)
+	for _, conns := range r.data {
+		for connID, name := range conns {
+			if _, ok := balancers[name]; !ok {
+				instances[name] = append(instances[name], connID)
+			}
+		}
+	}
+	for _, connIDs := range instances {
+		connIDs = unique(connIDs)
+		sort.Strings(connIDs)
+		for _, connID := range connIDs[1:] {
+			for _, conns := range r.data {
+				delete(conns, connID)
+			}
+			removed = append(removed, connID)
+		}
+	}
+
+	// keep the state of balancers whose strategy is unchanged.
+	for _, old := range r.functions {
+		for _, v := range functions {
+			if v.Name == old.Name && v.LoadBalance == old.LoadBalance && r.balancers[v.Name] != nil {
+				balancers[v.Name] = r.balancers[v.Name]
+			}
+		}
+	}
+	r.functions = functions
+	r.balancers = balancers
+
+	return unique(removed)
+}
+
+func unique(connIDs []string) []string {
+	seen := make(map[string]bool, len(connIDs))
+	result := make([]string, 0, len(connIDs))
+	for _, connID := range connIDs {
+		if !seen[connID] {
+			seen[connID] = true
+			result = append(result, connID)
+		}
+	}
+	return result
 }
 
 func (r *defaultRoute) Add(connID string, name string, observeDataTags []frame.Tag) (err error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	ok := false
-	for _, v := range r.functions {
+	var app *config.App
+	for i, v := range r.functions {
 		if v.Name == name {
-			ok = true
+			app = &r.functions[i]
 			break
 		}
 	}
-	if !ok {
+	if app == nil {
 		return fmt.Errorf("SFN[%s] does not exist in config functions", name)
 	}
+	if len(app.Input) > 0 && !sameTags(app.Input, observeDataTags) {
+		return fmt.Errorf("SFN[%s] observes data tags %v, but the input of workflow is %v", name, observeDataTags, app.Input)
+	}
 
-LOOP:
-	for _, conns := range r.data {
-		for connID, n := range conns {
-			if n == name {
-				err = yerr.NewDuplicateNameError(connID, fmt.Errorf("SFN[%s] is already linked to another connection", name))
-				delete(conns, connID)
-				break LOOP
+	// load balanced stream function can have multiple instances,
+	// otherwise the connection linked to the name is replaced.
+	if _, ok := r.balancers[name]; !ok {
+	LOOP:
+		for _, conns := range r.data {
+			for connID, n := range conns {
+				if n == name {
+					err = yerr.NewDuplicateNameError(connID, fmt.Errorf("SFN[%s] is already linked to another connection", name))
+					delete(conns, connID)
+					break LOOP
+				}
 			}
 		}
 	}
@@ -99,15 +213,92 @@ func (r *defaultRoute) Remove(connID string) error {
 	return nil
 }
 
+// empty returns true if no connection is in the route.
+func (r *defaultRoute) empty() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, conns := range r.data {
+		if len(conns) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// GetForwardRoutes returns the subscribers of the data tag, only one instance of each load balanced
+// stream function is returned, the consistent hashing picks it without the hash key.
 func (r *defaultRoute) GetForwardRoutes(tag frame.Tag) []string {
+	return r.forwardRoutes(tag, func(string) string { return "" })
+}
+
+// GetFrameRoutes returns the subscribers of the data frame by its data tag,
+// only one instance of each load balanced stream function is returned.
+func (r *defaultRoute) GetFrameRoutes(f *frame.DataFrame) []string {
+	return r.forwardRoutes(f.GetDataTag(), func(name string) string { return r.hashKey(name, f) })
+}
+
+func (r *defaultRoute) forwardRoutes(tag frame.Tag, key func(name string) string) []string {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	var keys []string
-	if conns := r.data[tag]; conns != nil {
-		for k := range conns {
-			keys = append(keys, k)
+	conns := r.data[tag]
+	if conns == nil {
+		return keys
+	}
+
+	// instances of load balanced stream functions, keyed by name.
+	instances := make(map[string][]string)
+	for connID, name := range conns {
+		if _, ok := r.balancers[name]; ok {
+			instances[name] = append(instances[name], connID)
+			continue
 		}
+		keys = append(keys, connID)
+	}
+	for name, candidates := range instances {
+		keys = append(keys, r.balancers[name].Pick(candidates, key(name)))
 	}
 	return keys
 }
+
+// hashKey returns the key of the data frame for consistent hashing.
+func (r *defaultRoute) hashKey(name string, f *frame.DataFrame) string {
+	for _, v := range r.functions {
+		if v.Name == name && v.HashKey == config.HashKeyTransactionID {
+			return f.TransactionID()
+		}
+	}
+	return f.SourceID()
+}
+
+func (r *defaultRoute) GetSnapshot() map[frame.Tag]map[string]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make(map[frame.Tag]map[string]string, len(r.data))
+	for tag, conns := range r.data {
+		result[tag] = make(map[string]string, len(conns))
+		for connID, name := range conns {
+			result[tag][connID] = name
+		}
+	}
+	return result
+}
+
+// sameTags returns true if a and b have the same tags regardless of order.
+func sameTags(a []frame.Tag, b []frame.Tag) bool {
+	setA := make(map[frame.Tag]bool, len(a))
+	for _, tag := range a {
+		setA[tag] = true
+	}
+	setB := make(map[frame.Tag]bool, len(b))
+	for _, tag := range b {
+		if !setA[tag] {
+			return false
+		}
+		setB[tag] = true
+	}
+	return len(setA) == len(setB)
+}
@@ -1,6 +1,7 @@
 package router
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -22,6 +23,8 @@ func TestRouter(t *testing.T) {
 	ids := route.GetForwardRoutes(frame.Tag(1))
 	assert.Equal(t, []string{"conn-1"}, ids)
 
+	assert.Equal(t, map[frame.Tag]map[string]string{frame.Tag(1): {"conn-1": "sfn-1"}}, route.(SnapshotRoute).GetSnapshot())
+
 	err = route.Add("conn-2", "sfn-2", []frame.Tag{frame.Tag(2)})
 	assert.EqualError(t, err, "SFN[sfn-2] does not exist in config functions")
 
@@ -39,3 +42,192 @@ func TestRouter(t *testing.T) {
 	ids = route.GetForwardRoutes(frame.Tag(1))
 	assert.Equal(t, []string(nil), ids)
 }
+
+func TestRouterLoadBalance(t *testing.T) {
+	router := Default([]config.App{
+		{Name: "sfn-rr", LoadBalance: config.LoadBalanceRoundRobin},
+		{Name: "sfn-hash", LoadBalance: config.LoadBalanceConsistentHash, HashKey: config.HashKeyTransactionID},
+		{Name: "sfn-least", LoadBalance: config.LoadBalanceLeastInflight},
+	})
+	route := router.Route(&metadata.Default{})
+
+	t.Run("round robin", func(t *testing.T) {
+		assert.NoError(t, route.Add("conn-1", "sfn-rr", []frame.Tag{frame.Tag(1)}))
+		assert.NoError(t, route.Add("conn-2", "sfn-rr", []frame.Tag{frame.Tag(1)}))
+
+		f := newDataFrame(frame.Tag(1), "source-1", "tid-1")
+		assert.Equal(t, []string{"conn-1"}, ForwardRoutes(route, f))
+		assert.Equal(t, []string{"conn-2"}, ForwardRoutes(route, f))
+		assert.Equal(t, []string{"conn-1"}, ForwardRoutes(route, f))
+	})
+
+	t.Run("consistent hash", func(t *testing.T) {
+		assert.NoError(t, route.Add("conn-3", "sfn-hash", []frame.Tag{frame.Tag(2)}))
+		assert.NoError(t, route.Add("conn-4", "sfn-hash", []frame.Tag{frame.Tag(2)}))
+
+		picked := map[string]bool{}
+		for i := 0; i < 100; i++ {
+			f := newDataFrame(frame.Tag(2), "source-1", fmt.Sprintf("tid-%d", i))
+			ids := ForwardRoutes(route, f)
+			assert.Len(t, ids, 1)
+			assert.Equal(t, ids, ForwardRoutes(route, f), "the same key should be routed to the same instance")
+			picked[ids[0]] = true
+		}
+		assert.Len(t, picked, 2, "the keys should be spread to all the instances")
+	})
+
+	t.Run("least inflight", func(t *testing.T) {
+		assert.NoError(t, route.Add("conn-5", "sfn-least", []frame.Tag{frame.Tag(3)}))
+		assert.NoError(t, route.Add("conn-6", "sfn-least", []frame.Tag{frame.Tag(3)}))
+
+		router.(*DefaultRouter).SetLoad(func(connID string) int {
+			if connID == "conn-5" {
+				return 10
+			}
+			return 1
+		})
+
+		f := newDataFrame(frame.Tag(3), "source-1", "tid-1")
+		assert.Equal(t, []string{"conn-6"}, ForwardRoutes(route, f))
+		assert.Equal(t, []string{"conn-6"}, ForwardRoutes(route, f))
+	})
+
+	t.Run("mixed with normal function", func(t *testing.T) {
+		router := Default([]config.App{{Name: "sfn-1"}, {Name: "sfn-rr", LoadBalance: config.LoadBalanceRoundRobin}})
+		route := router.Route(&metadata.Default{})
+
+		assert.NoError(t, route.Add("conn-1", "sfn-1", []frame.Tag{frame.Tag(1)}))
+		assert.NoError(t, route.Add("conn-2", "sfn-rr", []frame.Tag{frame.Tag(1)}))
+		assert.NoError(t, route.Add("conn-3", "sfn-rr", []frame.Tag{frame.Tag(1)}))
+
+		ids := ForwardRoutes(route, newDataFrame(frame.Tag(1), "source-1", "tid-1"))
+		assert.Len(t, ids, 2)
+		assert.Contains(t, ids, "conn-1")
+
+		// the route of tag also returns one instance of the load balanced stream function.
+		ids = route.GetForwardRoutes(frame.Tag(1))
+		assert.Len(t, ids, 2)
+		assert.Contains(t, ids, "conn-1")
+	})
+}
+
+func TestBalancerPick(t *testing.T) {
+	load := func() Load { return nil }
+	for _, strategy := range []string{config.LoadBalanceRoundRobin, config.LoadBalanceLeastInflight, config.LoadBalanceConsistentHash} {
+		candidates := []string{"conn-3", "conn-1", "conn-2"}
+		picked := newBalancer(strategy, load).Pick(candidates, "key")
+
+		assert.Contains(t, candidates, picked, strategy)
+		// the candidates of caller are not sorted in place.
+		assert.Equal(t, []string{"conn-3", "conn-1", "conn-2"}, candidates, strategy)
+	}
+}
+
+func newDataFrame(tag frame.Tag, sourceID string, tid string) *frame.DataFrame {
+	f := frame.NewDataFrame()
+	f.SetCarriage(tag, []byte("hello"))
+	f.SetSourceID(sourceID)
+	f.SetTransactionID(tid)
+	return f
+}
+
+func TestRouterUpdate(t *testing.T) {
+	router := Default([]config.App{{Name: "sfn-1"}, {Name: "sfn-2"}})
+	route := router.Route(&metadata.Default{})
+
+	assert.NoError(t, route.Add("conn-1", "sfn-1", []frame.Tag{frame.Tag(1), frame.Tag(2)}))
+	assert.NoError(t, route.Add("conn-2", "sfn-2", []frame.Tag{frame.Tag(1)}))
+
+	removed := router.(*DefaultRouter).Update([]config.App{{Name: "sfn-2"}, {Name: "sfn-3"}})
+	assert.Equal(t, []string{"conn-1"}, removed)
+
+	assert.Equal(t, []string{"conn-2"}, route.GetForwardRoutes(frame.Tag(1)))
+
+	assert.Error(t, route.Add("conn-1", "sfn-1", []frame.Tag{frame.Tag(1)}))
+	assert.NoError(t, route.Add("conn-3", "sfn-3", []frame.Tag{frame.Tag(1)}))
+}
+
+func TestRouterUpdateLoadBalance(t *testing.T) {
+	router := Default([]config.App{{Name: "sfn-1", LoadBalance: config.LoadBalanceRoundRobin}})
+	route := router.Route(&metadata.Default{})
+
+	assert.NoError(t, route.Add("conn-2", "sfn-1", []frame.Tag{frame.Tag(1), frame.Tag(2)}))
+	assert.NoError(t, route.Add("conn-1", "sfn-1", []frame.Tag{frame.Tag(1), frame.Tag(2)}))
+
+	// only one instance is kept after the load balancing is disabled.
+	removed := router.(*DefaultRouter).Update([]config.App{{Name: "sfn-1"}})
+	assert.Equal(t, []string{"conn-2"}, removed)
+	assert.Equal(t, map[frame.Tag]map[string]string{
+		frame.Tag(1): {"conn-1": "sfn-1"},
+		frame.Tag(2): {"conn-1": "sfn-1"},
+	}, route.(SnapshotRoute).GetSnapshot())
+}
+
+func TestRouterPipeline(t *testing.T) {
+	router := Default([]config.App{
+		{Name: "sfn-1", Input: []frame.Tag{1}, Output: []frame.Tag{2}},
+		{Name: "sfn-2", Input: []frame.Tag{2, 3}},
+	})
+	route := router.Route(&metadata.Default{})
+
+	assert.NoError(t, route.Add("conn-1", "sfn-1", []frame.Tag{frame.Tag(1)}))
+	assert.NoError(t, route.Add("conn-2", "sfn-2", []frame.Tag{frame.Tag(3), frame.Tag(2)}))
+
+	err := route.Add("conn-3", "sfn-1", []frame.Tag{frame.Tag(1), frame.Tag(2)})
+	assert.EqualError(t, err, "SFN[sfn-1] observes data tags [1 2], but the input of workflow is [1]")
+
+	err = route.Add("conn-4", "sfn-2", []frame.Tag{frame.Tag(2)})
+	assert.EqualError(t, err, "SFN[sfn-2] observes data tags [2], but the input of workflow is [2 3]")
+
+	// the input of sfn-2 is changed.
+	removed := router.(*DefaultRouter).Update([]config.App{
+		{Name: "sfn-1", Input: []frame.Tag{1}, Output: []frame.Tag{2}},
+		{Name: "sfn-2", Input: []frame.Tag{2}},
+	})
+	assert.Equal(t, []string{"conn-2"}, removed)
+}
+
+func TestTenantRouter(t *testing.T) {
+	router := Tenant([]config.App{{Name: "sfn-1"}, {Name: "sfn-2"}})
+
+	routeA := router.Route(metadata.NewTenant("tenant-a"))
+	routeB := router.Route(metadata.NewTenant("tenant-b"))
+	assert.Same(t, routeA, router.Route(metadata.NewTenant("tenant-a")))
+	assert.NotSame(t, routeA, routeB)
+
+	// the same name can be used by different tenants.
+	assert.NoError(t, routeA.Add("conn-1", "sfn-1", []frame.Tag{frame.Tag(1)}))
+	assert.NoError(t, routeB.Add("conn-2", "sfn-1", []frame.Tag{frame.Tag(1)}))
+
+	assert.Equal(t, []string{"conn-1"}, routeA.GetForwardRoutes(frame.Tag(1)))
+	assert.Equal(t, []string{"conn-2"}, routeB.GetForwardRoutes(frame.Tag(1)))
+
+	// the metadata without tenant uses the default tenant.
+	assert.Empty(t, router.Route(&metadata.Default{}).GetForwardRoutes(frame.Tag(1)))
+
+	removed := router.(*TenantRouter).Update([]config.App{{Name: "sfn-2"}})
+	assert.ElementsMatch(t, []string{"conn-1", "conn-2"}, removed)
+
+	router.Clean()
+	assert.NotSame(t, routeA, router.Route(metadata.NewTenant("tenant-a")))
+}
+
+func TestTenantRouterRemoveRoute(t *testing.T) {
+	router := Tenant([]config.App{{Name: "sfn-1"}, {Name: "sfn-2"}})
+	tenant := metadata.NewTenant("tenant-a")
+
+	route := router.Route(tenant)
+	assert.NoError(t, route.Add("conn-1", "sfn-1", []frame.Tag{frame.Tag(1)}))
+	assert.NoError(t, route.Add("conn-2", "sfn-2", []frame.Tag{frame.Tag(2)}))
+
+	// the route is kept until its last connection is removed.
+	assert.NoError(t, route.Remove("conn-1"))
+	assert.Same(t, route, router.Route(tenant))
+	assert.NoError(t, route.Remove("conn-2"))
+	assert.Empty(t, router.(*TenantRouter).routes)
+
+	// the removed route is added back by adding a connection to it.
+	assert.NoError(t, route.Add("conn-3", "sfn-1", []frame.Tag{frame.Tag(1)}))
+	assert.Same(t, route, router.Route(tenant))
+	assert.Equal(t, []string{"conn-3"}, router.Route(tenant).GetForwardRoutes(frame.Tag(1)))
+}
@@ -27,3 +27,26 @@ type Route interface {
 	// GetForwardRoutes returns all the subscribers by the given data tag.
 	GetForwardRoutes(tag frame.Tag) []string
 }
+
+// FrameRoute is implemented by the Route which picks the subscribers by the whole data frame,
+// e.g. one of the load balanced instances of a stream function by the source id.
+type FrameRoute interface {
+	// GetFrameRoutes returns the subscribers of the data frame.
+	GetFrameRoutes(f *frame.DataFrame) []string
+}
+
+// SnapshotRoute is implemented by the Route which can tell its route table.
+type SnapshotRoute interface {
+	// GetSnapshot returns a copy of the route table, It maps data tag to subscribers,
+	// each subscriber is a pair of connection id and name.
+	GetSnapshot() map[frame.Tag]map[string]string
+}
+
+// ForwardRoutes returns the subscribers of the data frame, It is the subscribers of its data tag
+// unless the route implements FrameRoute.
+func ForwardRoutes(route Route, f *frame.DataFrame) []string {
+	if r, ok := route.(FrameRoute); ok {
+		return r.GetFrameRoutes(f)
+	}
+	return route.GetForwardRoutes(f.GetDataTag())
+}
@@ -0,0 +1,116 @@
+package router
+
+import (
+	"sync"
+
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/pkg/config"
+)
+
+// TenantRouter keeps an isolated `Route` for each tenant, the data of a tenant
+// is only routed to the stream functions of the same tenant.
+// The route of a tenant is removed when its last connection is removed.
+type TenantRouter struct {
+	functions []config.App
+	routes    map[string]*tenantRoute
+	load      Load
+	mu        sync.Mutex
+}
+
+// Tenant return the TenantRouter, each tenant has the same stream functions.
+func Tenant(functions []config.App) Router {
+	return &TenantRouter{
+		functions: functions,
+		routes:    make(map[string]*tenantRoute),
+	}
+}
+
+// Route get the route of the tenant in metadata, the route is created on first use.
+func (r *TenantRouter) Route(m metadata.Metadata) Route {
+	tenant := metadata.TenantOf(m)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	route, ok := r.routes[tenant]
+	if !ok {
+		route = &tenantRoute{defaultRoute: newRoute(r.functions), router: r, tenant: tenant}
+		route.load = r.load
+		r.routes[tenant] = route
+	}
+	return route
+}
+
+// SetLoad sets the load of connections for the `least-inflight` load balancing.
+func (r *TenantRouter) SetLoad(load Load) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.load = load
+	for _, route := range r.routes {
+		route.mu.Lock()
+		route.load = load
+		route.mu.Unlock()
+	}
+}
+
+// Update replaces the stream functions of all tenants, It returns the connection ids
+// of the stream functions which are removed or whose input disagrees with the new workflow.
+func (r *TenantRouter) Update(functions []config.App) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.functions = functions
+	removed := []string{}
+	for _, route := range r.routes {
+		removed = append(removed, route.update(functions)...)
+	}
+	return removed
+}
+
+// tenantRoute is the route of a tenant, It is removed from the router when it has no connection.
+type tenantRoute struct {
+	*defaultRoute
+	router *TenantRouter
+	tenant string
+}
+
+// Add adds the connection to the route, the route removed from the router after it is got,
+// e.g. the last connection of the tenant is removed during the handshake, is added back.
+func (r *tenantRoute) Add(connID string, name string, observeDataTags []frame.Tag) error {
+	r.router.mu.Lock()
+	defer r.router.mu.Unlock()
+
+	route, ok := r.router.routes[r.tenant]
+	if !ok {
+		route = r
+		r.router.routes[r.tenant] = r
+	}
+	return route.defaultRoute.Add(connID, name, observeDataTags)
+}
+
+// Remove removes the connection from the route, the route is removed from the router
+// if it is the last connection.
+func (r *tenantRoute) Remove(connID string) error {
+	r.router.mu.Lock()
+	defer r.router.mu.Unlock()
+
+	if err := r.defaultRoute.Remove(connID); err != nil {
+		return err
+	}
+	if r.router.routes[r.tenant] == r && r.empty() {
+		delete(r.router.routes, r.tenant)
+	}
+	return nil
+}
+
+// Clean clean router.
+func (r *TenantRouter) Clean() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for tenant := range r.routes {
+		delete(r.routes, tenant)
+	}
+}
@@ -2,30 +2,50 @@ package core
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net"
 	"os"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/lucas-clemente/quic-go"
 	"github.com/yomorun/yomo/core/auth"
+	"github.com/yomorun/yomo/core/compress"
+	"github.com/yomorun/yomo/core/envelope"
 	"github.com/yomorun/yomo/core/frame"
 	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/core/metrics"
 	"github.com/yomorun/yomo/core/router"
+	"github.com/yomorun/yomo/core/trace"
 	"github.com/yomorun/yomo/core/yerr"
 
 	// authentication implements, Currently, only token authentication is implemented
 	_ "github.com/yomorun/yomo/pkg/auth"
 	"github.com/yomorun/yomo/pkg/logger"
+	pkgtls "github.com/yomorun/yomo/pkg/tls"
 )
 
 const (
 	// DefaultListenAddr is the default address to listen.
 	DefaultListenAddr = "0.0.0.0:9000"
+	// DefaultMaxHops is the default maximum times a broadcast DataFrame is forwarded across zippers,
+	// the DataFrames of sources are forwarded to the downstream zippers, but not relayed by them,
+	// relaying in a cascade needs MaxHops greater than 1.
+	DefaultMaxHops = 1
+)
+
+// The reasons of dropping the broadcast DataFrames across zippers.
+const (
+	meshDropVisited = "visited"
+	meshDropMaxHops = "max_hops"
+	// meshDropNoRelay is the DataFrame of upstream zipper not relayed because MaxHops is 1,
+	// It is the end of the cascade rather than the loop protection.
+	meshDropNoRelay = "no_relay"
 )
 
 // ServerOption is the option for server.
@@ -42,16 +62,28 @@ type Server struct {
 	name                    string
 	connector               Connector
 	router                  router.Router
+	acl                     atomic.Pointer[auth.ACL]
+	identities              sync.Map // connID -> identity of the credential
+	compressions            sync.Map // connID -> accepted compression algorithms
+	subscribers             sync.Map // connID -> true, the local clients subscribing data tags
+	advertisees             sync.Map // connID -> true, the upstream zippers asked for the observed data tags
+	meshRoutes              sync.Map // zipper name -> connID of the upstream zipper the results are routed back to
+	inflight                inflight
+	deadLetters             *deadLetterRing
+	stores                  *storeForward
+	tracer                  *trace.Tracer
 	metadataBuilder         metadata.Builder
 	alpnHandler             func(proto string) error
 	counterOfDataFrame      int64
+	counterOfMeshDropped    int64
 	downstreams             map[string]frame.Writer
+	downstreamFilters       map[string]*TagFilter
 	mu                      sync.Mutex
 	opts                    ServerOptions
 	beforeHandlers          []FrameHandler
 	afterHandlers           []FrameHandler
 	connectionCloseHandlers []ConnectionHandler
-	listener                Listener
+	listeners               []Listener
 	wg                      *sync.WaitGroup
 }
 
@@ -75,6 +107,13 @@ func (s *Server) Init(opts ...ServerOption) error {
 	}
 	// options defaults
 	s.initOptions()
+	if s.opts.DeadLetterRingSize > 0 {
+		s.deadLetters = newDeadLetterRing(s.opts.DeadLetterRingSize)
+	}
+	if s.opts.StoreDir != "" {
+		s.stores = newStoreForward(s.opts.StoreDir, s.opts.StoreMaxSize, s.opts.StoreMaxAge)
+	}
+	s.tracer = trace.NewTracer(s.name, s.opts.TraceExporter)
 
 	return nil
 }
@@ -111,16 +150,60 @@ func (s *Server) Serve(ctx context.Context, conn net.PacketConn) error {
 		logger.Errorf("%slistener.Listen: err=%v", ServerLogPrefix, err)
 		return err
 	}
-	s.listener = listener
-	// defer listener.Close()
-	logger.Printf("%s✅ [%s][%d] Listening on: %s, QUIC: %v, AUTH: %s", ServerLogPrefix, s.name, os.Getpid(), listener.Addr(), listener.Versions(), s.authNames())
+	// the fallback transports for the networks blocking UDP
+	if err := s.listenFallbacks(ctx, conn.LocalAddr().String()); err != nil {
+		listener.Close()
+		return err
+	}
+	return s.serveListener(ctx, listener)
+}
+
+// listenFallbacks listens TCP+TLS and WebSocket if their addresses are set.
+func (s *Server) listenFallbacks(ctx context.Context, addr string) error {
+	if s.opts.TCPAddr == "" && s.opts.WebSocketAddr == "" {
+		return nil
+	}
+	tlsConfig := s.opts.TLSConfig
+	if tlsConfig == nil {
+		tc, err := pkgtls.CreateServerTLSConfig(addr)
+		if err != nil {
+			return err
+		}
+		tlsConfig = tc
+	}
+	if s.opts.TCPAddr != "" {
+		listener, err := newTCPListener(s.opts.TCPAddr, tlsConfig)
+		if err != nil {
+			return err
+		}
+		go s.serveListener(ctx, listener)
+	}
+	if s.opts.WebSocketAddr != "" {
+		// the WebSocket clients may not offer the protocol of yomo.
+		tc := tlsConfig.Clone()
+		tc.NextProtos = append(tc.NextProtos, "http/1.1")
+		listener, err := newWebSocketListener(s.opts.WebSocketAddr, s.opts.WebSocketPath, tc)
+		if err != nil {
+			return err
+		}
+		go s.serveListener(ctx, listener)
+	}
+	return nil
+}
+
+// serveListener accepts the connections from listener and serves them.
+func (s *Server) serveListener(ctx context.Context, listener Listener) error {
+	s.mu.Lock()
+	s.listeners = append(s.listeners, listener)
+	s.mu.Unlock()
+	logger.Printf("%s✅ [%s][%d] Listening on: %s, %s: %v, AUTH: %s", ServerLogPrefix, s.name, os.Getpid(), listener.Addr(), listener.Name(), listener.Versions(), s.authNames())
 
 	for {
 		// create a new connection when new yomo-client connected
 		sctx, cancel := context.WithCancel(ctx)
 		defer cancel()
 
-		conn, err := s.listener.Accept(sctx)
+		conn, err := listener.Accept(sctx)
 		if err != nil {
 			logger.Errorf("%slistener accept connections error: %v", ServerLogPrefix, err)
 			return err
@@ -146,19 +229,7 @@ func (s *Server) Serve(ctx context.Context, conn net.PacketConn) error {
 				if err != nil {
 					// if client close the connection, then we should close the connection
 					// @CC: when Source close the connection, it won't affect connectors
-					name := "-"
-					clientID := "-"
-					if conn := s.connector.Get(connID); conn != nil {
-						// connector
-						s.connector.Remove(connID)
-						route := s.router.Route(conn.Metadata())
-						if route != nil {
-							route.Remove(connID)
-						}
-						name = conn.Name()
-						clientID = conn.ClientID()
-						conn.Close()
-					}
+					name, clientID := s.removeConnection(connID)
 					logger.Printf("%s💔 [%s][%s](%s) close the connection: %v", ServerLogPrefix, name, clientID, connID, err)
 					break
 				}
@@ -179,6 +250,43 @@ func (s *Server) Serve(ctx context.Context, conn net.PacketConn) error {
 	}
 }
 
+// removeConnection removes the connection from the connector and the route, and closes it,
+// It returns the name and client id of the connection, or "-" if it is not found.
+func (s *Server) removeConnection(connID string) (name string, clientID string) {
+	name, clientID = "-", "-"
+	// the observed data tags are changed by removing a stream function or a subscriber.
+	_, observer := s.subscribers.Load(connID)
+	if conn := s.connector.Get(connID); conn != nil {
+		observer = observer || conn.ClientType() == ClientTypeStreamFunction
+		// connector
+		s.connector.Remove(connID)
+		route := s.router.Route(conn.Metadata())
+		if route != nil {
+			route.Remove(connID)
+		}
+		s.offlineStore(conn)
+		name = conn.Name()
+		clientID = conn.ClientID()
+		conn.Close()
+	}
+	s.identities.Delete(connID)
+	s.compressions.Delete(connID)
+	s.subscribers.Delete(connID)
+	s.advertisees.Delete(connID)
+	s.inflight.remove(connID)
+	s.meshRoutes.Range(func(name, id interface{}) bool {
+		if id == connID {
+			s.meshRoutes.Delete(name)
+		}
+		return true
+	})
+	deleteConnMetrics(connID)
+	if observer {
+		s.advertiseTags()
+	}
+	return name, clientID
+}
+
 // handshakeWithTimeout call handshake with a timeout.
 func (s *Server) handshakeWithTimeout(conn quic.Connection, stream quic.Stream, timeout time.Duration) bool {
 	ch := make(chan bool)
@@ -221,8 +329,8 @@ func (s *Server) handshake(conn quic.Connection, stream quic.Stream, fs frame.Re
 	c := newContext(conn, stream).WithFrame(frm)
 
 	if err := s.handleHandshakeFrame(c); err != nil {
-		if err := fs.WriteFrame(frame.NewGoawayFrame(err.Error())); err != nil {
-			logger.Errorf("%s⛔️ write to client[%s] GoawayFrame error:%v", ServerLogPrefix, conn.RemoteAddr().String(), err)
+		if err := fs.WriteFrame(handshakeErrorFrame(err)); err != nil {
+			logger.Errorf("%s⛔️ write to client[%s] handshake error:%v", ServerLogPrefix, conn.RemoteAddr().String(), err)
 		}
 		return false
 	}
@@ -230,12 +338,24 @@ func (s *Server) handshake(conn quic.Connection, stream quic.Stream, fs frame.Re
 	return true
 }
 
+// handshakeErrorFrame returns the frame responding to the failed handshake, the client rejected with
+// an error code, e.g. ErrorCodeForbidden by access control, receives a RejectedFrame carrying the code,
+// otherwise a GoawayFrame.
+func handshakeErrorFrame(err error) frame.Frame {
+	if e, ok := err.(yerr.YomoError); ok {
+		return frame.NewRejectedFrame(err.Error()).SetCode(uint64(e.ErrorCode()))
+	}
+	return frame.NewGoawayFrame(err.Error())
+}
+
 // Close will shutdown the server.
 func (s *Server) Close() error {
 	// listener
-	if s.listener != nil {
-		s.listener.Close()
+	s.mu.Lock()
+	for _, listener := range s.listeners {
+		listener.Close()
 	}
+	s.mu.Unlock()
 	// router
 	if s.router != nil {
 		s.router.Clean()
@@ -245,6 +365,10 @@ func (s *Server) Close() error {
 		s.connector.Clean()
 	}
 	s.wg.Wait()
+	// store-and-forward
+	if s.stores != nil {
+		s.stores.close()
+	}
 	return nil
 }
 
@@ -284,31 +408,56 @@ func (s *Server) handleConnection(c *Context) {
 		}
 
 		// add frame to context
-		c := c.WithFrame(f)
-
-		// before frame handlers
-		for _, handler := range s.beforeHandlers {
-			if err := handler(c); err != nil {
-				logger.Errorf("%sbeforeFrameHandler err: %s", ServerLogPrefix, err)
-				c.CloseWithError(yerr.ErrorCodeBeforeHandler, err.Error())
-				return
-			}
-		}
-		// main handler
-		if err := s.mainFrameHandler(c); err != nil {
-			logger.Errorf("%smainFrameHandler err: %s", ServerLogPrefix, err)
-			c.CloseWithError(yerr.ErrorCodeMainHandler, err.Error())
+		if !s.handleFrame(c.WithFrame(f)) {
 			return
 		}
-		// after frame handler
-		for _, handler := range s.afterHandlers {
-			if err := handler(c); err != nil {
-				logger.Errorf("%safterFrameHandler err: %s", ServerLogPrefix, err)
-				c.CloseWithError(yerr.ErrorCodeAfterHandler, err.Error())
-				return
+	}
+}
+
+// handleFrame handles the frame in context by the before, main and after handlers,
+// It returns false if the connection is closed by the handlers.
+func (s *Server) handleFrame(c *Context) bool {
+	return s.handleFrameFrom(c, 0)
+}
+
+// handleFrameFrom handles the frame from the before handler at index start, the frame delayed by
+// a before handler is handled by the rest of handlers in background after the delay.
+func (s *Server) handleFrameFrom(c *Context, start int) bool {
+	// before frame handlers
+	for i := start; i < len(s.beforeHandlers); i++ {
+		if err := s.beforeHandlers[i](c); err != nil {
+			if errors.Is(err, ErrDropFrame) {
+				return true
+			}
+			if d, ok := err.(delayFrame); ok {
+				delayed, next := c.clone(), i+1
+				time.AfterFunc(time.Duration(d), func() { s.handleFrameFrom(delayed, next) })
+				return true
 			}
+			logger.Errorf("%sbeforeFrameHandler err: %s", ServerLogPrefix, err)
+			code := yerr.ErrorCodeBeforeHandler
+			if e, ok := err.(yerr.YomoError); ok {
+				code = e.ErrorCode()
+			}
+			c.CloseWithError(code, err.Error())
+			return false
 		}
 	}
+	// main handler
+	if err := s.mainFrameHandler(c); err != nil {
+		logger.Errorf("%smainFrameHandler err: %s", ServerLogPrefix, err)
+		c.CloseWithError(yerr.ErrorCodeMainHandler, err.Error())
+		return false
+	}
+	// after frame handler
+	for _, handler := range s.afterHandlers {
+		if err := handler(c); err != nil {
+			logger.Errorf("%safterFrameHandler err: %s", ServerLogPrefix, err)
+			c.CloseWithError(yerr.ErrorCodeAfterHandler, err.Error())
+			return false
+		}
+	}
+	return true
 }
 
 func (s *Server) mainFrameHandler(c *Context) error {
@@ -319,8 +468,22 @@ func (s *Server) mainFrameHandler(c *Context) error {
 	case frame.TagOfHandshakeFrame:
 		logger.Errorf("%sreceive a handshakeFrame, ingonre it", ServerLogPrefix)
 	case frame.TagOfDataFrame:
+		// the broadcast DataFrame forwarded back to this zipper has been handled.
+		if f := c.Frame.(*frame.DataFrame); f.GetMetaFrame().HasVisited(s.name) {
+			s.dropMeshFrame(f, meshDropVisited)
+			return nil
+		}
+		// the span covers routing to stream functions and dispatching to downstream zippers,
+		// they continue the trace as the children of this span.
+		span := s.tracer.Start("zipper", c.Frame.(*frame.DataFrame))
+		defer span.End()
 		if err := s.handleDataFrame(c); err != nil {
-			c.CloseWithError(yerr.ErrorCodeData, fmt.Sprintf("handleDataFrame err: %v", err))
+			span.SetError(err)
+			code := yerr.ErrorCodeData
+			if e, ok := err.(yerr.YomoError); ok {
+				code = e.ErrorCode()
+			}
+			c.CloseWithError(code, fmt.Sprintf("handleDataFrame err: %v", err))
 		} else {
 			s.dispatchToDownstreams(c)
 
@@ -334,8 +497,15 @@ func (s *Server) mainFrameHandler(c *Context) error {
 }
 
 // handle HandShakeFrame
-func (s *Server) handleHandshakeFrame(c *Context) error {
+func (s *Server) handleHandshakeFrame(c *Context) (err error) {
 	f := c.Frame.(*frame.HandshakeFrame)
+	// the rejected handshake is failed even if the RejectedFrame is written.
+	rejected := false
+	defer func() {
+		if err != nil || rejected {
+			metrics.ServerHandshakes.Inc(authName(f.AuthName()), metrics.ResultFailure)
+		}
+	}()
 
 	// basic info
 	connID := c.ConnID()
@@ -348,6 +518,7 @@ func (s *Server) handleHandshakeFrame(c *Context) error {
 	authed := auth.Authenticate(s.opts.Auths, f)
 	logger.Debugf("%sauthenticated==%v", ServerLogPrefix, authed)
 	if !authed {
+		rejected = true
 		err := fmt.Errorf("handshake authentication fails, client credential name is %s", authName(f.AuthName()))
 		// return err
 		logger.Debugf("%s🔑 <%s> [%s](%s) is connected!", ServerLogPrefix, clientType, f.Name, connID)
@@ -359,8 +530,16 @@ func (s *Server) handleHandshakeFrame(c *Context) error {
 		return nil
 	}
 
+	// access control of observed data tags
+	identity := auth.Identify(s.opts.Auths, f)
+	if acl := s.acl.Load(); acl != nil && !acl.CanObserve(identity, f.ObserveDataTags) {
+		return yerr.New(yerr.ErrorCodeForbidden, fmt.Errorf("[%s] is not allowed to observe data tags %v", f.Name, f.ObserveDataTags))
+	}
+
 	// client type
 	var conn Connection
+	var replay *storeQueue
+	var advertise bool
 	switch clientType {
 	case ClientTypeSource, ClientTypeStreamFunction:
 		// metadata
@@ -392,9 +571,12 @@ func (s *Server) handleHandshakeFrame(c *Context) error {
 					return err
 				}
 			}
+			// buffer the DataFrames until the ones buffered while offline are replayed.
+			replay = s.pauseStore(metadata, f.Name)
 		}
 	case ClientTypeUpstreamZipper:
 		conn = newConnection(f.Name, f.ClientID, clientType, nil, stream, f.ObserveDataTags)
+		advertise = f.AdvertiseTags && s.advertisesTags()
 	default:
 		// TODO: There is no need to Remove,
 		// unknown client type is not be add to connector.
@@ -404,15 +586,81 @@ func (s *Server) handleHandshakeFrame(c *Context) error {
 		return err
 	}
 
-	if _, err := stream.Write(frame.NewHandshakeAckFrame().Encode()); err != nil {
+	// the DataFrames are written to the streams of their tags if the client asks for it.
+	if f.StreamPerTag {
+		conn = newStreamConnection(conn, c.Conn)
+	}
+	// the DataFrames received as datagrams are forwarded as datagrams if both sides enable them.
+	datagrams := supportsDatagrams(c.Conn)
+	if datagrams {
+		conn = newDatagramConnection(connID, conn, c.Conn)
+	}
+
+	// send queue
+	if s.opts.SendQueueSize > 0 {
+		closeConn := c.closer()
+		disconnect := func(code yerr.ErrorCode, msg string) {
+			closeConn(code, msg)
+			s.removeConnection(connID)
+		}
+		name, m := conn.Name(), conn.Metadata()
+		deadLetter := func(f *frame.DataFrame, reason DeadLetterReason, err error) {
+			s.deadLetter(s.router.Route(m), newDeadLetter(f, reason, connID, name, err))
+		}
+		conn = newQueuedConnection(connID, conn, s.opts.SendQueueSize, s.opts.SendQueuePolicy, disconnect, deadLetter)
+	}
+
+	ack := frame.NewHandshakeAckFrame()
+	ack.StreamPerTag = f.StreamPerTag
+	ack.AdvertiseTags = advertise
+	if _, err := stream.Write(ack.Encode()); err != nil {
 		logger.Debugf("%s🔑 write to <%s> [%s](%s) AckFrame error:%v", ServerLogPrefix, clientType, f.Name, connID, err)
 	}
 
 	s.connector.Add(connID, conn)
+	s.identities.Store(connID, identity)
+	s.compressions.Store(connID, f.Compressions)
+	metrics.ServerHandshakes.Inc(authName(f.AuthName()), metrics.ResultSuccess)
 	logger.Printf("%s❤️  <%s> [%s][%s](%s) is connected!", ServerLogPrefix, clientType, f.Name, clientID, connID)
+	if replay != nil {
+		go s.replayStore(replay, connID, conn)
+	}
+	if advertise {
+		s.advertisees.Store(connID, true)
+		if err := conn.Write(frame.NewObservedTagsFrame(s.observedTags())); err != nil {
+			logger.Errorf("%sadvertise tags to upstream zipper [%s](%s) error: %v", ServerLogPrefix, f.Name, connID, err)
+		}
+	}
+	if clientType == ClientTypeStreamFunction {
+		s.advertiseTags()
+	}
+	if f.StreamPerTag {
+		go s.acceptDataStreams(c.Conn)
+	}
+	if datagrams {
+		go s.receiveDatagrams(c.Conn)
+	}
 	return nil
 }
 
+// acceptDataStreams accepts the unidirectional streams opened by the client for the DataFrames of each tag,
+// they are handled as the ones on the handshake stream of the connection.
+func (s *Server) acceptDataStreams(qconn quic.Connection) {
+	for {
+		stream, err := qconn.AcceptUniStream(qconn.Context())
+		if err != nil {
+			// the connection is closed, it is cleaned up by the handshake stream.
+			return
+		}
+		logger.Infof("%s❤️3/ [stream:%d] data stream created, connID=%s", ServerLogPrefix, stream.StreamID(), GetConnID(qconn))
+		go func() {
+			c := newContext(qconn, receiveStream{stream})
+			defer c.Clean()
+			s.handleConnection(c)
+		}()
+	}
+}
+
 // will reuse quic-go's keep-alive feature
 // func (s *Server) handlePingFrame(stream quic.Stream, conn quic.Connection, f *frame.PingFrame) error {
 // 	logger.Infof("%s------> GOT ❤️ PingFrame : %# x", ServerLogPrefix, f)
@@ -431,14 +679,39 @@ func (s *Server) handleDataFrame(c *Context) error {
 	}
 
 	f := c.Frame.(*frame.DataFrame)
-
-	m, err := s.metadataBuilder.Decode(f.GetMetaFrame().Metadata())
-	if err != nil {
-		return err
+	tag := tagLabel(f.GetDataTag())
+	// the result of the stream function completes the DataFrame in flight to it.
+	if from.ClientType() == ClientTypeStreamFunction {
+		s.inflight.done(fromID, f.TransactionID())
 	}
-	metadata := m
-	if metadata == nil {
-		metadata = from.Metadata()
+	metrics.ServerFramesReceived.Inc(fromID, from.Name(), tag)
+	metrics.ServerBytesReceived.Add(int64(len(f.GetCarriage())), fromID, from.Name(), tag)
+
+	// access control of published data tag
+	if acl := s.acl.Load(); acl != nil {
+		identity, _ := s.identities.Load(fromID)
+		if id, _ := identity.(string); !acl.CanPublish(id, f.GetDataTag()) {
+			return yerr.New(yerr.ErrorCodeForbidden, fmt.Errorf("[%s] is not allowed to publish data tag %d", from.Name(), f.GetDataTag()))
+		}
+	}
+
+	// the frames from upstream zipper carry the metadata stamped by upstream,
+	// others are stamped with the metadata of the connection, so clients cannot forge it.
+	metadata := from.Metadata()
+	if from.ClientType() == ClientTypeUpstreamZipper || metadata == nil {
+		m, err := s.metadataBuilder.Decode(f.GetMetaFrame().Metadata())
+		if err != nil {
+			return err
+		}
+		metadata = m
+		// the results of the visited zippers are routed back through the upstream zipper.
+		if from.ClientType() == ClientTypeUpstreamZipper {
+			for _, name := range f.GetMetaFrame().Visited() {
+				s.meshRoutes.Store(name, fromID)
+			}
+		}
+	} else {
+		f.GetMetaFrame().SetMetadata(metadata.Encode())
 	}
 
 	// route
@@ -449,11 +722,19 @@ func (s *Server) handleDataFrame(c *Context) error {
 	}
 
 	// get stream function connection ids from route
-	connIDs := route.GetForwardRoutes(f.GetDataTag())
+	connIDs, stored := s.storeAndForward(metadata, f, router.ForwardRoutes(route, f))
+	published := s.publish(metadata, f)
+	if len(connIDs) == 0 && !stored && published == 0 {
+		metrics.ServerRouteMisses.Inc(tag)
+		if !s.deliveredElsewhere(from, f) {
+			s.deadLetter(route, newDeadLetter(f, DeadLetterNoRoute, "", "", nil))
+		}
+	}
 	for _, toID := range connIDs {
 		conn := s.connector.Get(toID)
 		if conn == nil {
 			logger.Errorf("%sconn is nil: (%s)", ServerLogPrefix, toID)
+			s.deadLetter(route, newDeadLetter(f, DeadLetterWriteFailed, toID, "", errors.New("connection not found")))
 			continue
 		}
 
@@ -461,23 +742,153 @@ func (s *Server) handleDataFrame(c *Context) error {
 		logger.Debugf("%shandleDataFrame [%s](%s) -> [%s](%s): %v", ServerLogPrefix, from.Name(), fromID, to, toID, f)
 
 		// write data frame to stream
-		if err := conn.Write(f); err != nil {
+		n, err := s.writeDataFrame(toID, conn, f)
+		if err != nil {
 			logger.Warnf("%shandleDataFrame conn.Write %v", ServerLogPrefix, err)
+			metrics.ServerWriteErrors.Inc(toID, to)
+			s.deadLetter(route, newDeadLetter(f, DeadLetterWriteFailed, toID, to, err))
+			continue
+		}
+		if conn.ClientType() == ClientTypeStreamFunction {
+			s.inflight.add(toID, f.TransactionID())
+		}
+		// the send queue counts the DataFrame after it is written, it may be dropped.
+		if _, queued := conn.(*queuedConnection); !queued {
+			metrics.ServerFramesSent.Inc(toID, to, tag)
+			metrics.ServerBytesSent.Add(int64(n), toID, to, tag)
 		}
 	}
 
 	return nil
 }
 
+// deliveredElsewhere returns true if the DataFrame without stream function to be routed
+// is still delivered, by backflow to the source or by dispatching to downstream zippers.
+func (s *Server) deliveredElsewhere(from Connection, f *frame.DataFrame) bool {
+	if len(s.sourceConns(f)) > 0 {
+		return true
+	}
+	return s.forwardable(from, f) && f.GetMetaFrame().Hops() < uint32(s.opts.MaxHops) && len(s.Downstreams()) > 0
+}
+
+// forwardable returns true if the DataFrame is forwarded to the downstream zippers, they are the broadcast
+// DataFrames of sources, and the ones of upstream zippers to be relayed.
+func (s *Server) forwardable(from Connection, f *frame.DataFrame) bool {
+	t := from.ClientType()
+	return (t == ClientTypeSource || t == ClientTypeUpstreamZipper) && f.IsBroadcast()
+}
+
+// dropMeshFrame counts the broadcast DataFrame not forwarded across zippers.
+func (s *Server) dropMeshFrame(f *frame.DataFrame, reason string) {
+	m := f.GetMetaFrame()
+	logger.Debugf("%sdrop DataFrame across zippers: reason=%s, tid=%s, hops=%d, visited=%v", ServerLogPrefix, reason, f.TransactionID(), m.Hops(), m.Visited())
+	atomic.AddInt64(&s.counterOfMeshDropped, 1)
+	metrics.ServerMeshDropped.Inc(reason)
+}
+
+// deadLetter hands the DataFrame failed to be delivered to the dead letter destinations.
+func (s *Server) deadLetter(route router.Route, d *DeadLetter) {
+	logger.Warnf("%sdead letter: reason=%s, tag=%d, tid=%s, to=[%s](%s), err=%s", ServerLogPrefix, d.Reason, d.Tag, d.TransactionID, d.Name, d.ConnID, d.Error)
+	metrics.ServerDeadLetters.Inc(string(d.Reason))
+
+	if s.deadLetters != nil {
+		s.deadLetters.add(d)
+	}
+	for _, h := range s.opts.DeadLetterHandlers {
+		h(d)
+	}
+
+	// route the dead letter as a DataFrame, the dead letters of it are dropped to avoid loops.
+	if s.opts.DeadLetterTag == 0 || d.Tag == s.opts.DeadLetterTag {
+		return
+	}
+	buf, err := json.Marshal(d)
+	if err != nil {
+		logger.Errorf("%sencode dead letter error: %v", ServerLogPrefix, err)
+		return
+	}
+	f := frame.NewDataFrame()
+	f.SetCarriage(s.opts.DeadLetterTag, buf)
+	f.SetTransactionID(d.TransactionID)
+	f.SetSourceID(d.SourceID)
+	f.GetMetaFrame().SetMetadata(d.Metadata)
+	for _, toID := range router.ForwardRoutes(route, f) {
+		if conn := s.connector.Get(toID); conn != nil {
+			if err := conn.Write(f); err != nil {
+				logger.Errorf("%swrite dead letter to [%s](%s) error: %v", ServerLogPrefix, conn.Name(), toID, err)
+			}
+		}
+	}
+}
+
+// DeadLetters returns the latest dead letters kept in memory, from the oldest to the latest,
+// It returns nil if the dead letter ring is disabled.
+func (s *Server) DeadLetters() []*DeadLetter {
+	if s.deadLetters == nil {
+		return nil
+	}
+	return s.deadLetters.list()
+}
+
 func (s *Server) handleBackflowFrame(c *Context) error {
 	f := c.Frame.(*frame.DataFrame)
+	// the results of the DataFrame forwarded from an upstream zipper are routed back to it.
+	if origin := f.GetMetaFrame().Origin(); origin != "" && origin != s.name {
+		conn := s.connector.Get(c.ConnID())
+		if conn == nil || conn.ClientType() == ClientTypeUpstreamZipper {
+			return nil
+		}
+		return s.routeBackflow(origin, f)
+	}
+	return s.backflow(f)
+}
+
+// HandleBackflow handles the results routed back from a downstream zipper, they are written to
+// the sources connected to this zipper, or routed back further to the origin zipper.
+func (s *Server) HandleBackflow(f *frame.DataFrame) {
+	var err error
+	if origin := f.GetMetaFrame().Origin(); origin != "" && origin != s.name {
+		err = s.routeBackflow(origin, f)
+	} else {
+		err = s.backflow(f)
+	}
+	if err != nil {
+		logger.Errorf("%s♻️  HandleBackflow error: %v", ServerLogPrefix, err)
+	}
+}
+
+// routeBackflow writes the results to the upstream zipper on the way back to the origin zipper.
+func (s *Server) routeBackflow(origin string, f *frame.DataFrame) error {
+	connID, ok := s.meshRoutes.Load(origin)
+	if !ok {
+		logger.Warnf("%s♻️  no route back to zipper [%s], result=%v", ServerLogPrefix, origin, f)
+		return nil
+	}
+	conn := s.connector.Get(connID.(string))
+	if conn == nil {
+		return nil
+	}
+	logger.Debugf("%s♻️  routeBackflow --> zipper:%s, result=%v", ServerLogPrefix, origin, f)
+	return conn.Write(f)
+}
+
+// backflow writes the results to the sources connected to this zipper with BackflowFrame,
+// the sealed carriage is written as is with its key id, the source opens it.
+func (s *Server) backflow(f *frame.DataFrame) error {
 	tag := f.GetDataTag()
-	carriage := f.GetCarriage()
 	sourceID := f.SourceID()
 	// write to source with BackflowFrame
-	bf := frame.NewBackflowFrame(tag, carriage)
-	sourceConns := s.connector.GetSourceConns(sourceID, tag)
-	for _, source := range sourceConns {
+	bf := frame.NewBackflowFrame(tag, f.GetCarriage()).SetTransactionID(f.TransactionID())
+	if envelope.Sealed(f) {
+		bf.SetKeyID(f.GetMetaFrame().KeyID(), f.Compression())
+	} else {
+		carriage, err := compress.Carriage(f)
+		if err != nil {
+			return err
+		}
+		bf.SetCarriage(carriage)
+	}
+	for _, source := range s.sourceConns(f) {
 		if source != nil {
 			logger.Debugf("%s♻️  handleBackflowFrame --> source:%s, result=%v", ServerLogPrefix, sourceID, f)
 			if err := source.Write(bf); err != nil {
@@ -489,6 +900,46 @@ func (s *Server) handleBackflowFrame(c *Context) error {
 	return nil
 }
 
+// sourceConns returns the connections of the source of the DataFrame observing its tag, they are
+// in the tenant of the DataFrame, so a source cannot receive the results of other tenants by its id.
+func (s *Server) sourceConns(f *frame.DataFrame) []Connection {
+	m, err := s.metadataBuilder.Decode(f.GetMetaFrame().Metadata())
+	if err != nil {
+		logger.Warnf("%sdecode metadata of DataFrame error: %v", ServerLogPrefix, err)
+		return nil
+	}
+	tenant := metadata.TenantOf(m)
+	conns := []Connection{}
+	for _, conn := range s.connector.GetSourceConns(f.SourceID(), f.GetDataTag()) {
+		if metadata.TenantOf(conn.Metadata()) == tenant {
+			conns = append(conns, conn)
+		}
+	}
+	return conns
+}
+
+// writeDataFrame writes the DataFrame to the connection, the compressed carriage is forwarded as is
+// if the connection accepts the compression, otherwise a decompressed copy is written.
+// The sealed carriage is always forwarded as is, zipper never opens it.
+// It returns the size of the carriage written.
+func (s *Server) writeDataFrame(connID string, conn Connection, f *frame.DataFrame) (int, error) {
+	accepted, _ := s.compressions.Load(connID)
+	names, _ := accepted.([]string)
+	if envelope.Sealed(f) || compress.Accepts(names, f.Compression()) {
+		return len(f.GetCarriage()), conn.Write(f)
+	}
+	carriage, err := compress.Carriage(f)
+	if err != nil {
+		return 0, err
+	}
+	copied, err := frame.DecodeToDataFrame(f.Encode())
+	if err != nil {
+		return 0, err
+	}
+	copied.SetCarriage(f.GetDataTag(), carriage)
+	return len(carriage), conn.Write(copied)
+}
+
 // StatsFunctions returns the sfn stats of server.
 func (s *Server) StatsFunctions() map[string]string {
 	return s.connector.GetSnapshot()
@@ -499,19 +950,77 @@ func (s *Server) StatsCounter() int64 {
 	return s.counterOfDataFrame
 }
 
+// StatsMeshDropped returns how many broadcast DataFrames are dropped to prevent loops across zippers,
+// or over the max hops.
+func (s *Server) StatsMeshDropped() int64 {
+	return atomic.LoadInt64(&s.counterOfMeshDropped)
+}
+
+// StatsRoutes returns the route table of server, It is keyed by the observed data tag,
+// the value maps connection id to the name of the stream function.
+func (s *Server) StatsRoutes() map[frame.Tag]map[string]string {
+	result := make(map[frame.Tag]map[string]string)
+	routes := make(map[router.Route]struct{})
+	for _, conn := range s.connector.GetConns() {
+		route := s.router.Route(conn.Metadata())
+		if route == nil {
+			continue
+		}
+		if _, ok := routes[route]; ok {
+			continue
+		}
+		routes[route] = struct{}{}
+		snapshot, ok := route.(router.SnapshotRoute)
+		if !ok {
+			continue
+		}
+		for tag, conns := range snapshot.GetSnapshot() {
+			if result[tag] == nil {
+				result[tag] = make(map[string]string)
+			}
+			for connID, name := range conns {
+				result[tag][connID] = name
+			}
+		}
+	}
+	return result
+}
+
 // Downstreams return all the downstream servers.
 func (s *Server) Downstreams() map[string]frame.Writer {
-	return s.downstreams
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	downstreams := make(map[string]frame.Writer, len(s.downstreams))
+	for addr, ds := range s.downstreams {
+		downstreams[addr] = ds
+	}
+	return downstreams
 }
 
 // ConfigRouter is used to set router by zipper
-func (s *Server) ConfigRouter(router router.Router) {
+func (s *Server) ConfigRouter(r router.Router) {
 	s.mu.Lock()
-	s.router = router
-	logger.Debugf("%sconfig router is %#v", ServerLogPrefix, router)
+	s.router = r
+	// the load of connections is used by load balancing.
+	if v, ok := r.(interface{ SetLoad(router.Load) }); ok {
+		v.SetLoad(s.connLoad)
+	}
+	logger.Debugf("%sconfig router is %#v", ServerLogPrefix, r)
 	s.mu.Unlock()
 }
 
+// ConfigACL sets the access control of data tags, all data tags are allowed if it is nil.
+func (s *Server) ConfigACL(acl *auth.ACL) {
+	s.acl.Store(acl)
+}
+
+// connLoad returns the number of DataFrames in flight to the connection, they are dispatched
+// to the stream function and waiting for the results.
+func (s *Server) connLoad(connID string) int {
+	return s.inflight.load(connID)
+}
+
 // ConfigMetadataBuilder is used to set metadataBuilder by zipper
 func (s *Server) ConfigMetadataBuilder(builder metadata.Builder) {
 	s.mu.Lock()
@@ -536,24 +1045,66 @@ func (s *Server) AddDownstreamServer(addr string, c frame.Writer) {
 	s.mu.Unlock()
 }
 
+// RemoveDownstreamServer removes the downstream server by address, the downstream will be closed
+// if it implements io.Closer. It returns false if the downstream does not exist.
+func (s *Server) RemoveDownstreamServer(addr string) bool {
+	s.mu.Lock()
+	ds, ok := s.downstreams[addr]
+	delete(s.downstreams, addr)
+	delete(s.downstreamFilters, addr)
+	s.mu.Unlock()
+
+	if !ok {
+		return false
+	}
+	if closer, ok := ds.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			logger.Errorf("%sclose downstream [%s] error: %v", ServerLogPrefix, addr, err)
+		}
+	}
+	return true
+}
+
 // dispatch every DataFrames to all downstreams
 func (s *Server) dispatchToDownstreams(c *Context) {
 	conn := s.connector.Get(c.connID)
 	if conn == nil {
 		logger.Debugf("%sdispatchToDownstreams: s.connector.Get(%s) is nil", ServerLogPrefix, c.connID)
-	} else if conn.ClientType() == ClientTypeSource {
-		f := c.Frame.(*frame.DataFrame)
-		if f.IsBroadcast() {
-			if f.GetMetaFrame().Metadata() == nil {
-				f.GetMetaFrame().SetMetadata(conn.Metadata().Encode())
+	} else if f := c.Frame.(*frame.DataFrame); s.forwardable(conn, f) {
+		// the DataFrame no downstream zipper takes is not dropped across zippers.
+		downstreams := make(map[string]frame.Writer)
+		for addr, ds := range s.Downstreams() {
+			if s.forwardsTo(addr, ds, f.GetDataTag()) {
+				downstreams[addr] = ds
 			}
-			for addr, ds := range s.downstreams {
-				logger.Debugf("%sdispatching to [%s]: %# x", ServerLogPrefix, addr, f.TransactionID())
-				ds.WriteFrame(f)
+		}
+		if len(downstreams) == 0 {
+			return
+		}
+		if f.GetMetaFrame().Hops() >= uint32(s.opts.MaxHops) {
+			if conn.ClientType() == ClientTypeUpstreamZipper && s.opts.MaxHops <= 1 {
+				s.dropMeshFrame(f, meshDropNoRelay)
+			} else {
+				s.dropMeshFrame(f, meshDropMaxHops)
 			}
-		} else {
-			logger.Debugf("%sdispatchToDownstreams: frame is local only [%s, %s]", ServerLogPrefix, c.connID, f.TransactionID())
+			return
+		}
+		// the copy records this zipper is visited, the DataFrame may be still written to the local connections.
+		fwd := f.Clone()
+		if fwd.GetMetaFrame().Metadata() == nil {
+			fwd.GetMetaFrame().SetMetadata(conn.Metadata().Encode())
+		}
+		fwd.GetMetaFrame().Visit(s.name)
+		// the results are routed back to this zipper, where the source is connected.
+		if conn.ClientType() == ClientTypeSource {
+			fwd.GetMetaFrame().SetOrigin(s.name)
+		}
+		for addr, ds := range downstreams {
+			logger.Debugf("%sdispatching to [%s]: %# x", ServerLogPrefix, addr, f.TransactionID())
+			ds.WriteFrame(fwd)
 		}
+	} else if conn.ClientType() == ClientTypeSource {
+		logger.Debugf("%sdispatchToDownstreams: frame is local only [%s, %s]", ServerLogPrefix, c.connID, f.TransactionID())
 	}
 }
 
@@ -564,6 +1115,9 @@ func GetConnID(conn quic.Connection) string {
 
 func (s *Server) initOptions() {
 	// defaults
+	if s.opts.MaxHops <= 0 {
+		s.opts.MaxHops = DefaultMaxHops
+	}
 	if s.alpnHandler == nil {
 		s.alpnHandler = func(proto string) error {
 			logger.Infof("%sclient alpn proto is: %s", ServerLogPrefix, proto)
@@ -596,7 +1150,8 @@ func (s *Server) Connector() Connector {
 	return s.connector
 }
 
-// SetBeforeHandlers set the before handlers of server.
+// SetBeforeHandlers set the before handlers of server, the handler returns ErrDropFrame to drop
+// the frame without closing the connection, the code of YomoError is used to close the connection.
 func (s *Server) SetBeforeHandlers(handlers ...FrameHandler) {
 	s.beforeHandlers = append(s.beforeHandlers, handlers...)
 }
@@ -630,6 +1185,23 @@ func authName(name string) string {
 	return name
 }
 
+// tagLabel formats the data tag as a metrics label value.
+func tagLabel(tag frame.Tag) string {
+	return strconv.FormatUint(uint64(tag), 10)
+}
+
+// deleteConnMetrics deletes the metrics of the closed connection.
+func deleteConnMetrics(connID string) {
+	metrics.ServerFramesReceived.DeleteMatch(metrics.LabelConnID, connID)
+	metrics.ServerFramesSent.DeleteMatch(metrics.LabelConnID, connID)
+	metrics.ServerBytesReceived.DeleteMatch(metrics.LabelConnID, connID)
+	metrics.ServerBytesSent.DeleteMatch(metrics.LabelConnID, connID)
+	metrics.ServerWriteErrors.DeleteMatch(metrics.LabelConnID, connID)
+	metrics.ServerDatagramsOversized.DeleteMatch(metrics.LabelConnID, connID)
+	metrics.ServerDatagramsDropped.DeleteMatch(metrics.LabelConnID, connID)
+	metrics.ServerQueueDropped.DeleteMatch(metrics.LabelConnID, connID)
+}
+
 func (s *Server) doConnectionCloseHandlers(qconn quic.Connection) {
 	defer s.wg.Done()
 	logger.Debugf("%s🖤 [%s] quic connection closed", ServerLogPrefix, qconn.RemoteAddr())
@@ -3,9 +3,12 @@ package core
 import (
 	"crypto/tls"
 	"net"
+	"time"
 
 	"github.com/lucas-clemente/quic-go"
 	"github.com/yomorun/yomo/core/auth"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/trace"
 )
 
 // ServerOptions are the options for YoMo server.
@@ -15,6 +18,35 @@ type ServerOptions struct {
 	Addr       string
 	Auths      map[string]auth.Authentication
 	Conn       net.PacketConn
+	// SendQueueSize is the size of the send queue of each connection,
+	// frames are written synchronously if it is zero.
+	SendQueueSize int
+	// SendQueuePolicy decides what to do when the send queue is full.
+	SendQueuePolicy QueuePolicy
+	// DeadLetterHandlers handle the DataFrames failed to be delivered.
+	DeadLetterHandlers []DeadLetterHandler
+	// DeadLetterRingSize is the number of the latest dead letters kept in memory,
+	// disabled if it is zero.
+	DeadLetterRingSize int
+	// DeadLetterTag routes the dead letters as DataFrames of the tag to the stream functions
+	// observing it, the carriage is the JSON encoded DeadLetter, disabled if it is zero.
+	DeadLetterTag frame.Tag
+	// StoreDir is the directory of the logs buffering the DataFrames of offline stream functions,
+	// store-and-forward is disabled if it is empty.
+	StoreDir string
+	// StoreMaxSize is the size limit in bytes of the log of each stream function, no limit if it is zero.
+	StoreMaxSize int64
+	// StoreMaxAge is the age limit of the buffered DataFrames, no limit if it is zero.
+	StoreMaxAge time.Duration
+	// TraceExporter exports the tracing spans of DataFrames, tracing is disabled if it is nil.
+	TraceExporter trace.Exporter
+	// TCPAddr is the address to listen TCP+TLS for the clients in the networks blocking UDP, disabled if it is empty.
+	TCPAddr string
+	// WebSocketAddr is the address to listen WebSocket over TLS on WebSocketPath, disabled if it is empty.
+	WebSocketAddr string
+	WebSocketPath string
+	// MaxHops is the maximum times a broadcast DataFrame is forwarded across zippers, DefaultMaxHops if it is zero.
+	MaxHops int
 }
 
 // WithAddr sets the server address.
@@ -57,3 +89,74 @@ func WithConn(conn net.PacketConn) ServerOption {
 		o.Conn = conn
 	}
 }
+
+// WithSendQueue sets a bounded send queue with the overflow policy for each connection.
+func WithSendQueue(size int, policy QueuePolicy) ServerOption {
+	return func(o *ServerOptions) {
+		o.SendQueueSize = size
+		o.SendQueuePolicy = policy
+	}
+}
+
+// WithDeadLetterHandler adds a handler of the DataFrames failed to be delivered.
+func WithDeadLetterHandler(h DeadLetterHandler) ServerOption {
+	return func(o *ServerOptions) {
+		o.DeadLetterHandlers = append(o.DeadLetterHandlers, h)
+	}
+}
+
+// WithDeadLetterRing keeps the latest size dead letters in memory, they can be read by `Server.DeadLetters`.
+func WithDeadLetterRing(size int) ServerOption {
+	return func(o *ServerOptions) {
+		o.DeadLetterRingSize = size
+	}
+}
+
+// WithDeadLetterTag routes the dead letters to the stream functions observing tag.
+func WithDeadLetterTag(tag frame.Tag) ServerOption {
+	return func(o *ServerOptions) {
+		o.DeadLetterTag = tag
+	}
+}
+
+// WithStoreAndForward buffers the DataFrames of offline stream functions in dir, and replays
+// them after the stream functions reconnect, the stream functions are set by `Server.ConfigStoreAndForward`.
+func WithStoreAndForward(dir string, maxSize int64, maxAge time.Duration) ServerOption {
+	return func(o *ServerOptions) {
+		o.StoreDir = dir
+		o.StoreMaxSize = maxSize
+		o.StoreMaxAge = maxAge
+	}
+}
+
+// WithMaxHops sets the maximum times a broadcast DataFrame is forwarded across zippers, the DataFrames
+// received from upstream zippers are relayed to the downstream zippers within it, so relaying needs
+// it greater than 1, otherwise they are counted as `no_relay` mesh drops. The names of zippers
+// must be unique across the mesh, a zipper drops the DataFrames it has forwarded before.
+func WithMaxHops(n int) ServerOption {
+	return func(o *ServerOptions) {
+		o.MaxHops = n
+	}
+}
+
+// WithTraceExporter sets the exporter of tracing spans, tracing is disabled if it is nil.
+func WithTraceExporter(exporter trace.Exporter) ServerOption {
+	return func(o *ServerOptions) {
+		o.TraceExporter = exporter
+	}
+}
+
+// WithTCPAddr listens TCP+TLS on addr besides QUIC, for the clients in the networks blocking UDP.
+func WithTCPAddr(addr string) ServerOption {
+	return func(o *ServerOptions) {
+		o.TCPAddr = addr
+	}
+}
+
+// WithWebSocketAddr listens WebSocket over TLS on addr besides QUIC, the clients connect to `wss://addr/path`.
+func WithWebSocketAddr(addr string, path string) ServerOption {
+	return func(o *ServerOptions) {
+		o.WebSocketAddr = addr
+		o.WebSocketPath = path
+	}
+}
@@ -2,14 +2,18 @@ package core
 
 import (
 	"bytes"
+	"io"
 	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/yomorun/yomo/core/auth"
+	"github.com/yomorun/yomo/core/compress"
 	"github.com/yomorun/yomo/core/frame"
 	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/core/metrics"
 	"github.com/yomorun/yomo/core/router"
+	"github.com/yomorun/yomo/core/yerr"
 	yauth "github.com/yomorun/yomo/pkg/auth"
 	"github.com/yomorun/yomo/pkg/config"
 )
@@ -143,6 +147,8 @@ func TestHandleDataFrame(t *testing.T) {
 		assert.NoError(t, err, "server.handleDataFrame() should not return error")
 
 		assert.Equal(t, server.StatsCounter(), int64(1))
+		assert.Equal(t, int64(1), metrics.ServerFramesReceived.Get(sourceConnID, "source-1", "1"))
+		assert.Equal(t, int64(1), metrics.ServerFramesSent.Get("sfn-conn-id-1", "sfn-1", "1"))
 
 		// sfn-1 obverse tag 1
 		sfnStream1.writeEqual(t, dataFrame.Encode())
@@ -154,7 +160,7 @@ func TestHandleDataFrame(t *testing.T) {
 			err = server.handleBackflowFrame(c)
 			assert.NoError(t, err)
 
-			sourceStream.writeEqual(t, frame.NewBackflowFrame(tag, payload).Encode())
+			sourceStream.writeEqual(t, frame.NewBackflowFrame(tag, payload).SetTransactionID(dataFrame.TransactionID()).Encode())
 		})
 	})
 
@@ -328,6 +334,476 @@ func TestHandShake(t *testing.T) {
 
 }
 
+func TestHandleHandshakeFrameAuthFailure(t *testing.T) {
+	server := &Server{connector: newConnector()}
+	server.ConfigRouter(router.Default([]config.App{{Name: "sfn-1"}}))
+	server.ConfigMetadataBuilder(metadata.DefaultBuilder())
+	server.opts.Auths = map[string]auth.Authentication{
+		tokenAuth.Name(): tokenAuth,
+	}
+
+	for _, stream := range []io.ReadWriteCloser{newStreamAssert([]byte{}), failingStream{}} {
+		failures := metrics.ServerHandshakes.Get("token", metrics.ResultFailure)
+		c := &Context{
+			connID: "conn-1",
+			Stream: stream,
+			Frame:  frame.NewHandshakeFrame("sfn-1", "conn-1", byte(ClientTypeStreamFunction), []frame.Tag{1}, "token", "wrong-token"),
+		}
+		server.handleHandshakeFrame(c)
+
+		// the failure is counted once, whether the RejectedFrame is written or not.
+		assert.Equal(t, failures+1, metrics.ServerHandshakes.Get("token", metrics.ResultFailure))
+		assert.Nil(t, server.Connector().Get("conn-1"))
+	}
+}
+
+func TestACL(t *testing.T) {
+	server := &Server{connector: newConnector()}
+	server.ConfigRouter(router.Default([]config.App{{Name: "sfn-1"}, {Name: "sfn-2"}}))
+	server.ConfigMetadataBuilder(metadata.DefaultBuilder())
+	server.ConfigACL(auth.NewACL(map[string]auth.Permission{
+		"team-a": {Publish: []frame.Tag{1}, Observe: []frame.Tag{1}},
+	}))
+
+	t.Run("observe allowed", func(t *testing.T) {
+		stream := newStreamAssert([]byte{})
+		c := &Context{
+			connID: "conn-1",
+			Stream: stream,
+			Frame:  frame.NewHandshakeFrame("sfn-1", "conn-1", byte(ClientTypeStreamFunction), []frame.Tag{1}, "token", "team-a"),
+		}
+		assert.NoError(t, server.handleHandshakeFrame(c))
+		stream.writeEqual(t, frame.NewHandshakeAckFrame().Encode())
+	})
+
+	t.Run("observe forbidden", func(t *testing.T) {
+		c := &Context{
+			connID: "conn-2",
+			Stream: newStreamAssert([]byte{}),
+			Frame:  frame.NewHandshakeFrame("sfn-2", "conn-2", byte(ClientTypeStreamFunction), []frame.Tag{2}, "token", "team-a"),
+		}
+		err := server.handleHandshakeFrame(c)
+		assert.Error(t, err)
+		assert.Equal(t, yerr.ErrorCodeForbidden, err.(yerr.YomoError).ErrorCode())
+		assert.Nil(t, server.Connector().Get("conn-2"))
+
+		// the client is rejected with the error code.
+		rejected, ok := handshakeErrorFrame(err).(*frame.RejectedFrame)
+		assert.True(t, ok)
+		assert.Equal(t, uint64(yerr.ErrorCodeForbidden), rejected.Code())
+	})
+
+	t.Run("publish", func(t *testing.T) {
+		dataFrame := frame.NewDataFrame()
+		dataFrame.SetCarriage(1, []byte("hello yomo"))
+		c := &Context{connID: "conn-1", Frame: dataFrame}
+		assert.NoError(t, server.handleDataFrame(c))
+
+		dataFrame = frame.NewDataFrame()
+		dataFrame.SetCarriage(2, []byte("hello yomo"))
+		c = &Context{connID: "conn-1", Frame: dataFrame}
+		err := server.handleDataFrame(c)
+		assert.Error(t, err)
+		assert.Equal(t, yerr.ErrorCodeForbidden, err.(yerr.YomoError).ErrorCode())
+	})
+}
+
+func TestWriteDataFrameCompression(t *testing.T) {
+	server := &Server{connector: newConnector()}
+
+	f := frame.NewDataFrame()
+	f.SetCarriage(1, bytes.Repeat([]byte("yomo"), 100))
+	assert.NoError(t, compress.CompressFrame(f, compress.Gzip, 0))
+
+	t.Run("accepted", func(t *testing.T) {
+		stream := newStreamAssert([]byte{})
+		conn := newConnection("sfn-1", "sfn-1-id", ClientTypeStreamFunction, nil, stream, []frame.Tag{1})
+		server.compressions.Store("conn-1", []string{compress.Gzip})
+
+		n, err := server.writeDataFrame("conn-1", conn, f)
+		assert.NoError(t, err)
+		assert.Equal(t, len(f.GetCarriage()), n)
+		stream.writeEqual(t, f.Encode())
+	})
+
+	t.Run("not accepted", func(t *testing.T) {
+		stream := newStreamAssert([]byte{})
+		conn := newConnection("sfn-2", "sfn-2-id", ClientTypeStreamFunction, nil, stream, []frame.Tag{1})
+
+		n, err := server.writeDataFrame("conn-2", conn, f)
+		assert.NoError(t, err)
+		// the size of the decompressed carriage is counted.
+		assert.Equal(t, 400, n)
+		decompressed := frame.NewDataFrame()
+		decompressed.SetCarriage(1, bytes.Repeat([]byte("yomo"), 100))
+		decompressed.SetTransactionID(f.TransactionID())
+		stream.writeEqual(t, decompressed.Encode())
+		// the original DataFrame is not changed.
+		assert.Equal(t, compress.Gzip, f.Compression())
+	})
+}
+
+func TestWriteDataFrameSealed(t *testing.T) {
+	server := &Server{connector: newConnector()}
+
+	f := frame.NewDataFrame()
+	f.SetCarriage(1, bytes.Repeat([]byte("yomo"), 100))
+	assert.NoError(t, compress.CompressFrame(f, compress.Gzip, 0))
+	f.GetMetaFrame().SetKeyID("key-1")
+
+	// the sealed DataFrame is forwarded as is, even if the compression is not accepted.
+	stream := newStreamAssert([]byte{})
+	conn := newConnection("sfn-1", "sfn-1-id", ClientTypeStreamFunction, nil, stream, []frame.Tag{1})
+	_, err := server.writeDataFrame("conn-1", conn, f)
+	assert.NoError(t, err)
+	stream.writeEqual(t, f.Encode())
+}
+
+func TestDispatchToDownstreamsHops(t *testing.T) {
+	metadataBuilder := metadata.DefaultBuilder()
+	routers := router.Default([]config.App{})
+	connector := buildMockConnector(routers, metadataBuilder, []mockConnectorArgs{
+		{name: "source-1", clientID: "source-id-1", clientType: byte(ClientTypeSource), connID: "source-conn-1", stream: newStreamAssert([]byte{})},
+		{name: "zipper-b", clientID: "zipper-id-b", clientType: byte(ClientTypeUpstreamZipper), connID: "zipper-conn-b", stream: newStreamAssert([]byte{})},
+	})
+	defer connector.Clean()
+
+	server := NewServer("zipper-a", WithMaxHops(2))
+	server.connector = connector
+	server.ConfigRouter(routers)
+	server.ConfigMetadataBuilder(metadataBuilder)
+
+	downstream := &frameRecorder{}
+	server.AddDownstreamServer("zipper-c:9000", downstream)
+
+	broadcast := func(hops ...string) *frame.DataFrame {
+		f := frame.NewDataFrame()
+		f.SetCarriage(1, []byte("hello yomo"))
+		f.SetBroadcast(true)
+		for _, name := range hops {
+			f.GetMetaFrame().Visit(name)
+		}
+		return f
+	}
+
+	t.Run("forward from source", func(t *testing.T) {
+		f := broadcast()
+		server.dispatchToDownstreams(&Context{connID: "source-conn-1", Frame: f})
+
+		fwd := downstream.last(t)
+		assert.Equal(t, uint32(1), fwd.GetMetaFrame().Hops())
+		assert.Equal(t, []string{"zipper-a"}, fwd.GetMetaFrame().Visited())
+		// the original DataFrame is not changed.
+		assert.Equal(t, uint32(0), f.GetMetaFrame().Hops())
+	})
+
+	t.Run("relay from upstream zipper", func(t *testing.T) {
+		server.dispatchToDownstreams(&Context{connID: "zipper-conn-b", Frame: broadcast("zipper-b")})
+
+		fwd := downstream.last(t)
+		assert.Equal(t, []string{"zipper-b", "zipper-a"}, fwd.GetMetaFrame().Visited())
+	})
+
+	t.Run("max hops", func(t *testing.T) {
+		dropped := server.StatsMeshDropped()
+		server.dispatchToDownstreams(&Context{connID: "zipper-conn-b", Frame: broadcast("zipper-d", "zipper-b")})
+
+		assert.Len(t, downstream.frames, 2)
+		assert.Equal(t, dropped+1, server.StatsMeshDropped())
+	})
+
+	t.Run("visited", func(t *testing.T) {
+		dropped := server.StatsMeshDropped()
+		c := &Context{connID: "zipper-conn-b", Stream: newStreamAssert([]byte{}), Frame: broadcast("zipper-a", "zipper-b")}
+		assert.NoError(t, server.mainFrameHandler(c))
+
+		assert.Len(t, downstream.frames, 2)
+		assert.Equal(t, dropped+1, server.StatsMeshDropped())
+		assert.Equal(t, int64(0), server.StatsCounter())
+	})
+}
+
+func TestDispatchToDownstreamsNoRelay(t *testing.T) {
+	metadataBuilder := metadata.DefaultBuilder()
+	routers := router.Default([]config.App{})
+	connector := buildMockConnector(routers, metadataBuilder, []mockConnectorArgs{
+		{name: "zipper-b", clientID: "zipper-id-b", clientType: byte(ClientTypeUpstreamZipper), connID: "zipper-conn-b", stream: newStreamAssert([]byte{})},
+	})
+	defer connector.Clean()
+
+	server := NewServer("zipper-a")
+	server.connector = connector
+	server.ConfigRouter(routers)
+	server.ConfigMetadataBuilder(metadataBuilder)
+
+	downstream := &frameRecorder{}
+	server.AddDownstreamServer("zipper-c:9000", downstream)
+	server.ConfigDownstreamFilter("zipper-c:9000", &TagFilter{Allow: []frame.Tag{1}})
+
+	relayed := func(tag frame.Tag) *frame.DataFrame {
+		f := frame.NewDataFrame()
+		f.SetCarriage(tag, []byte("hello yomo"))
+		f.SetBroadcast(true)
+		f.GetMetaFrame().Visit("zipper-b")
+		return f
+	}
+
+	// the DataFrame is not relayed by default, it is not counted as max hops.
+	noRelay := metrics.ServerMeshDropped.Get(meshDropNoRelay)
+	maxHops := metrics.ServerMeshDropped.Get(meshDropMaxHops)
+	server.dispatchToDownstreams(&Context{connID: "zipper-conn-b", Frame: relayed(1)})
+	assert.Empty(t, downstream.frames)
+	assert.Equal(t, noRelay+1, metrics.ServerMeshDropped.Get(meshDropNoRelay))
+	assert.Equal(t, maxHops, metrics.ServerMeshDropped.Get(meshDropMaxHops))
+
+	// the DataFrame no downstream zipper takes is not counted.
+	dropped := server.StatsMeshDropped()
+	server.dispatchToDownstreams(&Context{connID: "zipper-conn-b", Frame: relayed(2)})
+	assert.Equal(t, dropped, server.StatsMeshDropped())
+}
+
+func TestDispatchToDownstreamsTagFilter(t *testing.T) {
+	metadataBuilder := metadata.DefaultBuilder()
+	routers := router.Default([]config.App{})
+	connector := buildMockConnector(routers, metadataBuilder, []mockConnectorArgs{
+		{name: "source-1", clientID: "source-id-1", clientType: byte(ClientTypeSource), connID: "source-conn-1", stream: newStreamAssert([]byte{})},
+	})
+	defer connector.Clean()
+
+	server := NewServer("zipper-a")
+	server.connector = connector
+	server.ConfigRouter(routers)
+	server.ConfigMetadataBuilder(metadataBuilder)
+
+	var (
+		all        = &frameRecorder{}
+		allowed    = &frameRecorder{}
+		denied     = &frameRecorder{}
+		advertiser = &advertisingRecorder{tags: []frame.Tag{2}}
+	)
+	server.AddDownstreamServer("all:9000", all)
+	server.AddDownstreamServer("allowed:9000", allowed)
+	server.ConfigDownstreamFilter("allowed:9000", &TagFilter{Allow: []frame.Tag{1}})
+	server.AddDownstreamServer("denied:9000", denied)
+	server.ConfigDownstreamFilter("denied:9000", &TagFilter{Deny: []frame.Tag{1}})
+	server.AddDownstreamServer("advertiser:9000", advertiser)
+
+	for _, tag := range []frame.Tag{1, 2} {
+		f := frame.NewDataFrame()
+		f.SetCarriage(tag, []byte("hello yomo"))
+		f.SetBroadcast(true)
+		server.dispatchToDownstreams(&Context{connID: "source-conn-1", Frame: f})
+	}
+
+	assert.Len(t, all.frames, 2)
+	assert.Len(t, allowed.frames, 1)
+	assert.Equal(t, frame.Tag(1), allowed.last(t).GetDataTag())
+	assert.Len(t, denied.frames, 1)
+	assert.Equal(t, frame.Tag(2), denied.last(t).GetDataTag())
+	assert.Len(t, advertiser.frames, 1)
+	assert.Equal(t, frame.Tag(2), advertiser.last(t).GetDataTag())
+}
+
+func TestAdvertiseTags(t *testing.T) {
+	server := &Server{connector: newConnector()}
+	server.ConfigRouter(router.Default([]config.App{{Name: "sfn-1"}, {Name: "sfn-2"}}))
+	server.ConfigMetadataBuilder(metadata.DefaultBuilder())
+
+	handshake := func(connID string, f *frame.HandshakeFrame) *streamAssert {
+		stream := newStreamAssert([]byte{})
+		assert.NoError(t, server.handleHandshakeFrame(&Context{connID: connID, Stream: stream, Frame: f}))
+		return stream
+	}
+
+	handshake("sfn-conn-1", frame.NewHandshakeFrame("sfn-1", "sfn-id-1", byte(ClientTypeStreamFunction), []frame.Tag{1}, "", ""))
+
+	upstream := frame.NewHandshakeFrame("zipper-b", "zipper-id-b", byte(ClientTypeUpstreamZipper), nil, "", "")
+	upstream.AdvertiseTags = true
+	stream := handshake("zipper-conn-b", upstream)
+
+	// the tags are advertised again when a stream function connects or disconnects.
+	handshake("sfn-conn-2", frame.NewHandshakeFrame("sfn-2", "sfn-id-2", byte(ClientTypeStreamFunction), []frame.Tag{3, 2}, "", ""))
+	server.removeConnection("sfn-conn-1")
+
+	ack := frame.NewHandshakeAckFrame()
+	ack.AdvertiseTags = true
+	stream.writeEqual(t, composeFrametoBytes(
+		ack,
+		frame.NewObservedTagsFrame([]frame.Tag{1}),
+		frame.NewObservedTagsFrame([]frame.Tag{1, 2, 3}),
+		frame.NewObservedTagsFrame([]frame.Tag{2, 3}),
+	))
+}
+
+func TestBackflowAcrossZippers(t *testing.T) {
+	metadataBuilder := metadata.DefaultBuilder()
+
+	// zipper-a dispatches the DataFrame of source-1 to zipper-b, where sfn-1 responds.
+	var (
+		sourceStream   = newStreamAssert([]byte{})
+		upstreamStream = newStreamAssert([]byte{})
+	)
+	routersA := router.Default([]config.App{})
+	connectorA := buildMockConnector(routersA, metadataBuilder, []mockConnectorArgs{
+		{name: "source-1", clientID: "source-id-1", clientType: byte(ClientTypeSource), obversedTag: 2, connID: "source-conn-1", stream: sourceStream},
+	})
+	defer connectorA.Clean()
+	serverA := NewServer("zipper-a")
+	serverA.connector = connectorA
+	serverA.ConfigRouter(routersA)
+	serverA.ConfigMetadataBuilder(metadataBuilder)
+
+	routersB := router.Default([]config.App{{Name: "sfn-1"}})
+	connectorB := buildMockConnector(routersB, metadataBuilder, []mockConnectorArgs{
+		{name: "zipper-a", clientID: "zipper-id-a", clientType: byte(ClientTypeUpstreamZipper), connID: "zipper-conn-a", stream: upstreamStream},
+		{name: "sfn-1", clientID: "sfn-id-1", clientType: byte(ClientTypeStreamFunction), obversedTag: 1, connID: "sfn-conn-1", stream: newStreamAssert([]byte{})},
+	})
+	defer connectorB.Clean()
+	serverB := NewServer("zipper-b")
+	serverB.connector = connectorB
+	serverB.ConfigRouter(routersB)
+	serverB.ConfigMetadataBuilder(metadataBuilder)
+
+	downstream := &frameRecorder{}
+	serverA.AddDownstreamServer("zipper-b:9000", downstream)
+
+	f := frame.NewDataFrame()
+	f.SetCarriage(1, []byte("hello yomo"))
+	f.SetSourceID("source-id-1")
+	f.SetBroadcast(true)
+	serverA.dispatchToDownstreams(&Context{connID: "source-conn-1", Frame: f})
+
+	fwd := downstream.last(t)
+	assert.Equal(t, "zipper-a", fwd.GetMetaFrame().Origin())
+
+	// zipper-b learns the route back to zipper-a.
+	assert.NoError(t, serverB.handleDataFrame(&Context{connID: "zipper-conn-a", Frame: fwd}))
+
+	result := frame.NewDataFrame()
+	result.SetCarriage(2, []byte("HELLO YOMO"))
+	result.SetSourceID("source-id-1")
+	result.GetMetaFrame().SetOrigin(fwd.GetMetaFrame().Origin())
+	assert.NoError(t, serverB.handleBackflowFrame(&Context{connID: "sfn-conn-1", Frame: result}))
+	upstreamStream.writeEqual(t, result.Encode())
+
+	// zipper-a writes the result routed back to the source.
+	serverA.HandleBackflow(result)
+	bf := frame.NewBackflowFrame(2, []byte("HELLO YOMO")).SetTransactionID(result.TransactionID())
+	sourceStream.writeEqual(t, bf.Encode())
+
+	// the route is removed with the upstream zipper.
+	serverB.removeConnection("zipper-conn-a")
+	_, ok := serverB.meshRoutes.Load("zipper-a")
+	assert.False(t, ok)
+}
+
+func TestBackflowTenant(t *testing.T) {
+	var (
+		streamA = newStreamAssert([]byte{})
+		streamB = newStreamAssert([]byte{})
+	)
+	// the sources of different tenants have the same id.
+	connector := newConnector()
+	connector.Add("source-conn-a", newConnection("source-1", "source-id-1", ClientTypeSource, metadata.NewTenant("team-a"), streamA, []frame.Tag{2}))
+	connector.Add("source-conn-b", newConnection("source-1", "source-id-1", ClientTypeSource, metadata.NewTenant("team-b"), streamB, []frame.Tag{2}))
+	defer connector.Clean()
+
+	server := &Server{connector: connector}
+	server.ConfigRouter(router.Tenant([]config.App{}))
+	server.ConfigMetadataBuilder(metadata.TenantBuilder(func(f *frame.HandshakeFrame) (string, error) { return f.AuthPayload(), nil }))
+
+	result := frame.NewDataFrame()
+	result.SetCarriage(2, []byte("hello yomo"))
+	result.SetSourceID("source-id-1")
+	result.GetMetaFrame().SetMetadata(metadata.NewTenant("team-a").Encode())
+	assert.NoError(t, server.backflow(result))
+
+	streamA.writeEqual(t, frame.NewBackflowFrame(2, []byte("hello yomo")).SetTransactionID(result.TransactionID()).Encode())
+	streamB.writeEqual(t, []byte{})
+}
+
+func TestBackflowSealed(t *testing.T) {
+	stream := newStreamAssert([]byte{})
+	connector := newConnector()
+	connector.Add("source-conn", newConnection("source-1", "source-id-1", ClientTypeSource, &metadata.Default{}, stream, []frame.Tag{2}))
+	connector.Add("sfn-conn", newConnection("sfn-1", "sfn-id-1", ClientTypeStreamFunction, &metadata.Default{}, newStreamAssert([]byte{}), []frame.Tag{1}))
+	defer connector.Clean()
+
+	server := &Server{connector: connector}
+	server.ConfigMetadataBuilder(metadata.DefaultBuilder())
+
+	result := frame.NewDataFrame()
+	result.SetCarriage(2, []byte("sealed"))
+	result.SetCompression(compress.Gzip)
+	result.SetSourceID("source-id-1")
+	result.GetMetaFrame().SetKeyID("key-1")
+
+	c := &Context{connID: "sfn-conn", Frame: result}
+	assert.NoError(t, server.handleBackflowFrame(c))
+
+	// the sealed result is backflowed as is, the source opens it by the key id.
+	stream.writeEqual(t, frame.NewBackflowFrame(2, []byte("sealed")).SetTransactionID(result.TransactionID()).SetKeyID("key-1", compress.Gzip).Encode())
+}
+
+func TestLeastInflight(t *testing.T) {
+	connector := newConnector()
+	defer connector.Clean()
+	connector.Add("source-conn", newConnection("source-1", "source-id-1", ClientTypeSource, &metadata.Default{}, newStreamAssert([]byte{}), nil))
+	connector.Add("sfn-conn-1", newConnection("sfn-1", "sfn-id-1", ClientTypeStreamFunction, &metadata.Default{}, newStreamAssert([]byte{}), []frame.Tag{1}))
+	connector.Add("sfn-conn-2", newConnection("sfn-1", "sfn-id-2", ClientTypeStreamFunction, &metadata.Default{}, newStreamAssert([]byte{}), []frame.Tag{1}))
+
+	server := &Server{connector: connector}
+	server.ConfigRouter(router.Default([]config.App{{Name: "sfn-1", LoadBalance: config.LoadBalanceLeastInflight}}))
+	server.ConfigMetadataBuilder(metadata.DefaultBuilder())
+	route := server.router.Route(&metadata.Default{})
+	assert.NoError(t, route.Add("sfn-conn-1", "sfn-1", []frame.Tag{1}))
+	assert.NoError(t, route.Add("sfn-conn-2", "sfn-1", []frame.Tag{1}))
+
+	send := func(tid string) {
+		f := frame.NewDataFrame()
+		f.SetCarriage(1, []byte("hello yomo"))
+		f.SetSourceID("source-id-1")
+		f.SetTransactionID(tid)
+		assert.NoError(t, server.handleDataFrame(&Context{connID: "source-conn", Frame: f}))
+	}
+
+	// each instance has a DataFrame in flight.
+	send("tid-1")
+	send("tid-2")
+	assert.Equal(t, 1, server.connLoad("sfn-conn-1"))
+	assert.Equal(t, 1, server.connLoad("sfn-conn-2"))
+
+	// the result completes the DataFrame in flight.
+	result := frame.NewDataFrame()
+	result.SetCarriage(2, []byte("result"))
+	result.SetSourceID("source-id-1")
+	result.SetTransactionID("tid-1")
+	assert.NoError(t, server.handleDataFrame(&Context{connID: "sfn-conn-1", Frame: result}))
+	assert.Equal(t, 0, server.connLoad("sfn-conn-1"))
+
+	// the next DataFrame goes to the instance without DataFrame in flight.
+	send("tid-3")
+	assert.Equal(t, 1, server.connLoad("sfn-conn-1"))
+	assert.Equal(t, 1, server.connLoad("sfn-conn-2"))
+}
+
+// frameRecorder records the DataFrames written to a downstream zipper.
+type frameRecorder struct {
+	frames []*frame.DataFrame
+}
+
+func (r *frameRecorder) WriteFrame(f frame.Frame) error {
+	r.frames = append(r.frames, f.(*frame.DataFrame))
+	return nil
+}
+
+func (r *frameRecorder) last(t *testing.T) *frame.DataFrame {
+	if len(r.frames) == 0 {
+		t.Fatal("no DataFrame is written to downstream")
+	}
+	return r.frames[len(r.frames)-1]
+}
+
 // streamAssert implements `io.ReadWriteCloser`,
 // It init from a byte array from test Read, `writeEqual` assert Write result.
 type streamAssert struct {
@@ -377,3 +853,13 @@ func composeFrametoBytes(frms ...frame.Frame) []byte {
 	}
 	return result
 }
+
+// advertisingRecorder records the DataFrames written to a downstream zipper advertising the tags.
+type advertisingRecorder struct {
+	frameRecorder
+	tags []frame.Tag
+}
+
+func (r *advertisingRecorder) AdvertisedTags() ([]frame.Tag, bool) {
+	return r.tags, true
+}
@@ -0,0 +1,290 @@
+// Package store provides an append-only log on local disk,
+// zipper buffers the DataFrames of offline stream functions in it.
+//
+// The log is split into segment files, records are read in the order they are appended,
+// a segment file is removed after all its records are read. The oldest segments are
+// removed when the log exceeds the size limit, records older than the age limit are skipped.
+package store
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	segmentExt = ".log"
+	// headerSize is the size of record header: 8 bytes timestamp and 4 bytes length.
+	headerSize = 12
+	// defaultSegmentSize is the segment size when the log has no size limit.
+	defaultSegmentSize = 64 << 20
+)
+
+// ErrRecordTooLarge is returned when appending a record larger than the size limit.
+var ErrRecordTooLarge = errors.New("store: record is larger than the size limit")
+
+// Log is an append-only log on local disk, It is safe for concurrent use.
+type Log struct {
+	dir         string
+	maxSize     int64
+	maxAge      time.Duration
+	segmentSize int64
+	mu          sync.Mutex
+	segments    []*segment
+	size        int64
+	w           *os.File      // the writer of the last segment
+	r           *bufio.Reader // the reader of the first segment
+	rf          *os.File
+	readOffset  int64
+}
+
+type segment struct {
+	id   uint64
+	path string
+	size int64
+}
+
+// Open opens the log in dir, the records remained in dir are kept.
+// maxSize is the size limit in bytes and maxAge is the age limit of records, no limit if they are zero.
+func Open(dir string, maxSize int64, maxAge time.Duration) (*Log, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	l := &Log{
+		dir:         dir,
+		maxSize:     maxSize,
+		maxAge:      maxAge,
+		segmentSize: defaultSegmentSize,
+	}
+	if maxSize > 0 && maxSize/4 < l.segmentSize {
+		l.segmentSize = maxSize / 4
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentExt) {
+			continue
+		}
+		id, err := strconv.ParseUint(strings.TrimSuffix(name, segmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		l.segments = append(l.segments, &segment{id: id, path: filepath.Join(dir, name), size: info.Size()})
+		l.size += info.Size()
+	}
+	sort.Slice(l.segments, func(i, j int) bool { return l.segments[i].id < l.segments[j].id })
+
+	return l, nil
+}
+
+// Append appends a record to the log.
+func (l *Log) Append(record []byte) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	n := int64(headerSize + len(record))
+	if l.maxSize > 0 && n > l.maxSize {
+		return ErrRecordTooLarge
+	}
+
+	last := l.last()
+	if last == nil || l.w == nil || last.size+n > l.segmentSize && last.size > 0 {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+		last = l.last()
+	}
+
+	buf := make([]byte, n)
+	binary.BigEndian.PutUint64(buf, uint64(time.Now().UnixNano()))
+	binary.BigEndian.PutUint32(buf[8:], uint32(len(record)))
+	copy(buf[headerSize:], record)
+	if _, err := l.w.Write(buf); err != nil {
+		return err
+	}
+	last.size += n
+	l.size += n
+
+	l.truncate()
+	return nil
+}
+
+// Next returns the next record of the log, It returns io.EOF if there are no more records.
+// The read position is not persisted, the records of the segment being read are read again after reopening.
+func (l *Log) Next() ([]byte, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for len(l.segments) > 0 {
+		first := l.segments[0]
+		if l.r == nil {
+			f, err := os.Open(first.path)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := f.Seek(l.readOffset, io.SeekStart); err != nil {
+				f.Close()
+				return nil, err
+			}
+			l.rf, l.r = f, bufio.NewReader(f)
+		}
+
+		if l.readOffset+headerSize <= first.size {
+			header := make([]byte, headerSize)
+			if _, err := io.ReadFull(l.r, header); err != nil {
+				l.resetReader()
+				return nil, err
+			}
+			ts := time.Unix(0, int64(binary.BigEndian.Uint64(header)))
+			record := make([]byte, binary.BigEndian.Uint32(header[8:]))
+			if _, err := io.ReadFull(l.r, record); err != nil {
+				l.resetReader()
+				return nil, err
+			}
+			l.readOffset += int64(headerSize + len(record))
+
+			if l.maxAge > 0 && time.Since(ts) > l.maxAge {
+				continue
+			}
+			return record, nil
+		}
+
+		// the first segment is read to the end.
+		if err := l.removeFirst(); err != nil {
+			return nil, err
+		}
+	}
+	return nil, io.EOF
+}
+
+// Empty returns true if all the records are read.
+func (l *Log) Empty() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, s := range l.segments {
+		if s.size > 0 && !(s == l.segments[0] && l.readOffset >= s.size) {
+			return false
+		}
+	}
+	return true
+}
+
+// Size returns the size in bytes of the segment files.
+func (l *Log) Size() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.size
+}
+
+// Close closes the log, the records remained are kept on disk.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.closeReader()
+	if l.w != nil {
+		err := l.w.Close()
+		l.w = nil
+		return err
+	}
+	return nil
+}
+
+func (l *Log) last() *segment {
+	if len(l.segments) == 0 {
+		return nil
+	}
+	return l.segments[len(l.segments)-1]
+}
+
+// rotate creates a new segment for writing.
+func (l *Log) rotate() error {
+	if l.w != nil {
+		if err := l.w.Close(); err != nil {
+			return err
+		}
+		l.w = nil
+	}
+	var id uint64
+	if last := l.last(); last != nil {
+		id = last.id + 1
+	}
+	path := filepath.Join(l.dir, fmt.Sprintf("%020d%s", id, segmentExt))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	l.w = f
+	l.segments = append(l.segments, &segment{id: id, path: path})
+	return nil
+}
+
+// truncate removes the oldest segments exceeding the size limit or the age limit,
+// the segment being written is kept.
+func (l *Log) truncate() {
+	for len(l.segments) > 1 {
+		first := l.segments[0]
+		expired := false
+		if l.maxAge > 0 {
+			if info, err := os.Stat(first.path); err == nil && time.Since(info.ModTime()) > l.maxAge {
+				expired = true
+			}
+		}
+		if !expired && (l.maxSize <= 0 || l.size <= l.maxSize) {
+			return
+		}
+		if err := l.removeFirst(); err != nil {
+			return
+		}
+	}
+}
+
+func (l *Log) removeFirst() error {
+	first := l.segments[0]
+	l.closeReader()
+	if len(l.segments) == 1 && l.w != nil {
+		if err := l.w.Close(); err != nil {
+			return err
+		}
+		l.w = nil
+	}
+	if err := os.Remove(first.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	l.segments = l.segments[1:]
+	l.size -= first.size
+	return nil
+}
+
+// resetReader closes the reader after a failed read, the record is read again from the read offset.
+func (l *Log) resetReader() {
+	l.rf.Close()
+	l.rf, l.r = nil, nil
+}
+
+func (l *Log) closeReader() {
+	if l.rf != nil {
+		l.rf.Close()
+	}
+	l.rf, l.r = nil, nil
+	l.readOffset = 0
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLog(t *testing.T) {
+	dir := t.TempDir()
+
+	l, err := Open(dir, 0, 0)
+	assert.NoError(t, err)
+	assert.True(t, l.Empty())
+
+	for _, record := range []string{"a", "b", "c"} {
+		assert.NoError(t, l.Append([]byte(record)))
+	}
+	assert.False(t, l.Empty())
+
+	record, err := l.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("a"), record)
+	assert.NoError(t, l.Close())
+
+	// the segment being read is read again after reopening.
+	l, err = Open(dir, 0, 0)
+	assert.NoError(t, err)
+	defer l.Close()
+
+	assert.NoError(t, l.Append([]byte("d")))
+	for _, expected := range []string{"a", "b", "c", "d"} {
+		record, err := l.Next()
+		assert.NoError(t, err)
+		assert.Equal(t, []byte(expected), record)
+	}
+	_, err = l.Next()
+	assert.ErrorIs(t, err, io.EOF)
+	assert.True(t, l.Empty())
+	assert.Equal(t, int64(0), l.Size())
+}
+
+func TestLogMaxSize(t *testing.T) {
+	// the segment size is 26 bytes, 2 records of 1 byte in each segment.
+	l, err := Open(t.TempDir(), 104, 0)
+	assert.NoError(t, err)
+	defer l.Close()
+
+	assert.ErrorIs(t, l.Append(make([]byte, 100)), ErrRecordTooLarge)
+
+	for _, record := range []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"} {
+		assert.NoError(t, l.Append([]byte(record)))
+	}
+	assert.LessOrEqual(t, l.Size(), int64(104))
+
+	// the oldest records are removed.
+	record, err := l.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("2"), record)
+}
+
+func TestLogMaxAge(t *testing.T) {
+	l, err := Open(t.TempDir(), 0, 50*time.Millisecond)
+	assert.NoError(t, err)
+	defer l.Close()
+
+	assert.NoError(t, l.Append([]byte("expired")))
+	time.Sleep(100 * time.Millisecond)
+	assert.NoError(t, l.Append([]byte("fresh")))
+
+	record, err := l.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("fresh"), record)
+}
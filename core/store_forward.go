@@ -0,0 +1,312 @@
+package core
+
+import (
+	"errors"
+	"io"
+	"net/url"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/core/store"
+	"github.com/yomorun/yomo/pkg/logger"
+)
+
+// storeForward buffers the DataFrames of offline stream functions in the logs on local disk,
+// and replays them in order after the stream function reconnects and completes the handshake.
+type storeForward struct {
+	dir       string
+	maxSize   int64
+	maxAge    time.Duration
+	mu        sync.Mutex
+	functions map[string][]frame.Tag // name -> observed data tags
+	queues    map[string]*storeQueue // tenant/name -> queue
+}
+
+// replayRetryInterval is the interval to retry the replay after failing to read the buffer.
+var replayRetryInterval = time.Second
+
+// recordLog is the log the DataFrames are buffered in, It is implemented by store.Log.
+type recordLog interface {
+	Append(record []byte) error
+	Next() ([]byte, error)
+	Close() error
+}
+
+// storeQueue is the buffer of a stream function, It is online when the stream function
+// is connected and all the buffered DataFrames are replayed.
+type storeQueue struct {
+	mu     sync.Mutex
+	log    recordLog
+	online bool
+	// pending is the DataFrame read from the log but not written to the stream function yet.
+	pending *frame.DataFrame
+}
+
+func newStoreForward(dir string, maxSize int64, maxAge time.Duration) *storeForward {
+	return &storeForward{
+		dir:       dir,
+		maxSize:   maxSize,
+		maxAge:    maxAge,
+		functions: make(map[string][]frame.Tag),
+		queues:    make(map[string]*storeQueue),
+	}
+}
+
+// setFunctions sets the stream functions to be buffered and their observed data tags.
+func (sf *storeForward) setFunctions(functions map[string][]frame.Tag) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+
+	sf.functions = functions
+}
+
+// queue returns the queue of the stream function, It returns nil if the stream function is not buffered.
+func (sf *storeForward) queue(m metadata.Metadata, name string) (*storeQueue, error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+
+	if _, ok := sf.functions[name]; !ok {
+		return nil, nil
+	}
+	tenant := metadata.TenantOf(m)
+	key := tenant + "/" + name
+	if q, ok := sf.queues[key]; ok {
+		return q, nil
+	}
+	log, err := store.Open(filepath.Join(sf.dir, url.PathEscape(tenant), url.PathEscape(name)), sf.maxSize, sf.maxAge)
+	if err != nil {
+		return nil, err
+	}
+	q := &storeQueue{log: log}
+	sf.queues[key] = q
+	return q, nil
+}
+
+// observers returns the buffered stream functions observing the tag.
+func (sf *storeForward) observers(tag frame.Tag) []string {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+
+	names := []string{}
+	for name, tags := range sf.functions {
+		for _, t := range tags {
+			if t == tag {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	return names
+}
+
+// tags returns the data tags observed by the buffered stream functions.
+func (sf *storeForward) tags() []frame.Tag {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+
+	var tags []frame.Tag
+	for _, t := range sf.functions {
+		tags = append(tags, t...)
+	}
+	return tags
+}
+
+// append buffers the DataFrame if the queue is not online, It returns false if the queue is online,
+// then the DataFrame should be written to the stream function directly.
+func (q *storeQueue) append(f *frame.DataFrame) (bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.online {
+		return false, nil
+	}
+	return true, q.log.Append(f.Encode())
+}
+
+// pause stops the queue to be online, the DataFrames are buffered until the replay is completed.
+func (q *storeQueue) pause() {
+	q.mu.Lock()
+	q.online = false
+	q.mu.Unlock()
+}
+
+// next returns the next buffered DataFrame, It is returned again until done is called.
+// The queue becomes online if there are no more DataFrames, the records failed to be decoded
+// are passed to corrupted and skipped.
+func (q *storeQueue) next(corrupted func(record []byte, err error)) (*frame.DataFrame, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.pending != nil {
+		return q.pending, nil
+	}
+	for {
+		record, err := q.log.Next()
+		if errors.Is(err, io.EOF) {
+			q.online = true
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, err
+		}
+		f, err := frame.DecodeToDataFrame(record)
+		if err != nil {
+			corrupted(record, err)
+			continue
+		}
+		q.pending = f
+		return f, nil
+	}
+}
+
+// done removes the DataFrame returned by next, after it is written to the stream function.
+func (q *storeQueue) done() {
+	q.mu.Lock()
+	q.pending = nil
+	q.mu.Unlock()
+}
+
+// close closes the logs of all the queues, the buffered DataFrames are kept on disk.
+func (sf *storeForward) close() {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+
+	for key, q := range sf.queues {
+		if err := q.log.Close(); err != nil {
+			logger.Errorf("%sclose buffer of [%s] error: %v", ServerLogPrefix, key, err)
+		}
+		delete(sf.queues, key)
+	}
+}
+
+// storeAndForward buffers the DataFrame for the stream functions which are offline or replaying,
+// It returns the connection ids the DataFrame should be written to directly, and whether the
+// DataFrame is buffered for any stream function.
+func (s *Server) storeAndForward(m metadata.Metadata, f *frame.DataFrame, connIDs []string) ([]string, bool) {
+	if s.stores == nil {
+		return connIDs, false
+	}
+
+	stored := false
+	buffer := func(name string) bool {
+		q, err := s.stores.queue(m, name)
+		if err != nil {
+			logger.Errorf("%sopen buffer of [%s] error: %v", ServerLogPrefix, name, err)
+			return false
+		}
+		if q == nil {
+			return false
+		}
+		ok, err := q.append(f)
+		if err != nil {
+			logger.Errorf("%sbuffer DataFrame for [%s] error: %v", ServerLogPrefix, name, err)
+			return false
+		}
+		stored = stored || ok
+		return ok
+	}
+
+	direct := make([]string, 0, len(connIDs))
+	connected := make(map[string]bool)
+	for _, connID := range connIDs {
+		if conn := s.connector.Get(connID); conn != nil {
+			connected[conn.Name()] = true
+			if buffer(conn.Name()) {
+				continue
+			}
+		}
+		direct = append(direct, connID)
+	}
+	for _, name := range s.stores.observers(f.GetDataTag()) {
+		if !connected[name] {
+			buffer(name)
+		}
+	}
+	return direct, stored
+}
+
+// pauseStore makes the DataFrames of the stream function buffered, until the replay is completed.
+func (s *Server) pauseStore(m metadata.Metadata, name string) *storeQueue {
+	if s.stores == nil {
+		return nil
+	}
+	q, err := s.stores.queue(m, name)
+	if err != nil {
+		logger.Errorf("%sopen buffer of [%s] error: %v", ServerLogPrefix, name, err)
+		return nil
+	}
+	if q != nil {
+		q.pause()
+	}
+	return q
+}
+
+// offlineStore makes the DataFrames of the stream function buffered again when its last connection
+// of the tenant is removed, until it reconnects and the replay is completed.
+func (s *Server) offlineStore(conn Connection) {
+	if s.stores == nil || conn.ClientType() != ClientTypeStreamFunction {
+		return
+	}
+	tenant := metadata.TenantOf(conn.Metadata())
+	for _, c := range s.connector.GetConns() {
+		if c.ClientType() == ClientTypeStreamFunction && c.Name() == conn.Name() && metadata.TenantOf(c.Metadata()) == tenant {
+			return
+		}
+	}
+	s.pauseStore(conn.Metadata(), conn.Name())
+}
+
+// replayStore writes the buffered DataFrames to the reconnected stream function in order.
+func (s *Server) replayStore(q *storeQueue, connID string, conn Connection) {
+	corrupted := func(record []byte, err error) {
+		s.deadLetter(s.router.Route(conn.Metadata()), &DeadLetter{
+			Time:     time.Now(),
+			Reason:   DeadLetterCorrupted,
+			Error:    err.Error(),
+			ConnID:   connID,
+			Name:     conn.Name(),
+			Carriage: record,
+		})
+	}
+	n := 0
+	for {
+		f, err := q.next(corrupted)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		// the queue stays offline, the replay is retried as long as the stream function is connected.
+		if err != nil {
+			logger.Errorf("%sread buffer of [%s](%s) error: %v, retry in %v", ServerLogPrefix, conn.Name(), connID, err, replayRetryInterval)
+			time.AfterFunc(replayRetryInterval, func() {
+				if s.connector.Get(connID) == conn {
+					s.replayStore(q, connID, conn)
+				}
+			})
+			return
+		}
+		// the connection is broken, the DataFrame is kept in the queue,
+		// It is replayed with the rest after the stream function reconnects.
+		if _, err := s.writeDataFrame(connID, conn, f); err != nil {
+			logger.Errorf("%sreplay buffer to [%s](%s) error: %v", ServerLogPrefix, conn.Name(), connID, err)
+			return
+		}
+		q.done()
+		n++
+	}
+	if n > 0 {
+		logger.Printf("%s[%s](%s) replayed %d buffered DataFrames", ServerLogPrefix, conn.Name(), connID, n)
+	}
+}
+
+// ConfigStoreAndForward sets the stream functions whose DataFrames are buffered while they are offline,
+// It maps the name of stream function to its observed data tags. It takes effect only if the
+// store-and-forward is enabled by `WithStoreAndForward`.
+func (s *Server) ConfigStoreAndForward(functions map[string][]frame.Tag) {
+	if s.stores != nil {
+		s.stores.setFunctions(functions)
+		s.advertiseTags()
+	}
+}
@@ -0,0 +1,182 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/core/router"
+	"github.com/yomorun/yomo/pkg/config"
+)
+
+func TestStoreAndForward(t *testing.T) {
+	metadataBuilder := metadata.DefaultBuilder()
+	routers := router.Default([]config.App{{Name: "sfn-1", Input: []frame.Tag{1}}})
+
+	sourceStream := newStreamAssert([]byte{})
+	connector := buildMockConnector(routers, metadataBuilder, []mockConnectorArgs{
+		{
+			name:        "source-1",
+			clientID:    "source-conn-id",
+			clientType:  byte(ClientTypeSource),
+			obversedTag: 2,
+			connID:      "source-conn-id",
+			stream:      sourceStream,
+		},
+	})
+	defer connector.Clean()
+
+	server := &Server{connector: connector}
+	server.Init(WithStoreAndForward(t.TempDir(), 0, 0))
+	defer server.stores.close()
+	server.ConfigRouter(routers)
+	server.ConfigMetadataBuilder(metadataBuilder)
+	server.ConfigStoreAndForward(map[string][]frame.Tag{"sfn-1": {1}})
+
+	send := func() *frame.DataFrame {
+		dataFrame := frame.NewDataFrame()
+		dataFrame.SetCarriage(1, []byte("hello yomo"))
+		dataFrame.SetSourceID("source-conn-id")
+
+		c := &Context{connID: "source-conn-id", Frame: dataFrame}
+		assert.NoError(t, server.handleDataFrame(c))
+		return dataFrame
+	}
+
+	// the stream function is offline, the DataFrames are buffered.
+	buffered := []frame.Frame{send(), send()}
+
+	// the stream function reconnects.
+	handshakeFrame := frame.NewHandshakeFrame("sfn-1", "sfn-1-id", byte(ClientTypeStreamFunction), []frame.Tag{1}, "token", "mock-token")
+	m, _ := metadataBuilder.Build(handshakeFrame)
+	sfnStream := newStreamAssert([]byte{})
+	conn := newConnection("sfn-1", "sfn-1-id", ClientTypeStreamFunction, m, sfnStream, []frame.Tag{1})
+	assert.NoError(t, routers.Route(m).Add("sfn-1-conn-id", "sfn-1", []frame.Tag{1}))
+	replay := server.pauseStore(m, "sfn-1")
+	assert.NotNil(t, replay)
+	connector.Add("sfn-1-conn-id", conn)
+
+	// the DataFrames arrived during the replay are buffered after the previous ones.
+	buffered = append(buffered, send())
+	server.replayStore(replay, "sfn-1-conn-id", conn)
+	sfnStream.writeEqual(t, composeFrametoBytes(buffered...))
+
+	// the DataFrames are written directly after the replay is completed.
+	direct := send()
+	sfnStream.writeEqual(t, composeFrametoBytes(append(buffered, direct)...))
+
+	// the stream function goes offline again, the DataFrames are buffered until it reconnects.
+	server.removeConnection("sfn-1-conn-id")
+	offline := []frame.Frame{send(), send()}
+
+	sfnStream = newStreamAssert([]byte{})
+	conn = newConnection("sfn-1", "sfn-1-id", ClientTypeStreamFunction, m, sfnStream, []frame.Tag{1})
+	assert.NoError(t, routers.Route(m).Add("sfn-1-conn-id-2", "sfn-1", []frame.Tag{1}))
+	replay = server.pauseStore(m, "sfn-1")
+	assert.NotNil(t, replay)
+	connector.Add("sfn-1-conn-id-2", conn)
+
+	server.replayStore(replay, "sfn-1-conn-id-2", conn)
+	sfnStream.writeEqual(t, composeFrametoBytes(offline...))
+}
+
+func TestReplayStoreFailures(t *testing.T) {
+	server := &Server{connector: newConnector()}
+	server.Init(WithStoreAndForward(t.TempDir(), 0, 0), WithDeadLetterRing(10))
+	defer server.stores.close()
+	server.ConfigRouter(router.Default([]config.App{{Name: "sfn-1"}}))
+	server.ConfigMetadataBuilder(metadata.DefaultBuilder())
+	server.ConfigStoreAndForward(map[string][]frame.Tag{"sfn-1": {1}})
+
+	m := &metadata.Default{}
+	q := server.pauseStore(m, "sfn-1")
+	require.NotNil(t, q)
+
+	buffer := func() frame.Frame {
+		f := frame.NewDataFrame()
+		f.SetCarriage(1, []byte("hello yomo"))
+		ok, err := q.append(f)
+		require.NoError(t, err)
+		require.True(t, ok)
+		return f
+	}
+	online := func() bool {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		return q.online
+	}
+
+	t.Run("write failed", func(t *testing.T) {
+		buffered := []frame.Frame{buffer(), buffer()}
+
+		// the DataFrame failed to be written is kept in the queue.
+		broken := newConnection("sfn-1", "sfn-1-id", ClientTypeStreamFunction, m, failingStream{}, []frame.Tag{1})
+		server.replayStore(q, "conn-1", broken)
+		assert.False(t, online())
+		assert.Empty(t, server.DeadLetters())
+
+		stream := newStreamAssert([]byte{})
+		conn := newConnection("sfn-1", "sfn-1-id", ClientTypeStreamFunction, m, stream, []frame.Tag{1})
+		server.replayStore(q, "conn-2", conn)
+		assert.True(t, online())
+		stream.writeEqual(t, composeFrametoBytes(buffered...))
+	})
+
+	t.Run("corrupted", func(t *testing.T) {
+		q.pause()
+		require.NoError(t, q.log.Append([]byte{}))
+		buffered := buffer()
+
+		stream := newStreamAssert([]byte{})
+		conn := newConnection("sfn-1", "sfn-1-id", ClientTypeStreamFunction, m, stream, []frame.Tag{1})
+		server.replayStore(q, "conn-3", conn)
+		stream.writeEqual(t, buffered.Encode())
+
+		// the record failed to be decoded goes to the dead letters.
+		deadLetters := server.DeadLetters()
+		require.Len(t, deadLetters, 1)
+		assert.Equal(t, DeadLetterCorrupted, deadLetters[0].Reason)
+		assert.Equal(t, "conn-3", deadLetters[0].ConnID)
+	})
+
+	t.Run("read failed", func(t *testing.T) {
+		interval := replayRetryInterval
+		replayRetryInterval = 10 * time.Millisecond
+		defer func() { replayRetryInterval = interval }()
+
+		q.pause()
+		buffered := buffer()
+		q.mu.Lock()
+		q.log = &flakyLog{recordLog: q.log}
+		q.mu.Unlock()
+
+		// the replay is retried while the stream function is connected.
+		stream := newStreamAssert([]byte{})
+		conn := newConnection("sfn-1", "sfn-1-id", ClientTypeStreamFunction, m, stream, []frame.Tag{1})
+		server.connector.Add("conn-4", conn)
+		defer server.connector.Remove("conn-4")
+		server.replayStore(q, "conn-4", conn)
+		assert.False(t, online())
+
+		require.Eventually(t, online, time.Second, 10*time.Millisecond)
+		stream.writeEqual(t, buffered.Encode())
+	})
+}
+
+// flakyLog fails the first read of the log.
+type flakyLog struct {
+	recordLog
+	failed bool
+}
+
+func (l *flakyLog) Next() ([]byte, error) {
+	if !l.failed {
+		l.failed = true
+		return nil, errors.New("read error")
+	}
+	return l.recordLog.Next()
+}
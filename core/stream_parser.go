@@ -31,6 +31,8 @@ func ParseFrame(stream io.Reader) (frame.Frame, error) {
 		return frame.DecodeToBackflowFrame(buf)
 	case 0x80 | byte(frame.TagOfHandshakeAckFrame):
 		return frame.DecodeToHandshakeAckFrame(buf)
+	case 0x80 | byte(frame.TagOfObservedTagsFrame):
+		return frame.DecodeToObservedTagsFrame(buf)
 	default:
 		return nil, fmt.Errorf("unknown frame type, buf[0]=%#x", buf[0])
 	}
@@ -0,0 +1,142 @@
+package core
+
+import (
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/lucas-clemente/quic-go"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/pkg/logger"
+)
+
+// tagStreams writes the DataFrames of each tag to their own unidirectional stream opened on demand,
+// so a large frame of one tag does not block the frames of the others (head-of-line blocking).
+// The frames of the same tag keep their order.
+type tagStreams struct {
+	open    func() (io.WriteCloser, error)
+	mu      sync.Mutex
+	streams map[frame.Tag]*tagStream
+	closed  bool
+}
+
+type tagStream struct {
+	mu sync.Mutex
+	w  io.WriteCloser
+}
+
+func newTagStreams(open func() (io.WriteCloser, error)) *tagStreams {
+	return &tagStreams{
+		open:    open,
+		streams: make(map[frame.Tag]*tagStream),
+	}
+}
+
+// Write writes the DataFrame to the stream of its tag, It returns false if the stream cannot be opened,
+// e.g. the peer limits the number of streams, then the caller writes it to the handshake stream instead.
+func (t *tagStreams) Write(f *frame.DataFrame) (bool, error) {
+	tag := f.GetDataTag()
+	s, err := t.get(tag)
+	if err != nil {
+		logger.Debugf("%sopen the stream of tag %#x error, fall back to the handshake stream: %v", ServerLogPrefix, tag, err)
+		return false, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.w.Write(f.Encode()); err != nil {
+		// the next frame of the tag opens a new stream.
+		t.remove(tag, s)
+		return true, err
+	}
+	return true, nil
+}
+
+func (t *tagStreams) get(tag frame.Tag) (*tagStream, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closed {
+		return nil, errors.New("streams are closed")
+	}
+	if s, ok := t.streams[tag]; ok {
+		return s, nil
+	}
+	w, err := t.open()
+	if err != nil {
+		return nil, err
+	}
+	s := &tagStream{w: w}
+	t.streams[tag] = s
+	return s, nil
+}
+
+func (t *tagStreams) remove(tag frame.Tag, s *tagStream) {
+	t.mu.Lock()
+	if t.streams[tag] == s {
+		delete(t.streams, tag)
+	}
+	t.mu.Unlock()
+
+	s.w.Close()
+}
+
+// Close closes all the streams.
+func (t *tagStreams) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.closed = true
+	for tag, s := range t.streams {
+		s.w.Close()
+		delete(t.streams, tag)
+	}
+	return nil
+}
+
+// streamConnection wraps a Connection, the DataFrames are written to the streams of their tags,
+// other frames are written to the underlying connection.
+type streamConnection struct {
+	Connection
+	streams *tagStreams
+}
+
+func newStreamConnection(conn Connection, qconn quic.Connection) *streamConnection {
+	return &streamConnection{
+		Connection: conn,
+		streams: newTagStreams(func() (io.WriteCloser, error) {
+			return qconn.OpenUniStream()
+		}),
+	}
+}
+
+// Write writes the DataFrame to the stream of its tag.
+func (c *streamConnection) Write(f frame.Frame) error {
+	if df, ok := f.(*frame.DataFrame); ok {
+		if written, err := c.streams.Write(df); written {
+			return err
+		}
+	}
+	return c.Connection.Write(f)
+}
+
+// Close closes the streams of tags and the underlying connection.
+func (c *streamConnection) Close() error {
+	c.streams.Close()
+	return c.Connection.Close()
+}
+
+// receiveStream adapts the unidirectional stream to the Stream of Context.
+type receiveStream struct {
+	quic.ReceiveStream
+}
+
+func (s receiveStream) Write(p []byte) (int, error) {
+	return 0, errors.New("write to a receive stream")
+}
+
+func (s receiveStream) Close() error {
+	s.CancelRead(0)
+	return nil
+}
@@ -0,0 +1,70 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+)
+
+type nopWriteCloser struct {
+	*bytes.Buffer
+	closed bool
+}
+
+func (w *nopWriteCloser) Close() error {
+	w.closed = true
+	return nil
+}
+
+func TestTagStreams(t *testing.T) {
+	opened := []*nopWriteCloser{}
+	streams := newTagStreams(func() (io.WriteCloser, error) {
+		w := &nopWriteCloser{Buffer: new(bytes.Buffer)}
+		opened = append(opened, w)
+		return w, nil
+	})
+
+	f1 := frame.NewDataFrame()
+	f1.SetCarriage(1, []byte("large"))
+	f2 := frame.NewDataFrame()
+	f2.SetCarriage(2, []byte("small"))
+	f3 := frame.NewDataFrame()
+	f3.SetCarriage(1, []byte("next"))
+
+	for _, f := range []*frame.DataFrame{f1, f2, f3} {
+		written, err := streams.Write(f)
+		assert.True(t, written)
+		assert.NoError(t, err)
+	}
+
+	// a stream is opened for each tag, the frames of the same tag keep their order.
+	assert.Len(t, opened, 2)
+	assert.Equal(t, append(f1.Encode(), f3.Encode()...), opened[0].Bytes())
+	assert.Equal(t, f2.Encode(), opened[1].Bytes())
+
+	assert.NoError(t, streams.Close())
+	assert.True(t, opened[0].closed)
+	assert.True(t, opened[1].closed)
+	written, _ := streams.Write(f1)
+	assert.False(t, written)
+}
+
+func TestStreamConnectionFallback(t *testing.T) {
+	stream := newStreamAssert([]byte{})
+	conn := &streamConnection{
+		Connection: newConnection("sfn-1", "sfn-1-id", ClientTypeStreamFunction, nil, stream, []frame.Tag{1}),
+		streams: newTagStreams(func() (io.WriteCloser, error) {
+			return nil, errors.New("too many open streams")
+		}),
+	}
+
+	// the DataFrame is written to the handshake stream if the stream of tag cannot be opened.
+	f := frame.NewDataFrame()
+	f.SetCarriage(1, []byte("yomo"))
+	assert.NoError(t, conn.Write(f))
+	stream.writeEqual(t, f.Encode())
+}
@@ -0,0 +1,144 @@
+// Package trace records the spans of DataFrames across the hops of YoMo,
+// source, zippers and stream functions, to find which hop adds latency.
+//
+// The trace context is carried in the MetaFrame of DataFrame in W3C traceparent style,
+// a 16 bytes trace ID and an 8 bytes span ID of the last hop, both in lowercase hex.
+// Each hop continues the trace of the DataFrame it receives, or starts a new one.
+package trace
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/yomorun/yomo/core/frame"
+)
+
+// Span describes the processing of a DataFrame in a hop.
+type Span struct {
+	TraceID       string            `json:"trace_id"`
+	SpanID        string            `json:"span_id"`
+	ParentSpanID  string            `json:"parent_span_id,omitempty"`
+	Name          string            `json:"name"`
+	Service       string            `json:"service"`
+	Tag           frame.Tag         `json:"tag"`
+	TransactionID string            `json:"tid"`
+	StartTime     time.Time         `json:"start_time"`
+	EndTime       time.Time         `json:"end_time"`
+	Attributes    map[string]string `json:"attributes,omitempty"`
+
+	mu       sync.Mutex
+	exporter Exporter
+	ended    bool
+}
+
+// Exporter exports the ended spans.
+type Exporter interface {
+	// Export exports the span, It must not block for long and must be safe for concurrent use.
+	Export(span *Span) error
+}
+
+// Tracer starts the spans of a service, the service is the name of client or zipper.
+// A nil Tracer is valid and starts nil spans, which do nothing.
+type Tracer struct {
+	service  string
+	exporter Exporter
+}
+
+// NewTracer creates a Tracer, It returns nil if the exporter is nil.
+func NewTracer(service string, exporter Exporter) *Tracer {
+	if exporter == nil {
+		return nil
+	}
+	return &Tracer{service: service, exporter: exporter}
+}
+
+// Start starts a span of the DataFrame, It continues the trace carried by the DataFrame
+// or starts a new trace, then sets the span as the last hop of the DataFrame,
+// so the spans of next hops are its children.
+func (t *Tracer) Start(name string, f *frame.DataFrame) *Span {
+	if t == nil {
+		return nil
+	}
+	meta := f.GetMetaFrame()
+	span := &Span{
+		TraceID:       meta.TraceID(),
+		SpanID:        newID(8),
+		ParentSpanID:  meta.SpanID(),
+		Name:          name,
+		Service:       t.service,
+		Tag:           f.GetDataTag(),
+		TransactionID: f.TransactionID(),
+		StartTime:     time.Now(),
+		exporter:      t.exporter,
+	}
+	if span.TraceID == "" {
+		span.TraceID = newID(16)
+		span.ParentSpanID = ""
+	}
+	meta.SetTraceID(span.TraceID)
+	meta.SetSpanID(span.SpanID)
+	return span
+}
+
+// SetAttribute sets an attribute of the span.
+func (s *Span) SetAttribute(key, value string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Attributes == nil {
+		s.Attributes = make(map[string]string)
+	}
+	s.Attributes[key] = value
+}
+
+// SetError records the error of the span, the nil error is ignored.
+func (s *Span) SetError(err error) {
+	if err != nil {
+		s.SetAttribute("error", err.Error())
+	}
+}
+
+// End ends the span and exports it, only the first call takes effect.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.EndTime = time.Now()
+	s.mu.Unlock()
+
+	s.exporter.Export(s)
+}
+
+// Duration returns the duration of the span, It is zero before the span ends.
+func (s *Span) Duration() time.Duration {
+	if s.EndTime.IsZero() {
+		return 0
+	}
+	return s.EndTime.Sub(s.StartTime)
+}
+
+// Inject copies the trace context of the DataFrame to the DataFrame produced from it,
+// such as the DataFrame returned by a stream function.
+func Inject(from *frame.MetaFrame, to *frame.DataFrame) {
+	to.GetMetaFrame().SetTraceID(from.TraceID())
+	to.GetMetaFrame().SetSpanID(from.SpanID())
+}
+
+func newID(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		b = []byte(time.Now().Format("20060102150405.000000000"))[:n]
+	}
+	return hex.EncodeToString(b)
+}
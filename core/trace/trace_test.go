@@ -0,0 +1,85 @@
+package trace
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+)
+
+type spans []*Span
+
+func (s *spans) Export(span *Span) error {
+	*s = append(*s, span)
+	return nil
+}
+
+func TestTracer(t *testing.T) {
+	exported := &spans{}
+
+	f := frame.NewDataFrame()
+	f.SetCarriage(1, []byte("hello yomo"))
+
+	// the source starts a new trace.
+	source := NewTracer("source-1", exported).Start("source", f)
+	assert.Len(t, source.TraceID, 32)
+	assert.Len(t, source.SpanID, 16)
+	assert.Empty(t, source.ParentSpanID)
+	assert.Equal(t, source.TraceID, f.GetMetaFrame().TraceID())
+	assert.Equal(t, source.SpanID, f.GetMetaFrame().SpanID())
+	source.End()
+
+	// the zipper continues the trace carried by the DataFrame.
+	received, err := frame.DecodeToDataFrame(f.Encode())
+	assert.NoError(t, err)
+	zipper := NewTracer("zipper-1", exported).Start("zipper", received)
+	assert.Equal(t, source.TraceID, zipper.TraceID)
+	assert.Equal(t, source.SpanID, zipper.ParentSpanID)
+	zipper.SetError(errors.New("closed"))
+	zipper.End()
+	zipper.End()
+
+	assert.Equal(t, spans{source, zipper}, *exported)
+	assert.Equal(t, "closed", zipper.Attributes["error"])
+	assert.Equal(t, f.TransactionID(), zipper.TransactionID)
+	assert.Equal(t, frame.Tag(1), zipper.Tag)
+	assert.GreaterOrEqual(t, int64(zipper.Duration()), int64(0))
+
+	// the response of stream function is the child of its span.
+	resp := frame.NewDataFrame()
+	Inject(received.GetMetaFrame(), resp)
+	assert.Equal(t, zipper.TraceID, resp.GetMetaFrame().TraceID())
+	assert.Equal(t, zipper.SpanID, resp.GetMetaFrame().SpanID())
+}
+
+func TestNilTracer(t *testing.T) {
+	tracer := NewTracer("source-1", nil)
+	assert.Nil(t, tracer)
+
+	f := frame.NewDataFrame()
+	f.SetCarriage(1, []byte("hello yomo"))
+	span := tracer.Start("source", f)
+	assert.Nil(t, span)
+	span.SetError(errors.New("closed"))
+	span.End()
+	assert.Empty(t, f.GetMetaFrame().TraceID())
+}
+
+func TestJSONExporter(t *testing.T) {
+	buf := &bytes.Buffer{}
+
+	f := frame.NewDataFrame()
+	f.SetCarriage(1, []byte("hello yomo"))
+	span := NewTracer("source-1", NewJSONExporter(buf)).Start("source", f)
+	span.End()
+
+	result := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &result))
+	assert.Equal(t, span.TraceID, result["trace_id"])
+	assert.Equal(t, span.SpanID, result["span_id"])
+	assert.Equal(t, "source-1", result["service"])
+	assert.Equal(t, "source", result["name"])
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"context"
+	"crypto/tls"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/lucas-clemente/quic-go"
+)
+
+// Transport dials the connection to zipper. QUIC is the default one, TCP+TLS and WebSocket
+// are the fallback ones for the networks blocking UDP, the Y3 framing is the same on all of them.
+type Transport interface {
+	// Name returns the name of the transport.
+	Name() string
+	// Dial dials the zipper listening on addr.
+	Dial(ctx context.Context, addr string, tlsConfig *tls.Config, quicConfig *quic.Config) (quic.Connection, error)
+}
+
+// NewQUICTransport returns the QUIC transport.
+func NewQUICTransport() Transport {
+	return quicTransport{}
+}
+
+type quicTransport struct{}
+
+func (quicTransport) Name() string { return "QUIC" }
+
+func (quicTransport) Dial(ctx context.Context, addr string, tlsConfig *tls.Config, quicConfig *quic.Config) (quic.Connection, error) {
+	return quic.DialAddrContext(ctx, addr, tlsConfig, quicConfig)
+}
+
+// NewTCPTransport returns the TCP+TLS transport, It dials addr, or the address of the zipper if addr is empty,
+// the zipper listens on it by `WithTCPAddr`.
+func NewTCPTransport(addr string) Transport {
+	return tcpTransport{addr: addr}
+}
+
+type tcpTransport struct {
+	addr string
+}
+
+func (tcpTransport) Name() string { return "TCP" }
+
+func (t tcpTransport) Dial(ctx context.Context, addr string, tlsConfig *tls.Config, _ *quic.Config) (quic.Connection, error) {
+	if t.addr != "" {
+		addr = t.addr
+	}
+	dialer := &tls.Dialer{Config: tlsConfig}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	tlsConn := conn.(*tls.Conn)
+	return newNetConnection(tlsConn, tlsConn.ConnectionState().NegotiatedProtocol), nil
+}
+
+// NewWebSocketTransport returns the WebSocket transport, It dials the url, e.g. `wss://zipper:9443/yomo`,
+// the zipper listens on it by `WithWebSocketAddr`.
+func NewWebSocketTransport(url string) Transport {
+	return websocketTransport{url: url}
+}
+
+type websocketTransport struct {
+	url string
+}
+
+func (websocketTransport) Name() string { return "WebSocket" }
+
+func (t websocketTransport) Dial(ctx context.Context, _ string, tlsConfig *tls.Config, _ *quic.Config) (quic.Connection, error) {
+	dialer := &websocket.Dialer{
+		TLSClientConfig:  tlsConfig,
+		HandshakeTimeout: tlsHandshakeTimeout,
+	}
+	conn, _, err := dialer.DialContext(ctx, t.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	proto := ""
+	if tlsConn, ok := conn.UnderlyingConn().(*tls.Conn); ok {
+		proto = tlsConn.ConnectionState().NegotiatedProtocol
+	}
+	return newNetConnection(newWebSocketConn(conn), proto), nil
+}
+
+// dialTimeout limits the dial of each transport, so the next one is tried in time.
+const dialTimeout = 10 * time.Second
@@ -0,0 +1,105 @@
+package core
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
+	pkgtls "github.com/yomorun/yomo/pkg/tls"
+)
+
+func TestNetConnection(t *testing.T) {
+	c1, c2 := net.Pipe()
+	client, server := newNetConnection(c1, "yomo"), newNetConnection(c2, "yomo")
+	assert.Equal(t, "yomo", server.ConnectionState().TLS.NegotiatedProtocol)
+	assert.False(t, supportsDatagrams(server))
+
+	// the connection carries a single stream.
+	stream, err := client.OpenStreamSync(context.TODO())
+	assert.NoError(t, err)
+	_, err = client.OpenStream()
+	assert.ErrorIs(t, err, errSingleStream)
+	_, err = client.OpenUniStream()
+	assert.ErrorIs(t, err, errSingleStream)
+
+	accepted, err := server.AcceptStream(context.TODO())
+	assert.NoError(t, err)
+
+	// the second AcceptStream blocks until the connection is closed.
+	closed := make(chan error)
+	go func() {
+		_, err := server.AcceptStream(context.TODO())
+		closed <- err
+	}()
+
+	f := frame.NewDataFrame()
+	f.SetCarriage(1, []byte("yomo"))
+	go NewFrameStream(stream).WriteFrame(f)
+	received, err := NewFrameStream(accepted).ReadFrame()
+	assert.NoError(t, err)
+	assert.Equal(t, f.Encode(), received.Encode())
+
+	client.CloseWithError(0, "bye")
+	// the server reads EOF, then the connection is closed.
+	_, err = NewFrameStream(accepted).ReadFrame()
+	assert.Error(t, err)
+	select {
+	case err := <-closed:
+		assert.ErrorIs(t, err, net.ErrClosed)
+	case <-time.After(time.Second):
+		t.Fatal("AcceptStream is not unblocked by closing the connection")
+	}
+}
+
+func TestFallbackTransports(t *testing.T) {
+	serverTLS, err := pkgtls.CreateServerTLSConfig("127.0.0.1")
+	assert.NoError(t, err)
+	clientTLS := pkgtls.MustCreateClientTLSConfig()
+
+	tcp, err := newTCPListener("127.0.0.1:0", serverTLS)
+	assert.NoError(t, err)
+	defer tcp.Close()
+
+	wsTLS := serverTLS.Clone()
+	wsTLS.NextProtos = append(wsTLS.NextProtos, "http/1.1")
+	ws, err := newWebSocketListener("127.0.0.1:0", "/yomo", wsTLS)
+	assert.NoError(t, err)
+	defer ws.Close()
+
+	tests := []struct {
+		name      string
+		listener  Listener
+		transport Transport
+	}{
+		{"tcp", tcp, NewTCPTransport(tcp.Addr().String())},
+		{"websocket", ws, NewWebSocketTransport("wss://" + ws.Addr().String() + "/yomo")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			conn, err := tt.transport.Dial(ctx, "", clientTLS, nil)
+			assert.NoError(t, err)
+			defer conn.CloseWithError(0, "")
+			stream, err := conn.OpenStreamSync(ctx)
+			assert.NoError(t, err)
+
+			accepted, err := tt.listener.Accept(ctx)
+			assert.NoError(t, err)
+			assert.Equal(t, "yomo", accepted.ConnectionState().TLS.NegotiatedProtocol)
+			serverStream, err := accepted.AcceptStream(ctx)
+			assert.NoError(t, err)
+
+			// the Y3 frames are the same on every transport.
+			handshake := frame.NewHandshakeFrame("source", "source-id", byte(ClientTypeSource), nil, "", "")
+			assert.NoError(t, NewFrameStream(stream).WriteFrame(handshake))
+			received, err := NewFrameStream(serverStream).ReadFrame()
+			assert.NoError(t, err)
+			assert.Equal(t, handshake.Encode(), received.Encode())
+		})
+	}
+}
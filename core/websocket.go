@@ -0,0 +1,76 @@
+package core
+
+import (
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// websocketConn adapts the WebSocket connection to net.Conn, the Y3 frames are written
+// in binary messages and read as a continuous stream across the messages.
+type websocketConn struct {
+	conn   *websocket.Conn
+	reader io.Reader
+	wmu    sync.Mutex
+}
+
+var _ net.Conn = &websocketConn{}
+
+func newWebSocketConn(conn *websocket.Conn) *websocketConn {
+	return &websocketConn{conn: conn}
+}
+
+func (c *websocketConn) Read(p []byte) (int, error) {
+	for {
+		if c.reader == nil {
+			typ, r, err := c.conn.NextReader()
+			if err != nil {
+				if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+					return 0, io.EOF
+				}
+				return 0, err
+			}
+			if typ != websocket.BinaryMessage {
+				continue
+			}
+			c.reader = r
+		}
+		n, err := c.reader.Read(p)
+		if err == io.EOF {
+			// the next message continues the stream.
+			c.reader = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (c *websocketConn) Write(p []byte) (int, error) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	if err := c.conn.WriteMessage(websocket.BinaryMessage, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (c *websocketConn) Close() error         { return c.conn.Close() }
+func (c *websocketConn) LocalAddr() net.Addr  { return c.conn.LocalAddr() }
+func (c *websocketConn) RemoteAddr() net.Addr { return c.conn.RemoteAddr() }
+
+func (c *websocketConn) SetDeadline(t time.Time) error {
+	if err := c.conn.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return c.conn.SetWriteDeadline(t)
+}
+
+func (c *websocketConn) SetReadDeadline(t time.Time) error  { return c.conn.SetReadDeadline(t) }
+func (c *websocketConn) SetWriteDeadline(t time.Time) error { return c.conn.SetWriteDeadline(t) }
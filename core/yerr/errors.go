@@ -64,6 +64,12 @@ const (
 	ErrorCodeUnknownClient ErrorCode = 0xCD
 	// ErrorCodeDuplicateName unknown client error
 	ErrorCodeDuplicateName ErrorCode = 0xC6
+	// ErrorCodeQueueFull send queue is full
+	ErrorCodeQueueFull ErrorCode = 0xC8
+	// ErrorCodeForbidden the data tag is not allowed by ACL
+	ErrorCodeForbidden ErrorCode = 0xC9
+	// ErrorCodeRateLimited the client exceeds the rate limit
+	ErrorCodeRateLimited ErrorCode = 0xCA
 )
 
 var errCodeStringMap = map[ErrorCode]string{
@@ -79,6 +85,9 @@ var errCodeStringMap = map[ErrorCode]string{
 	ErrorCodeData:          "DataFrame",
 	ErrorCodeUnknownClient: "UnknownClient",
 	ErrorCodeDuplicateName: "DuplicateName",
+	ErrorCodeQueueFull:     "QueueFull",
+	ErrorCodeForbidden:     "Forbidden",
+	ErrorCodeRateLimited:   "RateLimited",
 }
 
 func (e ErrorCode) String() string {
@@ -7,8 +7,11 @@ require (
 	github.com/bytecodealliance/wasmtime-go v1.0.0
 	github.com/caarlos0/env/v6 v6.10.1
 	github.com/cenkalti/backoff/v4 v4.1.3
+	github.com/eclipse/paho.mqtt.golang v1.4.2
 	github.com/fatih/color v1.13.0
+	github.com/gorilla/websocket v1.5.0
 	github.com/joho/godotenv v1.4.0
+	github.com/klauspost/compress v1.15.12
 	github.com/lucas-clemente/quic-go v0.31.0
 	github.com/matoous/go-nanoid/v2 v2.0.0
 	github.com/reactivex/rxgo/v2 v2.5.0
@@ -18,6 +21,7 @@ require (
 	github.com/spf13/viper v1.13.0
 	github.com/stretchr/testify v1.8.1
 	github.com/yomorun/y3 v1.0.5
+	go.uber.org/multierr v1.8.0
 	go.uber.org/zap v1.23.0
 	golang.org/x/exp v0.0.0-20221212164502-fae10dda9338
 	golang.org/x/tools v0.3.0
@@ -51,10 +55,10 @@ require (
 	github.com/subosito/gotenv v1.4.1 // indirect
 	github.com/teivah/onecontext v1.3.0 // indirect
 	go.uber.org/atomic v1.10.0 // indirect
-	go.uber.org/multierr v1.8.0 // indirect
 	golang.org/x/crypto v0.1.0 // indirect
 	golang.org/x/mod v0.7.0 // indirect
 	golang.org/x/net v0.2.0 // indirect
+	golang.org/x/sync v0.1.0 // indirect
 	golang.org/x/sys v0.2.0 // indirect
 	golang.org/x/text v0.4.0 // indirect
 	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
@@ -2,11 +2,13 @@ package yomo
 
 import (
 	"crypto/tls"
+	"time"
 
 	"github.com/lucas-clemente/quic-go"
 	"github.com/yomorun/yomo/core"
 	"github.com/yomorun/yomo/core/frame"
 	"github.com/yomorun/yomo/core/log"
+	"github.com/yomorun/yomo/core/trace"
 )
 
 const (
@@ -21,8 +23,14 @@ type Option func(o *Options)
 type Options struct {
 	ZipperAddr string // target Zipper endpoint address
 	// ZipperListenAddr     string // Zipper endpoint address
-	ZipperWorkflowConfig string // Zipper workflow file
-	MeshConfigURL        string // meshConfigURL is the URL of edge-mesh config
+	ZipperWorkflowConfig string          // Zipper workflow file
+	MeshConfigURL        string          // meshConfigURL is the URL of edge-mesh config
+	MeshConfigInterval   time.Duration   // MeshConfigInterval is the interval of re-loading the edge-mesh config
+	AdminAddr            string          // AdminAddr is the listening address of the zipper's admin HTTP API
+	AdminToken           string          // AdminToken is the bearer token required by the DELETE endpoints of the admin HTTP API
+	IngressAddr          string          // IngressAddr is the listening address of the zipper's HTTP ingress
+	EgressAddr           string          // EgressAddr is the listening address of the zipper's WebSocket/SSE egress
+	DownstreamTagFilter  *core.TagFilter // DownstreamTagFilter filters the data tags forwarded to the downstream zipper
 	ServerOptions        []core.ServerOption
 	ClientOptions        []core.ClientOption
 	QuicConfig           *quic.Config
@@ -53,6 +61,52 @@ func WithMeshConfigURL(url string) Option {
 	}
 }
 
+// WithMeshConfigInterval re-loads the edge-mesh config on the interval, the downstream zippers
+// are connected or disconnected by the changes of it.
+func WithMeshConfigInterval(interval time.Duration) Option {
+	return func(o *Options) {
+		o.MeshConfigInterval = interval
+	}
+}
+
+// WithDownstreamTagFilter filters the data tags of the DataFrames forwarded to the downstream zipper
+// created by `NewDownstreamZipper`, only the allowed ones are forwarded if allow is not empty,
+// the denied ones are never forwarded.
+func WithDownstreamTagFilter(allow []frame.Tag, deny []frame.Tag) Option {
+	return func(o *Options) {
+		o.DownstreamTagFilter = &core.TagFilter{Allow: allow, Deny: deny}
+	}
+}
+
+// WithAdminAddr enables the admin HTTP API of the YoMo-Zipper on addr.
+func WithAdminAddr(addr string) Option {
+	return func(o *Options) {
+		o.AdminAddr = addr
+	}
+}
+
+// WithAdminToken sets the bearer token required by the DELETE endpoints of the admin HTTP API,
+// they are disabled if the token is not set.
+func WithAdminToken(token string) Option {
+	return func(o *Options) {
+		o.AdminToken = token
+	}
+}
+
+// WithIngressAddr enables the HTTP ingress of the YoMo-Zipper on addr, the requests are written as DataFrames.
+func WithIngressAddr(addr string) Option {
+	return func(o *Options) {
+		o.IngressAddr = addr
+	}
+}
+
+// WithEgressAddr enables the WebSocket/SSE egress of the YoMo-Zipper on addr, the browsers subscribe data tags by it.
+func WithEgressAddr(addr string) Option {
+	return func(o *Options) {
+		o.EgressAddr = addr
+	}
+}
+
 // WithTLSConfig sets the TLS configuration for the client.
 func WithTLSConfig(tc *tls.Config) Option {
 	return func(o *Options) {
@@ -91,6 +145,145 @@ func WithAuth(name string, args ...string) Option {
 	}
 }
 
+// WithSendQueue sets a bounded send queue with the overflow policy for each connection (used by server)
+func WithSendQueue(size int, policy core.QueuePolicy) Option {
+	return func(o *Options) {
+		o.ServerOptions = append(
+			o.ServerOptions,
+			core.WithSendQueue(size, policy),
+		)
+	}
+}
+
+// WithDeadLetterHandler adds a handler of the DataFrames failed to be delivered (used by server)
+func WithDeadLetterHandler(h core.DeadLetterHandler) Option {
+	return func(o *Options) {
+		o.ServerOptions = append(
+			o.ServerOptions,
+			core.WithDeadLetterHandler(h),
+		)
+	}
+}
+
+// WithDeadLetterRing keeps the latest size dead letters in memory, they are exposed by the admin API (used by server)
+func WithDeadLetterRing(size int) Option {
+	return func(o *Options) {
+		o.ServerOptions = append(
+			o.ServerOptions,
+			core.WithDeadLetterRing(size),
+		)
+	}
+}
+
+// WithDeadLetterTag routes the dead letters to the stream functions observing tag (used by server)
+func WithDeadLetterTag(tag frame.Tag) Option {
+	return func(o *Options) {
+		o.ServerOptions = append(
+			o.ServerOptions,
+			core.WithDeadLetterTag(tag),
+		)
+	}
+}
+
+// WithStoreAndForward buffers the DataFrames of offline stream functions in dir with the limits,
+// the stream functions are enabled by `store_and_forward` in workflow config (used by server)
+func WithStoreAndForward(dir string, maxSize int64, maxAge time.Duration) Option {
+	return func(o *Options) {
+		o.ServerOptions = append(
+			o.ServerOptions,
+			core.WithStoreAndForward(dir, maxSize, maxAge),
+		)
+	}
+}
+
+// WithTraceExporter enables tracing, the spans of DataFrames are exported by the exporter (used by source/sfn/server)
+func WithTraceExporter(exporter trace.Exporter) Option {
+	return func(o *Options) {
+		o.ClientOptions = append(
+			o.ClientOptions,
+			core.WithClientTraceExporter(exporter),
+		)
+		o.ServerOptions = append(
+			o.ServerOptions,
+			core.WithTraceExporter(exporter),
+		)
+	}
+}
+
+// WithCompression compresses the carriage of the data with the algorithm, `gzip` or `zstd`,
+// the data smaller than threshold bytes is sent uncompressed (used by source/sfn)
+func WithCompression(name string, threshold int) Option {
+	return func(o *Options) {
+		o.ClientOptions = append(
+			o.ClientOptions,
+			core.WithCompression(name, threshold),
+		)
+	}
+}
+
+// WithStreamPerTag writes the data of each tag to their own stream, so a large data of one tag
+// does not delay the others (used by source/sfn)
+func WithStreamPerTag() Option {
+	return func(o *Options) {
+		o.ClientOptions = append(
+			o.ClientOptions,
+			core.WithStreamPerTag(),
+		)
+	}
+}
+
+// WithDatagramTags sends the data of the tags as unreliable QUIC datagrams, which tolerate loss
+// but not latency (used by source/sfn)
+func WithDatagramTags(tags ...frame.Tag) Option {
+	return func(o *Options) {
+		o.ClientOptions = append(
+			o.ClientOptions,
+			core.WithDatagramTags(tags...),
+		)
+	}
+}
+
+// WithTransports sets the transports dialing the zipper in order, the next one is tried if the previous
+// one fails, e.g. TCP+TLS and WebSocket in the networks blocking UDP (used by source/sfn/upstream zipper)
+func WithTransports(transports ...core.Transport) Option {
+	return func(o *Options) {
+		o.ClientOptions = append(
+			o.ClientOptions,
+			core.WithTransports(transports...),
+		)
+	}
+}
+
+// WithMaxHops sets the maximum times a broadcast DataFrame is forwarded across zippers.
+func WithMaxHops(n int) Option {
+	return func(o *Options) {
+		o.ServerOptions = append(
+			o.ServerOptions,
+			core.WithMaxHops(n),
+		)
+	}
+}
+
+// WithTCPAddr listens TCP+TLS on addr besides QUIC (used by zipper)
+func WithTCPAddr(addr string) Option {
+	return func(o *Options) {
+		o.ServerOptions = append(
+			o.ServerOptions,
+			core.WithTCPAddr(addr),
+		)
+	}
+}
+
+// WithWebSocketAddr listens WebSocket over TLS on addr and path besides QUIC (used by zipper)
+func WithWebSocketAddr(addr string, path string) Option {
+	return func(o *Options) {
+		o.ServerOptions = append(
+			o.ServerOptions,
+			core.WithWebSocketAddr(addr, path),
+		)
+	}
+}
+
 // WithCredential sets the client credential method (used by client)
 func WithCredential(payload string) Option {
 	return func(o *Options) {
@@ -0,0 +1,284 @@
+// Package admin provides an embedded HTTP API for inspecting and controlling a running YoMo-Zipper.
+//
+// The API returns JSON, the endpoints are:
+//
+//	GET    /connections          list the connected clients
+//	DELETE /connections/{connID} send a GoawayFrame to the connection
+//	GET    /downstreams          list the downstream zippers
+//	DELETE /downstreams/{addr}   remove the downstream zipper
+//	GET    /routes               list the route table
+//	GET    /stats                show the counters of zipper
+//	GET    /deadletters          list the latest DataFrames failed to be delivered
+//	GET    /metrics              export the metrics in Prometheus text format
+//
+// The DELETE endpoints require the header `Authorization: Bearer <token>` with the admin token,
+// they are disabled if the token is not set.
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/yomorun/yomo/core"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metrics"
+	"github.com/yomorun/yomo/pkg/logger"
+)
+
+const adminLogPrefix = "\033[35m[yomo:admin]\033[0m "
+
+// Connection describes a client connected to zipper.
+type Connection struct {
+	ConnID          string      `json:"conn_id"`
+	Name            string      `json:"name"`
+	ClientID        string      `json:"client_id"`
+	ClientType      string      `json:"client_type"`
+	ObserveDataTags []frame.Tag `json:"observe_data_tags"`
+	QueueDepth      *int        `json:"queue_depth,omitempty"`
+}
+
+// Downstream describes a downstream zipper.
+type Downstream struct {
+	Addr  string `json:"addr"`
+	State string `json:"state,omitempty"`
+}
+
+// Subscriber describes a stream function which observes a data tag.
+type Subscriber struct {
+	ConnID string `json:"conn_id"`
+	Name   string `json:"name"`
+}
+
+// Route describes the subscribers of a data tag.
+type Route struct {
+	Tag         frame.Tag    `json:"tag"`
+	Subscribers []Subscriber `json:"subscribers"`
+}
+
+// Stats describes the counters of zipper.
+type Stats struct {
+	Connections int   `json:"connections"`
+	Downstreams int   `json:"downstreams"`
+	DataFrames  int64 `json:"data_frames"`
+	MeshDropped int64 `json:"mesh_dropped"`
+}
+
+// Server is the admin HTTP server of a YoMo-Zipper.
+type Server struct {
+	server     *core.Server
+	token      string
+	httpServer *http.Server
+	mux        *http.ServeMux
+}
+
+// NewServer creates an admin server for the zipper's underlying server, token is the bearer token
+// required by the DELETE endpoints, they are disabled if it is empty.
+func NewServer(addr string, server *core.Server, token string) *Server {
+	s := &Server{
+		server: server,
+		token:  token,
+		mux:    http.NewServeMux(),
+	}
+	s.mux.HandleFunc("/connections", s.handleConnections)
+	s.mux.HandleFunc("/connections/", s.handleConnection)
+	s.mux.HandleFunc("/downstreams", s.handleDownstreams)
+	s.mux.HandleFunc("/downstreams/", s.handleDownstream)
+	s.mux.HandleFunc("/routes", s.handleRoutes)
+	s.mux.HandleFunc("/stats", s.handleStats)
+	s.mux.HandleFunc("/deadletters", s.handleDeadLetters)
+	s.mux.Handle("/metrics", metrics.Handler())
+	s.httpServer = &http.Server{Addr: addr, Handler: s}
+
+	return s
+}
+
+// ListenAndServe starts the admin server.
+func (s *Server) ListenAndServe() error {
+	logger.Printf("%s✅ Admin API listening on: %s", adminLogPrefix, s.httpServer.Addr)
+	err := s.httpServer.ListenAndServe()
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+// Close shuts down the admin server.
+func (s *Server) Close() error {
+	return s.httpServer.Shutdown(context.Background())
+}
+
+// ServeHTTP implements http.Handler interface.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleConnections(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	result := []Connection{}
+	for connID, conn := range s.server.Connector().GetConns() {
+		c := Connection{
+			ConnID:          connID,
+			Name:            conn.Name(),
+			ClientID:        conn.ClientID(),
+			ClientType:      conn.ClientType().String(),
+			ObserveDataTags: conn.ObserveDataTags(),
+		}
+		// the connection has a send queue
+		if v, ok := conn.(interface{ QueueLen() int }); ok {
+			depth := v.QueueLen()
+			c.QueueDepth = &depth
+		}
+		result = append(result, c)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ConnID < result[j].ConnID })
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleConnection(w http.ResponseWriter, r *http.Request) {
+	connID, err := pathParam(r, "/connections/")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if r.Method != http.MethodDelete {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !s.authorize(w, r) {
+		return
+	}
+	conn := s.server.Connector().Get(connID)
+	if conn == nil {
+		writeError(w, http.StatusNotFound, "connection not found: "+connID)
+		return
+	}
+	// the client closes the connection itself when it receives the GoawayFrame.
+	if err := conn.Write(frame.NewGoawayFrame("disconnected by admin")); err != nil {
+		logger.Errorf("%swrite to [%s](%s) GoawayFrame error: %v", adminLogPrefix, conn.Name(), connID, err)
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	logger.Printf("%s[%s](%s) is disconnected", adminLogPrefix, conn.Name(), connID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleDownstreams(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	result := []Downstream{}
+	for addr, ds := range s.server.Downstreams() {
+		downstream := Downstream{Addr: addr}
+		if v, ok := ds.(interface{ State() core.ConnState }); ok {
+			downstream.State = v.State()
+		}
+		result = append(result, downstream)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Addr < result[j].Addr })
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleDownstream(w http.ResponseWriter, r *http.Request) {
+	addr, err := pathParam(r, "/downstreams/")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if r.Method != http.MethodDelete {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !s.authorize(w, r) {
+		return
+	}
+	if ok := s.server.RemoveDownstreamServer(addr); !ok {
+		writeError(w, http.StatusNotFound, "downstream not found: "+addr)
+		return
+	}
+	logger.Printf("%sdownstream [%s] is removed", adminLogPrefix, addr)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleRoutes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	result := []Route{}
+	for tag, conns := range s.server.StatsRoutes() {
+		route := Route{Tag: tag, Subscribers: []Subscriber{}}
+		for connID, name := range conns {
+			route.Subscribers = append(route.Subscribers, Subscriber{ConnID: connID, Name: name})
+		}
+		sort.Slice(route.Subscribers, func(i, j int) bool { return route.Subscribers[i].ConnID < route.Subscribers[j].ConnID })
+		result = append(result, route)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Tag < result[j].Tag })
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, http.StatusOK, Stats{
+		Connections: len(s.server.StatsFunctions()),
+		Downstreams: len(s.server.Downstreams()),
+		DataFrames:  s.server.StatsCounter(),
+		MeshDropped: s.server.StatsMeshDropped(),
+	})
+}
+
+func (s *Server) handleDeadLetters(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	result := s.server.DeadLetters()
+	if result == nil {
+		writeError(w, http.StatusNotFound, "dead letter ring is disabled")
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// authorize returns true if the request carries the admin token, otherwise It responds the error.
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) bool {
+	if s.token == "" {
+		writeError(w, http.StatusForbidden, "admin token is not set")
+		return false
+	}
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+		writeError(w, http.StatusUnauthorized, "unauthorized")
+		return false
+	}
+	return true
+}
+
+// pathParam returns the unescaped path after prefix.
+func pathParam(r *http.Request, prefix string) (string, error) {
+	return url.PathUnescape(strings.TrimPrefix(r.URL.EscapedPath(), prefix))
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Errorf("%swrite response error: %v", adminLogPrefix, err)
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, map[string]string{"error": msg})
+}
@@ -0,0 +1,94 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/core/router"
+	"github.com/yomorun/yomo/pkg/config"
+)
+
+type mockDownstream struct{ closed bool }
+
+func (d *mockDownstream) WriteFrame(frm frame.Frame) error { return nil }
+
+func (d *mockDownstream) Close() error {
+	d.closed = true
+	return nil
+}
+
+func TestAdminServer(t *testing.T) {
+	server := core.NewServer("zipper")
+	server.ConfigMetadataBuilder(metadata.DefaultBuilder())
+	server.ConfigRouter(router.Default([]config.App{{Name: "sfn-1"}}))
+
+	ds := &mockDownstream{}
+	server.AddDownstreamServer("127.0.0.1:9002", ds)
+
+	handler := NewServer("localhost:0", server, "admin-token")
+
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		wantCode int
+		wantBody string
+	}{
+		{"list connections", http.MethodGet, "/connections", http.StatusOK, "[]\n"},
+		{"disconnect without token", http.MethodDelete, "/connections/127.0.0.1:1234", http.StatusUnauthorized, "{\"error\":\"unauthorized\"}\n"},
+		{"disconnect unknown connection", http.MethodDelete, "/connections/127.0.0.1:1234", http.StatusNotFound, "{\"error\":\"connection not found: 127.0.0.1:1234\"}\n"},
+		{"list downstreams", http.MethodGet, "/downstreams", http.StatusOK, "[{\"addr\":\"127.0.0.1:9002\"}]\n"},
+		{"list routes", http.MethodGet, "/routes", http.StatusOK, "[]\n"},
+		{"stats", http.MethodGet, "/stats", http.StatusOK, "{\"connections\":0,\"downstreams\":1,\"data_frames\":0,\"mesh_dropped\":0}\n"},
+		{"stats method not allowed", http.MethodPost, "/stats", http.StatusMethodNotAllowed, "{\"error\":\"method not allowed\"}\n"},
+		{"remove downstream", http.MethodDelete, "/downstreams/127.0.0.1:9002", http.StatusNoContent, ""},
+		{"dead letter ring disabled", http.MethodGet, "/deadletters", http.StatusNotFound, "{\"error\":\"dead letter ring is disabled\"}\n"},
+		{"remove unknown downstream", http.MethodDelete, "/downstreams/127.0.0.1:9002", http.StatusNotFound, "{\"error\":\"downstream not found: 127.0.0.1:9002\"}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, tt.target, nil)
+			if tt.name != "disconnect without token" {
+				r.Header.Set("Authorization", "Bearer admin-token")
+			}
+			handler.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, tt.wantBody, w.Body.String())
+		})
+	}
+
+	assert.True(t, ds.closed, "downstream should be closed after removed")
+	assert.Empty(t, server.Downstreams())
+}
+
+func TestAdminDeadLetters(t *testing.T) {
+	server := core.NewServer("zipper", core.WithDeadLetterRing(10))
+	handler := NewServer("localhost:0", server, "")
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/deadletters", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "[]\n", w.Body.String())
+}
+
+func TestAdminTokenNotSet(t *testing.T) {
+	server := core.NewServer("zipper")
+	server.AddDownstreamServer("127.0.0.1:9002", &mockDownstream{})
+	handler := NewServer("localhost:0", server, "")
+
+	// the DELETE endpoints are disabled without the admin token.
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/downstreams/127.0.0.1:9002", nil))
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Len(t, server.Downstreams(), 1)
+}
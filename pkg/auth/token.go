@@ -6,9 +6,9 @@ import (
 
 var _ auth.Authentication = (*TokenAuth)(nil)
 
-// TokenAuth token authentication (simple)
+// TokenAuth token authentication (simple), the token is also the identity of client.
 type TokenAuth struct {
-	token string
+	tokens []string
 }
 
 // NewTokenAuth create a token authentication
@@ -16,16 +16,19 @@ func NewTokenAuth() *TokenAuth {
 	return &TokenAuth{}
 }
 
-// Init authentication initialize arguments
+// Init authentication initialize arguments, each argument is an accepted token.
 func (a *TokenAuth) Init(args ...string) {
-	if len(args) > 0 {
-		a.token = args[0]
-	}
+	a.tokens = args
 }
 
 // Authenticate authentication client's credential
 func (a *TokenAuth) Authenticate(payload string) bool {
-	return a.token == payload
+	for _, token := range a.tokens {
+		if token == payload {
+			return true
+		}
+	}
+	return false
 }
 
 // Name authentication name
@@ -19,3 +19,13 @@ func TestToken(t *testing.T) {
 	authed = auth.Authenticate("other-token")
 	assert.False(t, authed)
 }
+
+func TestMultipleTokens(t *testing.T) {
+	auth := NewTokenAuth()
+
+	auth.Init("team-a", "team-b")
+
+	assert.True(t, auth.Authenticate("team-a"))
+	assert.True(t, auth.Authenticate("team-b"))
+	assert.False(t, auth.Authenticate("team-c"))
+}
@@ -0,0 +1,125 @@
+// Package mqtt bridges an MQTT broker to YoMo-Zipper, It is embedded in the zipper by the `mqtt`
+// section of workflow config, or runs standalone by `yomo bridge mqtt`.
+//
+// The messages of the subscribed topic filters are written to zipper as the data of the mapped tags,
+// the data of the published tags, or the results of the data written by the bridge, are published
+// to the mapped topics.
+package mqtt
+
+import (
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/pkg/config"
+	"github.com/yomorun/yomo/pkg/id"
+	"github.com/yomorun/yomo/pkg/logger"
+)
+
+const bridgeLogPrefix = "\033[34m[yomo:mqtt]\033[0m "
+
+// connectTimeout limits the wait for the first connection to the broker, the bridge keeps
+// retrying in background after it, and reconnects automatically once connected.
+const connectTimeout = 10 * time.Second
+
+// Conn is the connection of the bridge to zipper.
+type Conn interface {
+	// Write writes the data of the MQTT message as the tag.
+	Write(tag frame.Tag, data []byte) error
+	// Close closes the connection.
+	Close() error
+}
+
+// Bridge bridges an MQTT broker to zipper.
+type Bridge struct {
+	cfg    *config.MQTTBridge
+	client paho.Client
+	mu     sync.RWMutex
+	conn   Conn
+}
+
+// New creates a bridge by the config.
+func New(cfg *config.MQTTBridge) *Bridge {
+	b := &Bridge{cfg: cfg}
+
+	clientID := cfg.ClientID
+	if clientID == "" {
+		clientID = "yomo-" + id.New()
+	}
+	opts := paho.NewClientOptions().
+		AddBroker(cfg.Broker).
+		SetClientID(clientID).
+		SetUsername(cfg.Username).
+		SetPassword(cfg.Password).
+		SetConnectRetry(true).
+		SetAutoReconnect(true).
+		// the subscriptions are renewed on each reconnection.
+		SetOnConnectHandler(b.subscribe).
+		SetConnectionLostHandler(func(_ paho.Client, err error) {
+			logger.Warnf("%sconnection to broker %s lost: %v", bridgeLogPrefix, cfg.Broker, err)
+		})
+	b.client = paho.NewClient(opts)
+
+	return b
+}
+
+// Start connects the broker, the messages of the subscribed topic filters are written to conn.
+func (b *Bridge) Start(conn Conn) error {
+	b.mu.Lock()
+	b.conn = conn
+	b.mu.Unlock()
+
+	token := b.client.Connect()
+	if !token.WaitTimeout(connectTimeout) {
+		logger.Warnf("%sconnect to broker %s timeout, keep retrying", bridgeLogPrefix, b.cfg.Broker)
+		return nil
+	}
+	if err := token.Error(); err != nil {
+		return err
+	}
+	logger.Printf("%s✅ bridge to broker %s", bridgeLogPrefix, b.cfg.Broker)
+	return nil
+}
+
+func (b *Bridge) getConn() Conn {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.conn
+}
+
+func (b *Bridge) subscribe(client paho.Client) {
+	for _, sub := range b.cfg.Subscribe {
+		sub := sub
+		token := client.Subscribe(sub.Topic, sub.QoS, func(_ paho.Client, msg paho.Message) {
+			logger.Debugf("%s%s -> tag %#x, %d bytes", bridgeLogPrefix, msg.Topic(), sub.Tag, len(msg.Payload()))
+			if err := b.getConn().Write(sub.Tag, msg.Payload()); err != nil {
+				logger.Errorf("%swrite tag %#x of topic %s error: %v", bridgeLogPrefix, sub.Tag, msg.Topic(), err)
+			}
+		})
+		if token.Wait() && token.Error() != nil {
+			logger.Errorf("%ssubscribe %s error: %v", bridgeLogPrefix, sub.Topic, token.Error())
+		}
+	}
+}
+
+// Handle publishes the data received from zipper to the topics of its tag, backflow is true
+// if it is the result of the data written by the bridge. It does not wait for the broker.
+func (b *Bridge) Handle(tag frame.Tag, data []byte, backflow bool) {
+	for _, pub := range b.cfg.Publish {
+		if pub.Tag != tag || pub.Backflow != backflow {
+			continue
+		}
+		logger.Debugf("%stag %#x -> %s, %d bytes", bridgeLogPrefix, tag, pub.Topic, len(data))
+		b.client.Publish(pub.Topic, pub.QoS, pub.Retained, data)
+	}
+}
+
+// Close disconnects the broker and zipper.
+func (b *Bridge) Close() error {
+	b.client.Disconnect(250)
+	if conn := b.getConn(); conn != nil {
+		return conn.Close()
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package mqtt
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yomorun/yomo/core"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/core/router"
+	"github.com/yomorun/yomo/pkg/config"
+)
+
+func TestBridge(t *testing.T) {
+	broker := newBroker(t)
+
+	server := core.NewServer("zipper")
+	server.ConfigMetadataBuilder(metadata.DefaultBuilder())
+	server.ConfigRouter(router.Default([]config.App{{Name: "sfn-1"}}))
+
+	// the stream function responds the upper case of the data of tag 0x33 with tag 0x34.
+	var sfn *core.LocalClient
+	handshake := frame.NewHandshakeFrame("sfn-1", "sfn-1-id", byte(core.ClientTypeStreamFunction), []frame.Tag{0x33}, "", "")
+	sfn, err := server.ConnectLocal(handshake, func(f frame.Frame) {
+		if df, ok := f.(*frame.DataFrame); ok {
+			df.SetCarriage(0x34, bytes.ToUpper(df.GetCarriage()))
+			go sfn.WriteFrame(df)
+		}
+	})
+	require.NoError(t, err)
+	defer sfn.Close()
+
+	bridge := New(&config.MQTTBridge{
+		Broker:    broker.URL(),
+		Subscribe: []config.MQTTSubscription{{Topic: "devices/+/temperature", Tag: 0x33}},
+		Publish: []config.MQTTPublication{
+			{Tag: 0x34, Topic: "results", Backflow: true},
+			{Tag: 0x33, Topic: "mirror"},
+		},
+	})
+	conn, err := DialLocal(server, "mqtt-bridge", bridge)
+	require.NoError(t, err)
+	require.NoError(t, bridge.Start(conn))
+	defer bridge.Close()
+
+	// a device
+	received := make(chan string, 100)
+	device := paho.NewClient(paho.NewClientOptions().AddBroker(broker.URL()).SetClientID("device"))
+	token := device.Connect()
+	require.True(t, token.WaitTimeout(time.Second))
+	require.NoError(t, token.Error())
+	defer device.Disconnect(0)
+
+	for _, topic := range []string{"results", "mirror"} {
+		token := device.Subscribe(topic, 0, func(_ paho.Client, msg paho.Message) {
+			received <- msg.Topic() + ":" + string(msg.Payload())
+		})
+		require.True(t, token.WaitTimeout(time.Second))
+	}
+
+	// the subscriptions of the bridge are done after connected.
+	seen := make(map[string]bool)
+	require.Eventually(t, func() bool {
+		device.Publish("devices/1/temperature", 0, false, "hello").Wait()
+		for {
+			select {
+			case msg := <-received:
+				seen[msg] = true
+			default:
+				return seen["results:HELLO"] && seen["mirror:hello"]
+			}
+		}
+	}, 3*time.Second, 50*time.Millisecond)
+}
+
+func TestMatch(t *testing.T) {
+	assert.True(t, match("devices/+/temperature", "devices/1/temperature"))
+	assert.True(t, match("devices/#", "devices/1/temperature"))
+	assert.False(t, match("devices/+", "devices/1/temperature"))
+	assert.False(t, match("devices/+/humidity", "devices/1/temperature"))
+}
@@ -0,0 +1,164 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// broker is a minimal MQTT 3.1.1 broker embedded in the tests, the messages are delivered at QoS 0.
+type broker struct {
+	ln   net.Listener
+	mu   sync.Mutex
+	subs map[net.Conn][]string
+}
+
+func newBroker(t *testing.T) *broker {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &broker{ln: ln, subs: make(map[net.Conn][]string)}
+	go b.serve()
+	t.Cleanup(func() { ln.Close() })
+	return b
+}
+
+// URL returns the URL of the broker.
+func (b *broker) URL() string {
+	return "tcp://" + b.ln.Addr().String()
+}
+
+func (b *broker) serve() {
+	for {
+		conn, err := b.ln.Accept()
+		if err != nil {
+			return
+		}
+		go b.handle(conn)
+	}
+}
+
+func (b *broker) handle(conn net.Conn) {
+	defer func() {
+		b.mu.Lock()
+		delete(b.subs, conn)
+		b.mu.Unlock()
+		conn.Close()
+	}()
+	r := bufio.NewReader(conn)
+	for {
+		header, body, err := readPacket(r)
+		if err != nil {
+			return
+		}
+		switch header >> 4 {
+		case 1: // CONNECT
+			b.write(conn, 0x20, []byte{0, 0})
+		case 3: // PUBLISH
+			qos := (header >> 1) & 0x03
+			n := int(binary.BigEndian.Uint16(body))
+			topic := string(body[2 : 2+n])
+			payload := body[2+n:]
+			if qos > 0 {
+				b.write(conn, 0x40, payload[:2])
+				payload = payload[2:]
+			}
+			b.publish(topic, payload)
+		case 8: // SUBSCRIBE
+			pid, rest := body[:2], body[2:]
+			var filters []string
+			granted := append([]byte{}, pid...)
+			for len(rest) > 0 {
+				n := int(binary.BigEndian.Uint16(rest))
+				filters = append(filters, string(rest[2:2+n]))
+				rest = rest[3+n:]
+				granted = append(granted, 0)
+			}
+			b.mu.Lock()
+			b.subs[conn] = append(b.subs[conn], filters...)
+			b.mu.Unlock()
+			b.write(conn, 0x90, granted)
+		case 10: // UNSUBSCRIBE
+			b.write(conn, 0xB0, body[:2])
+		case 12: // PINGREQ
+			b.write(conn, 0xD0, nil)
+		case 14: // DISCONNECT
+			return
+		}
+	}
+}
+
+func (b *broker) publish(topic string, payload []byte) {
+	body := make([]byte, 2, 2+len(topic)+len(payload))
+	binary.BigEndian.PutUint16(body, uint16(len(topic)))
+	body = append(append(body, topic...), payload...)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for conn, filters := range b.subs {
+		for _, filter := range filters {
+			if match(filter, topic) {
+				b.write(conn, 0x30, body)
+				break
+			}
+		}
+	}
+}
+
+func (b *broker) write(conn net.Conn, header byte, body []byte) {
+	buf := []byte{header}
+	n := len(body)
+	for {
+		digit := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			digit |= 0x80
+		}
+		buf = append(buf, digit)
+		if n == 0 {
+			break
+		}
+	}
+	conn.Write(append(buf, body...))
+}
+
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	n, multiplier := 0, 1
+	for {
+		digit, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		n += int(digit&0x7F) * multiplier
+		multiplier *= 128
+		if digit&0x80 == 0 {
+			break
+		}
+	}
+	body := make([]byte, n)
+	_, err = io.ReadFull(r, body)
+	return header, body, err
+}
+
+// match returns true if the topic matches the filter with the wildcards `+` and `#`.
+func match(filter, topic string) bool {
+	fs, ts := strings.Split(filter, "/"), strings.Split(topic, "/")
+	for i, f := range fs {
+		if f == "#" {
+			return true
+		}
+		if i >= len(ts) || (f != "+" && f != ts[i]) {
+			return false
+		}
+	}
+	return len(fs) == len(ts)
+}
@@ -0,0 +1,45 @@
+package mqtt
+
+import (
+	"github.com/yomorun/yomo/core"
+	"github.com/yomorun/yomo/core/auth"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/pkg/id"
+)
+
+// localConn connects the bridge embedded in the zipper.
+type localConn struct {
+	client   *core.LocalClient
+	sourceID string
+}
+
+// DialLocal connects the bridge to the zipper in process as a source subscribing the published tags,
+// the data of them and the results of the data written by the bridge are handled by Bridge.Handle.
+func DialLocal(server *core.Server, name string, b *Bridge) (Conn, error) {
+	cred := auth.NewCredential(b.cfg.Credential)
+	sourceID := id.New()
+	handshake := frame.NewHandshakeFrame(name, sourceID, byte(core.ClientTypeSource), b.cfg.PublishTags(), cred.Name(), cred.Payload())
+	client, err := server.Subscribe(handshake, func(f frame.Frame) {
+		switch f := f.(type) {
+		case *frame.DataFrame:
+			b.Handle(f.GetDataTag(), f.GetCarriage(), false)
+		case *frame.BackflowFrame:
+			b.Handle(f.GetDataTag(), f.GetCarriage(), true)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &localConn{client: client, sourceID: sourceID}, nil
+}
+
+func (c *localConn) Write(tag frame.Tag, data []byte) error {
+	f := frame.NewDataFrame()
+	f.SetCarriage(tag, data)
+	f.SetSourceID(c.sourceID)
+	return c.client.WriteFrame(f)
+}
+
+func (c *localConn) Close() error {
+	return c.client.Close()
+}
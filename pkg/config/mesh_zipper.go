@@ -1,9 +1,58 @@
 package config
 
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/yomorun/yomo/core/frame"
+)
+
 // MeshZipper describes mesh configurations.
 type MeshZipper struct {
 	Name       string `json:"name"`
 	Host       string `json:"host"`
 	Port       int    `json:"port"`
 	Credential string `json:"credential,omitempty"`
+	// Allow is the data tags forwarded to the zipper, all the tags are forwarded if it is empty.
+	Allow []frame.Tag `json:"allow,omitempty"`
+	// Deny is the data tags not forwarded to the zipper, It takes precedence over Allow.
+	Deny []frame.Tag `json:"deny,omitempty"`
+}
+
+// Addr returns the address of the zipper.
+func (m MeshZipper) Addr() string {
+	return fmt.Sprintf("%s:%d", m.Host, m.Port)
+}
+
+// LoadMeshConfig loads the mesh config, the location is an HTTP(S) URL or a local file path.
+func LoadMeshConfig(location string) ([]MeshZipper, error) {
+	var r io.Reader
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		res, err := http.Get(location)
+		if err != nil {
+			return nil, err
+		}
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("config: download mesh config %s: %s", location, res.Status)
+		}
+		r = res.Body
+	} else {
+		f, err := os.Open(location)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var configs []MeshZipper
+	if err := json.NewDecoder(r).Decode(&configs); err != nil {
+		return nil, err
+	}
+	return configs, nil
 }
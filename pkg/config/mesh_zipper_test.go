@@ -0,0 +1,57 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yomorun/yomo/core/frame"
+)
+
+const meshJSON = `[
+  {"name": "us", "host": "us.example.com", "port": 9000},
+  {"name": "eu", "host": "eu.example.com", "port": 9000, "credential": "token:eu", "allow": [51, 52], "deny": [53]}
+]`
+
+func TestLoadMeshConfig(t *testing.T) {
+	expected := []MeshZipper{
+		{Name: "us", Host: "us.example.com", Port: 9000},
+		{Name: "eu", Host: "eu.example.com", Port: 9000, Credential: "token:eu", Allow: []frame.Tag{0x33, 0x34}, Deny: []frame.Tag{0x35}},
+	}
+
+	t.Run("file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "mesh.json")
+		require.NoError(t, os.WriteFile(path, []byte(meshJSON), 0o644))
+
+		configs, err := LoadMeshConfig(path)
+		require.NoError(t, err)
+		assert.Equal(t, expected, configs)
+		assert.Equal(t, "eu.example.com:9000", configs[1].Addr())
+	})
+
+	t.Run("url", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(meshJSON))
+		}))
+		defer srv.Close()
+
+		configs, err := LoadMeshConfig(srv.URL)
+		require.NoError(t, err)
+		assert.Equal(t, expected, configs)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		srv := httptest.NewServer(http.NotFoundHandler())
+		defer srv.Close()
+
+		_, err := LoadMeshConfig(srv.URL)
+		assert.Error(t, err)
+
+		_, err = LoadMeshConfig(filepath.Join(t.TempDir(), "none.json"))
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/yomorun/yomo/core/frame"
+	"gopkg.in/yaml.v3"
+)
+
+// MQTTBridge represents the bridge between an MQTT broker and zipper, the messages of the subscribed
+// topic filters are written as the data of tags, the data of tags are published to topics.
+type MQTTBridge struct {
+	// Broker is the URL of the MQTT broker, e.g. `tcp://localhost:1883`.
+	Broker string `yaml:"broker"`
+	// ClientID is the MQTT client id, It is generated if empty.
+	ClientID string `yaml:"client_id,omitempty"`
+	// Username and Password authenticate the bridge to the broker.
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+	// Credential authenticates the bridge to zipper, e.g. `token:yomo`.
+	Credential string `yaml:"credential,omitempty"`
+	// Subscribe maps the topic filters to the data tags written to zipper.
+	Subscribe []MQTTSubscription `yaml:"subscribe,omitempty"`
+	// Publish maps the data tags received from zipper to the topics.
+	Publish []MQTTPublication `yaml:"publish,omitempty"`
+}
+
+// MQTTSubscription represents the messages of the topic filter are written as the data of tag.
+type MQTTSubscription struct {
+	// Topic is the topic filter, the wildcards `+` and `#` are allowed.
+	Topic string    `yaml:"topic"`
+	Tag   frame.Tag `yaml:"tag"`
+	QoS   byte      `yaml:"qos,omitempty"`
+}
+
+// MQTTPublication represents the data of tag are published to topic.
+type MQTTPublication struct {
+	Tag      frame.Tag `yaml:"tag"`
+	Topic    string    `yaml:"topic"`
+	QoS      byte      `yaml:"qos,omitempty"`
+	Retained bool      `yaml:"retained,omitempty"`
+	// Backflow publishes only the results of the data written by the bridge,
+	// otherwise all the data of tag are published.
+	Backflow bool `yaml:"backflow,omitempty"`
+}
+
+// LoadMQTTBridgeConfig loads the MQTTBridge config by path.
+func LoadMQTTBridgeConfig(path string) (*MQTTBridge, error) {
+	buffer, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	config := &MQTTBridge{}
+	if err := yaml.Unmarshal(buffer, config); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Validate validates the MQTTBridge config.
+func (c *MQTTBridge) Validate() error {
+	if c.Broker == "" {
+		return errors.New("mqtt: missing broker")
+	}
+	for _, sub := range c.Subscribe {
+		if sub.Topic == "" {
+			return fmt.Errorf("mqtt: missing topic of subscribed tag %#x", sub.Tag)
+		}
+		if sub.QoS > 2 {
+			return fmt.Errorf("mqtt: invalid qos %d of topic %s", sub.QoS, sub.Topic)
+		}
+	}
+	for _, pub := range c.Publish {
+		if pub.Topic == "" {
+			return fmt.Errorf("mqtt: missing topic of published tag %#x", pub.Tag)
+		}
+		if pub.QoS > 2 {
+			return fmt.Errorf("mqtt: invalid qos %d of topic %s", pub.QoS, pub.Topic)
+		}
+	}
+	return nil
+}
+
+// PublishTags returns the data tags published to MQTT, they are observed by the bridge.
+func (c *MQTTBridge) PublishTags() []frame.Tag {
+	var tags []frame.Tag
+	seen := make(map[frame.Tag]bool)
+	for _, pub := range c.Publish {
+		if !seen[pub.Tag] {
+			seen[pub.Tag] = true
+			tags = append(tags, pub.Tag)
+		}
+	}
+	return tags
+}
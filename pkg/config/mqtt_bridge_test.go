@@ -0,0 +1,46 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yomorun/yomo/core/frame"
+)
+
+func TestLoadMQTTBridgeConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mqtt.yaml")
+	data := `broker: tcp://localhost:1883
+credential: token:yomo
+subscribe:
+  - topic: devices/#
+    tag: 0x33
+    qos: 1
+publish:
+  - tag: 0x34
+    topic: results
+    backflow: true
+  - tag: 0x34
+    topic: all
+  - tag: 0x35
+    topic: others
+    retained: true`
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o666))
+
+	got, err := LoadMQTTBridgeConfig(path)
+
+	require.NoError(t, err)
+	assert.Equal(t, "token:yomo", got.Credential)
+	assert.Equal(t, []MQTTSubscription{{Topic: "devices/#", Tag: 0x33, QoS: 1}}, got.Subscribe)
+	assert.Equal(t, []frame.Tag{0x34, 0x35}, got.PublishTags())
+}
+
+func TestValidateMQTTBridge(t *testing.T) {
+	assert.EqualError(t, (&MQTTBridge{}).Validate(), "mqtt: missing broker")
+	assert.EqualError(t, (&MQTTBridge{
+		Broker:    "tcp://localhost:1883",
+		Subscribe: []MQTTSubscription{{Topic: "devices/#", Tag: 0x33, QoS: 3}},
+	}).Validate(), "mqtt: invalid qos 3 of topic devices/#")
+}
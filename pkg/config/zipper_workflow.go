@@ -2,23 +2,95 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
+	"github.com/yomorun/yomo/core/frame"
 	"gopkg.in/yaml.v3"
 )
 
+// Load balancing strategies of the stream function instances.
+const (
+	// LoadBalanceRoundRobin picks the instances in turn.
+	LoadBalanceRoundRobin = "round-robin"
+	// LoadBalanceLeastInflight picks the instance which has the fewest frames in flight.
+	LoadBalanceLeastInflight = "least-inflight"
+	// LoadBalanceConsistentHash picks the instance by consistent hashing on the hash key.
+	LoadBalanceConsistentHash = "consistent-hash"
+)
+
+// Hash keys of the consistent hashing load balancing.
+const (
+	// HashKeySourceID hashes on the source ID of the data frame.
+	HashKeySourceID = "source_id"
+	// HashKeyTransactionID hashes on the transaction ID of the data frame.
+	HashKeyTransactionID = "transaction_id"
+)
+
+// Keys of the rate limits.
+const (
+	// RateLimitByName limits the frames by the name of client.
+	RateLimitByName = "name"
+	// RateLimitByClientID limits the frames by the id of client.
+	RateLimitByClientID = "client_id"
+	// RateLimitByTag limits the frames by the data tag.
+	RateLimitByTag = "tag"
+	// RateLimitByIdentity limits the frames by the identity of client credential.
+	RateLimitByIdentity = "identity"
+	// RateLimitByTenant limits the frames by the tenant of client.
+	RateLimitByTenant = "tenant"
+)
+
+// Policies of the frames over the rate limit.
+const (
+	// RateLimitPolicyDrop drops the frames over the limit.
+	RateLimitPolicyDrop = "drop"
+	// RateLimitPolicyDelay delays the frames over the limit until the tokens are refilled.
+	RateLimitPolicyDelay = "delay"
+	// RateLimitPolicyDisconnect disconnects the client sending the frames over the limit.
+	RateLimitPolicyDisconnect = "disconnect"
+)
+
 // App represents a YoMo Application.
 type App struct {
 	Name string `yaml:"name"`
+	// LoadBalance enables multiple instances of the stream function, each data frame
+	// is sent to only one instance which is picked by the strategy,
+	// It can be `round-robin`, `least-inflight` or `consistent-hash`.
+	LoadBalance string `yaml:"load_balance,omitempty"`
+	// HashKey is the key of `consistent-hash` load balancing,
+	// It can be `source_id` (default) or `transaction_id`.
+	HashKey string `yaml:"hash_key,omitempty"`
+	// Input is the data tags observed by the stream function in the pipeline,
+	// the stream function must observe exactly these tags if it is set.
+	Input []frame.Tag `yaml:"input,omitempty"`
+	// Output is the data tags emitted by the stream function in the pipeline.
+	Output []frame.Tag `yaml:"output,omitempty"`
+	// StoreAndForward buffers the data of input tags on disk while the stream function is offline,
+	// and replays them after it reconnects, It requires input tags.
+	StoreAndForward bool `yaml:"store_and_forward,omitempty"`
 }
 
 // Workflow represents a YoMo Workflow.
 type Workflow struct {
+	// Functions are the stream functions, If they declare input or output tags,
+	// they form a sequential pipeline in the order of the list.
 	Functions []App `yaml:"functions"`
 }
 
+// IsPipeline returns true if any function of the workflow declares input or output tags.
+func (w Workflow) IsPipeline() bool {
+	for _, app := range w.Functions {
+		if len(app.Input) > 0 || len(app.Output) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
 // WorkflowConfig represents a YoMo Workflow config.
 type WorkflowConfig struct {
 	// Name represents the name of the zipper.
@@ -29,6 +101,47 @@ type WorkflowConfig struct {
 	Port int `yaml:"port"`
 	// Workflow represents the sfn workflow.
 	Workflow `yaml:",inline"`
+	// ACL represents the data tags each identity may publish and observe,
+	// all data tags are allowed if it is empty.
+	ACL []ACLRule `yaml:"acl,omitempty"`
+	// Tenants isolate the clients of zipper, the data of a tenant is only routed to
+	// the stream functions of the same tenant, Clients not in any tenant share the default one.
+	Tenants []Tenant `yaml:"tenants,omitempty"`
+	// RateLimits limit the DataFrames received by zipper with token buckets,
+	// a DataFrame must be allowed by all the matched rate limits.
+	RateLimits []RateLimit `yaml:"rate_limits,omitempty"`
+	// MQTT bridges an MQTT broker to the zipper, It is disabled if empty.
+	MQTT *MQTTBridge `yaml:"mqtt,omitempty"`
+}
+
+// RateLimit represents a token bucket rate limit of DataFrames.
+type RateLimit struct {
+	// By is the key of the rate limit, It can be `name`, `client_id`, `tag`, `identity` or `tenant`.
+	By string `yaml:"by"`
+	// Value is the value of key the rate limit applies to, `*` or empty applies to
+	// every value with its own token bucket.
+	Value string `yaml:"value,omitempty"`
+	// Rate is the number of DataFrames per second.
+	Rate float64 `yaml:"rate"`
+	// Burst is the size of token bucket, it is the integer part of rate if not set.
+	Burst int `yaml:"burst,omitempty"`
+	// Policy is what to do with the DataFrames over the limit,
+	// It can be `drop` (default), `delay` or `disconnect`.
+	Policy string `yaml:"policy,omitempty"`
+}
+
+// Tenant represents the identities belong to a tenant, the identity is the credential of client.
+type Tenant struct {
+	Name       string   `yaml:"name"`
+	Identities []string `yaml:"identities"`
+}
+
+// ACLRule represents the data tags an identity may publish and observe, the identity is
+// the credential of client, e.g. the token, `*` matches the identities without their own rule.
+type ACLRule struct {
+	Identity string      `yaml:"identity"`
+	Publish  []frame.Tag `yaml:"publish,omitempty"`
+	Observe  []frame.Tag `yaml:"observe,omitempty"`
 }
 
 // ErrWorkflowConfigExt represents the extension of workflow config is incorrect.
@@ -91,6 +204,50 @@ func validateWorkflowConfig(wfConf *WorkflowConfig) error {
 		}
 	}
 
+	for _, app := range wfConf.Functions {
+		if err := validateLoadBalance(app); err != nil {
+			return err
+		}
+		if app.StoreAndForward && len(app.Input) == 0 {
+			return fmt.Errorf("workflow: store_and_forward of function %s requires input", app.Name)
+		}
+	}
+
+	if err := validatePipeline(wfConf.Workflow); err != nil {
+		return err
+	}
+
+	identities := map[string]string{}
+	for _, tenant := range wfConf.Tenants {
+		if tenant.Name == "" {
+			return errors.New("workflow: missing name of tenant")
+		}
+		for _, identity := range tenant.Identities {
+			if other, ok := identities[identity]; ok && other != tenant.Name {
+				return fmt.Errorf("workflow: identity %s belongs to both tenant %s and %s", identity, other, tenant.Name)
+			}
+			identities[identity] = tenant.Name
+		}
+	}
+
+	for _, rule := range wfConf.ACL {
+		if rule.Identity == "" {
+			return errors.New("workflow: missing identity in acl")
+		}
+	}
+
+	for _, limit := range wfConf.RateLimits {
+		if err := validateRateLimit(limit); err != nil {
+			return err
+		}
+	}
+
+	if wfConf.MQTT != nil {
+		if err := wfConf.MQTT.Validate(); err != nil {
+			return err
+		}
+	}
+
 	errMsg := ""
 	if wfConf.Name == "" || wfConf.Host == "" || wfConf.Port <= 0 {
 		errMsg = "Missing name, host or port in workflow config. "
@@ -106,3 +263,79 @@ func validateWorkflowConfig(wfConf *WorkflowConfig) error {
 
 	return nil
 }
+
+func validateLoadBalance(app App) error {
+	switch app.LoadBalance {
+	case "", LoadBalanceRoundRobin, LoadBalanceLeastInflight, LoadBalanceConsistentHash:
+	default:
+		return fmt.Errorf("workflow: unknown load_balance of function %s: %s", app.Name, app.LoadBalance)
+	}
+	switch app.HashKey {
+	case "", HashKeySourceID, HashKeyTransactionID:
+	default:
+		return fmt.Errorf("workflow: unknown hash_key of function %s: %s", app.Name, app.HashKey)
+	}
+	return nil
+}
+
+func validateRateLimit(limit RateLimit) error {
+	switch limit.By {
+	case RateLimitByName, RateLimitByClientID, RateLimitByIdentity, RateLimitByTenant:
+	case RateLimitByTag:
+		if limit.Value != "" && limit.Value != "*" {
+			if _, err := strconv.ParseUint(limit.Value, 0, 32); err != nil {
+				return fmt.Errorf("workflow: invalid tag of rate limit: %s", limit.Value)
+			}
+		}
+	default:
+		return fmt.Errorf("workflow: unknown key of rate limit: %s", limit.By)
+	}
+	if limit.Rate <= 0 || limit.Burst < 0 {
+		return fmt.Errorf("workflow: rate and burst of rate limit by %s must be positive", limit.By)
+	}
+	switch limit.Policy {
+	case "", RateLimitPolicyDrop, RateLimitPolicyDelay, RateLimitPolicyDisconnect:
+	default:
+		return fmt.Errorf("workflow: unknown policy of rate limit: %s", limit.Policy)
+	}
+	return nil
+}
+
+// validatePipeline checks the functions are chained in order: every function declares input tags,
+// each function except the first one observes at least one output tag of the previous function,
+// and no function emits the input tags of itself or the functions before it.
+func validatePipeline(wf Workflow) error {
+	if !wf.IsPipeline() {
+		return nil
+	}
+
+	upstream := map[frame.Tag]bool{}
+	for i, app := range wf.Functions {
+		if len(app.Input) == 0 {
+			return fmt.Errorf("workflow: missing input of function %s in pipeline", app.Name)
+		}
+		if i > 0 && !containsAny(wf.Functions[i-1].Output, app.Input) {
+			return fmt.Errorf("workflow: input of function %s does not match output of function %s", app.Name, wf.Functions[i-1].Name)
+		}
+		for _, tag := range app.Input {
+			upstream[tag] = true
+		}
+		for _, tag := range app.Output {
+			if upstream[tag] {
+				return fmt.Errorf("workflow: output tag %d of function %s loops back in pipeline", tag, app.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func containsAny(tags []frame.Tag, targets []frame.Tag) bool {
+	for _, tag := range tags {
+		for _, target := range targets {
+			if tag == target {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core/frame"
 )
 
 func TestParseWorkflowConfig(t *testing.T) {
@@ -45,6 +46,321 @@ functions:
 			wantErr:       false,
 			wantErrString: "",
 		},
+		{
+			name: "load balance config",
+			args: args{
+				ext: ".yaml",
+				data: `name: Service
+host: localhost
+port: 9000
+functions:
+  - name: Noise
+    load_balance: consistent-hash
+    hash_key: transaction_id`,
+			},
+			want: &WorkflowConfig{
+				Name: "Service",
+				Host: "localhost",
+				Port: 9000,
+				Workflow: Workflow{
+					Functions: []App{
+						{Name: "Noise", LoadBalance: LoadBalanceConsistentHash, HashKey: HashKeyTransactionID},
+					},
+				}},
+			wantErr:       false,
+			wantErrString: "",
+		},
+		{
+			name: "unknown load balance",
+			args: args{
+				ext: ".yaml",
+				data: `name: Service
+host: localhost
+port: 9000
+functions:
+  - name: Noise
+    load_balance: random`,
+			},
+			want:          nil,
+			wantErr:       true,
+			wantErrString: "workflow: unknown load_balance of function Noise: random",
+		},
+		{
+			name: "pipeline config",
+			args: args{
+				ext: ".yaml",
+				data: `name: Service
+host: localhost
+port: 9000
+functions:
+  - name: Noise
+    input: [1]
+    output: [2]
+  - name: Noise2
+    input: [2]
+    output: [3, 4]`,
+			},
+			want: &WorkflowConfig{
+				Name: "Service",
+				Host: "localhost",
+				Port: 9000,
+				Workflow: Workflow{
+					Functions: []App{
+						{Name: "Noise", Input: []frame.Tag{1}, Output: []frame.Tag{2}},
+						{Name: "Noise2", Input: []frame.Tag{2}, Output: []frame.Tag{3, 4}},
+					},
+				}},
+			wantErr:       false,
+			wantErrString: "",
+		},
+		{
+			name: "pipeline missing input",
+			args: args{
+				ext: ".yaml",
+				data: `name: Service
+host: localhost
+port: 9000
+functions:
+  - name: Noise
+    output: [2]`,
+			},
+			want:          nil,
+			wantErr:       true,
+			wantErrString: "workflow: missing input of function Noise in pipeline",
+		},
+		{
+			name: "pipeline input mismatch",
+			args: args{
+				ext: ".yaml",
+				data: `name: Service
+host: localhost
+port: 9000
+functions:
+  - name: Noise
+    input: [1]
+    output: [2]
+  - name: Noise2
+    input: [3]`,
+			},
+			want:          nil,
+			wantErr:       true,
+			wantErrString: "workflow: input of function Noise2 does not match output of function Noise",
+		},
+		{
+			name: "pipeline loop",
+			args: args{
+				ext: ".yaml",
+				data: `name: Service
+host: localhost
+port: 9000
+functions:
+  - name: Noise
+    input: [1]
+    output: [2]
+  - name: Noise2
+    input: [2]
+    output: [1]`,
+			},
+			want:          nil,
+			wantErr:       true,
+			wantErrString: "workflow: output tag 1 of function Noise2 loops back in pipeline",
+		},
+		{
+			name: "acl config",
+			args: args{
+				ext: ".yaml",
+				data: `name: Service
+host: localhost
+port: 9000
+functions:
+  - name: Noise
+acl:
+  - identity: team-a
+    publish: [1]
+    observe: [1, 2]`,
+			},
+			want: &WorkflowConfig{
+				Name: "Service",
+				Host: "localhost",
+				Port: 9000,
+				Workflow: Workflow{
+					Functions: []App{{Name: "Noise"}},
+				},
+				ACL: []ACLRule{
+					{Identity: "team-a", Publish: []frame.Tag{1}, Observe: []frame.Tag{1, 2}},
+				},
+			},
+			wantErr:       false,
+			wantErrString: "",
+		},
+		{
+			name: "acl missing identity",
+			args: args{
+				ext: ".yaml",
+				data: `name: Service
+host: localhost
+port: 9000
+functions:
+  - name: Noise
+acl:
+  - publish: [1]`,
+			},
+			want:          nil,
+			wantErr:       true,
+			wantErrString: "workflow: missing identity in acl",
+		},
+		{
+			name: "tenants config",
+			args: args{
+				ext: ".yaml",
+				data: `name: Service
+host: localhost
+port: 9000
+functions:
+  - name: Noise
+tenants:
+  - name: team-a
+    identities: [token-a1, token-a2]
+  - name: team-b
+    identities: [token-b]`,
+			},
+			want: &WorkflowConfig{
+				Name: "Service",
+				Host: "localhost",
+				Port: 9000,
+				Workflow: Workflow{
+					Functions: []App{{Name: "Noise"}},
+				},
+				Tenants: []Tenant{
+					{Name: "team-a", Identities: []string{"token-a1", "token-a2"}},
+					{Name: "team-b", Identities: []string{"token-b"}},
+				},
+			},
+			wantErr:       false,
+			wantErrString: "",
+		},
+		{
+			name: "identity in multiple tenants",
+			args: args{
+				ext: ".yaml",
+				data: `name: Service
+host: localhost
+port: 9000
+functions:
+  - name: Noise
+tenants:
+  - name: team-a
+    identities: [token-a]
+  - name: team-b
+    identities: [token-a]`,
+			},
+			want:          nil,
+			wantErr:       true,
+			wantErrString: "workflow: identity token-a belongs to both tenant team-a and team-b",
+		},
+		{
+			name: "store and forward without input",
+			args: args{
+				ext: ".yaml",
+				data: `name: Service
+host: localhost
+port: 9000
+functions:
+  - name: Noise
+    store_and_forward: true`,
+			},
+			want:          nil,
+			wantErr:       true,
+			wantErrString: "workflow: store_and_forward of function Noise requires input",
+		},
+		{
+			name: "unknown policy of rate limit",
+			args: args{
+				ext: ".yaml",
+				data: `name: Service
+host: localhost
+port: 9000
+functions:
+  - name: Noise
+rate_limits:
+  - by: name
+    rate: 100
+    policy: block`,
+			},
+			want:          nil,
+			wantErr:       true,
+			wantErrString: "workflow: unknown policy of rate limit: block",
+		},
+		{
+			name: "invalid tag of rate limit",
+			args: args{
+				ext: ".yaml",
+				data: `name: Service
+host: localhost
+port: 9000
+functions:
+  - name: Noise
+rate_limits:
+  - by: tag
+    value: noise
+    rate: 100`,
+			},
+			want:          nil,
+			wantErr:       true,
+			wantErrString: "workflow: invalid tag of rate limit: noise",
+		},
+		{
+			name: "mqtt bridge",
+			args: args{
+				ext: ".yaml",
+				data: `name: Service
+host: localhost
+port: 9000
+functions:
+  - name: Noise
+mqtt:
+  broker: tcp://localhost:1883
+  subscribe:
+    - topic: devices/+/temperature
+      tag: 0x33
+  publish:
+    - tag: 0x34
+      topic: results
+      backflow: true`,
+			},
+			want: &WorkflowConfig{
+				Name: "Service",
+				Host: "localhost",
+				Port: 9000,
+				Workflow: Workflow{
+					Functions: []App{{Name: "Noise"}},
+				},
+				MQTT: &MQTTBridge{
+					Broker:    "tcp://localhost:1883",
+					Subscribe: []MQTTSubscription{{Topic: "devices/+/temperature", Tag: 0x33}},
+					Publish:   []MQTTPublication{{Tag: 0x34, Topic: "results", Backflow: true}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "mqtt bridge missing topic",
+			args: args{
+				ext: ".yaml",
+				data: `name: Service
+host: localhost
+port: 9000
+functions:
+  - name: Noise
+mqtt:
+  broker: tcp://localhost:1883
+  publish:
+    - tag: 0x34`,
+			},
+			want:          nil,
+			wantErr:       true,
+			wantErrString: "mqtt: missing topic of published tag 0x34",
+		},
 		{
 			name: "not yaml extension",
 			args: args{
@@ -0,0 +1,56 @@
+// Package deadletter provides the destinations of the dead letters of YoMo-Zipper.
+package deadletter
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/yomorun/yomo/core"
+	"github.com/yomorun/yomo/pkg/logger"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const deadLetterLogPrefix = "\033[31m[yomo:deadletter]\033[0m "
+
+// File writes the dead letters to a local file, one JSON per line. The file is rotated
+// when it reaches maxSize megabytes, at most maxBackups rotated files are kept.
+type File struct {
+	mu sync.Mutex
+	w  *lumberjack.Logger
+}
+
+// NewFile creates a File writing to filename.
+func NewFile(filename string, maxSize, maxBackups int) *File {
+	return &File{
+		w: &lumberjack.Logger{
+			Filename:   filename,
+			MaxSize:    maxSize,
+			MaxBackups: maxBackups,
+		},
+	}
+}
+
+// Handle writes the dead letter to file, It implements `core.DeadLetterHandler`.
+func (f *File) Handle(d *core.DeadLetter) {
+	buf, err := json.Marshal(d)
+	if err != nil {
+		logger.Errorf("%sencode dead letter error: %v", deadLetterLogPrefix, err)
+		return
+	}
+	buf = append(buf, '\n')
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, err := f.w.Write(buf); err != nil {
+		logger.Errorf("%swrite dead letter error: %v", deadLetterLogPrefix, err)
+	}
+}
+
+// Close closes the file.
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.w.Close()
+}
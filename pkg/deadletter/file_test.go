@@ -0,0 +1,38 @@
+package deadletter
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core"
+)
+
+func TestFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "deadletter.log")
+	f := NewFile(filename, 1, 1)
+
+	f.Handle(&core.DeadLetter{Reason: core.DeadLetterNoRoute, Tag: 1, TransactionID: "tid-1", Carriage: []byte("hello")})
+	f.Handle(&core.DeadLetter{Reason: core.DeadLetterWriteFailed, Tag: 2, TransactionID: "tid-2", ConnID: "conn-1"})
+	assert.NoError(t, f.Close())
+
+	file, err := os.Open(filename)
+	assert.NoError(t, err)
+	defer file.Close()
+
+	letters := []core.DeadLetter{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var d core.DeadLetter
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &d))
+		letters = append(letters, d)
+	}
+
+	assert.Len(t, letters, 2)
+	assert.Equal(t, core.DeadLetterNoRoute, letters[0].Reason)
+	assert.Equal(t, []byte("hello"), letters[0].Carriage)
+	assert.Equal(t, "conn-1", letters[1].ConnID)
+}
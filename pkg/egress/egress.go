@@ -0,0 +1,231 @@
+// Package egress provides the embedded WebSocket and Server-Sent-Events endpoints of YoMo-Zipper,
+// so the browsers subscribe the data of tags, e.g. the results of stream functions, without a Go client.
+//
+// The endpoints are:
+//
+//	GET /ws?tags={tags}   subscribe the data tags by WebSocket, e.g. `/ws?tags=0x33,0x34`
+//	GET /sse?tags={tags}  subscribe the data tags by Server-Sent-Events
+//
+// With `&tid={tid}`, only the data of the transaction is subscribed, e.g. the results of the one
+// started through the HTTP ingress. Each data is a JSON Message, the WebSocket sends it as a text message,
+// the SSE sends it as an event named `data` with the transaction id as the event id.
+//
+// The credential is authenticated by the auth plugins of zipper, see auth.NewHTTPCredential, the browsers
+// pass it by the query `credential` as they cannot set the headers. The endpoints accept any origin
+// as no cookie is used, the credential must be passed explicitly.
+package egress
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/websocket"
+	"github.com/yomorun/yomo/core"
+	"github.com/yomorun/yomo/core/auth"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/pkg/id"
+	"github.com/yomorun/yomo/pkg/logger"
+)
+
+const egressLogPrefix = "\033[36m[yomo:egress]\033[0m "
+
+// bufferSize is the number of the messages buffered for each subscriber,
+// the messages are dropped if the subscriber is slower than the data.
+const bufferSize = 64
+
+// Message is the data sent to the subscribers.
+type Message struct {
+	Tag           frame.Tag `json:"tag"`
+	TransactionID string    `json:"tid"`
+	Data          []byte    `json:"data"`
+}
+
+// Server is the egress server of a YoMo-Zipper.
+type Server struct {
+	server     *core.Server
+	httpServer *http.Server
+	mux        *http.ServeMux
+	upgrader   websocket.Upgrader
+}
+
+// NewServer creates an egress server for the zipper's underlying server.
+func NewServer(addr string, server *core.Server) *Server {
+	s := &Server{
+		server: server,
+		mux:    http.NewServeMux(),
+		upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	}
+	s.mux.HandleFunc("/ws", s.handleWebSocket)
+	s.mux.HandleFunc("/sse", s.handleSSE)
+	s.httpServer = &http.Server{Addr: addr, Handler: s}
+
+	return s
+}
+
+// ListenAndServe starts the egress server.
+func (s *Server) ListenAndServe() error {
+	logger.Printf("%s✅ WebSocket/SSE egress listening on: %s", egressLogPrefix, s.httpServer.Addr)
+	err := s.httpServer.ListenAndServe()
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+// Close shuts down the egress server, the subscriptions are closed with the connections.
+func (s *Server) Close() error {
+	return s.httpServer.Shutdown(context.Background())
+}
+
+// ServeHTTP implements http.Handler interface.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// subscription receives the messages of the subscribed tags.
+type subscription struct {
+	client   *core.LocalClient
+	messages chan *Message
+}
+
+// subscribe subscribes the data tags of the request.
+func (s *Server) subscribe(r *http.Request) (*subscription, int, error) {
+	if r.Method != http.MethodGet {
+		return nil, http.StatusMethodNotAllowed, errors.New("method not allowed")
+	}
+	query := r.URL.Query()
+	tags, err := parseTags(query.Get("tags"))
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	tid := query.Get("tid")
+
+	sub := &subscription{messages: make(chan *Message, bufferSize)}
+	cred := auth.NewHTTPCredential(r)
+	handshake := frame.NewHandshakeFrame("egress", id.New(), byte(core.ClientTypeSource), tags, cred.Name(), cred.Payload())
+	client, err := s.server.Subscribe(handshake, func(f frame.Frame) {
+		df, ok := f.(*frame.DataFrame)
+		if !ok || (tid != "" && df.TransactionID() != tid) {
+			return
+		}
+		select {
+		case sub.messages <- &Message{Tag: df.GetDataTag(), TransactionID: df.TransactionID(), Data: df.GetCarriage()}:
+		default:
+			logger.Warnf("%s[%s] subscriber is slow, drop the data of tag %#x", egressLogPrefix, r.RemoteAddr, df.GetDataTag())
+		}
+	})
+	if err != nil {
+		return nil, http.StatusUnauthorized, err
+	}
+	sub.client = client
+	logger.Debugf("%s[%s] subscribe tags %v, tid=%s", egressLogPrefix, r.RemoteAddr, tags, tid)
+	return sub, http.StatusOK, nil
+}
+
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	sub, code, err := s.subscribe(r)
+	if err != nil {
+		writeError(w, code, err.Error())
+		return
+	}
+	defer sub.client.Close()
+
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logger.Warnf("%sWebSocket upgrade %s error: %v", egressLogPrefix, r.RemoteAddr, err)
+		return
+	}
+	defer conn.Close()
+
+	// the messages from the browser are discarded, the read fails when the connection is closed.
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case msg := <-sub.messages:
+			if err := conn.WriteJSON(msg); err != nil {
+				logger.Debugf("%s[%s] WebSocket write error: %v", egressLogPrefix, r.RemoteAddr, err)
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+func (s *Server) handleSSE(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+	sub, code, err := s.subscribe(r)
+	if err != nil {
+		writeError(w, code, err.Error())
+		return
+	}
+	defer sub.client.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case msg := <-sub.messages:
+			buf, err := json.Marshal(msg)
+			if err != nil {
+				logger.Errorf("%sencode message error: %v", egressLogPrefix, err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "id: %s\nevent: data\ndata: %s\n\n", msg.TransactionID, buf); err != nil {
+				logger.Debugf("%s[%s] SSE write error: %v", egressLogPrefix, r.RemoteAddr, err)
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// parseTags parses the comma separated data tags in decimal or hexadecimal with 0x prefix.
+func parseTags(s string) ([]frame.Tag, error) {
+	if s == "" {
+		return nil, errors.New("tags are required")
+	}
+	var tags []frame.Tag
+	for _, v := range strings.Split(s, ",") {
+		tag, err := strconv.ParseUint(strings.TrimSpace(v), 0, 32)
+		if err != nil {
+			return nil, errors.New("invalid tag: " + v)
+		}
+		tags = append(tags, frame.Tag(tag))
+	}
+	return tags, nil
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(map[string]string{"error": msg}); err != nil {
+		logger.Errorf("%swrite response error: %v", egressLogPrefix, err)
+	}
+}
@@ -0,0 +1,122 @@
+package egress
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yomorun/yomo/core"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/core/router"
+	"github.com/yomorun/yomo/pkg/config"
+)
+
+func newZipper(t *testing.T, opts ...core.ServerOption) (*core.Server, *core.LocalClient) {
+	server := core.NewServer("zipper", opts...)
+	server.ConfigMetadataBuilder(metadata.DefaultBuilder())
+	server.ConfigRouter(router.Default([]config.App{{Name: "sfn-1"}}))
+
+	handshake := frame.NewHandshakeFrame("source", "source-id", byte(core.ClientTypeSource), nil, "token", "secret")
+	source, err := server.ConnectLocal(handshake, func(frame.Frame) {})
+	require.NoError(t, err)
+	t.Cleanup(func() { source.Close() })
+
+	return server, source
+}
+
+func write(t *testing.T, source *core.LocalClient, tag frame.Tag, tid string, data string) {
+	f := frame.NewDataFrame()
+	f.SetCarriage(tag, []byte(data))
+	f.SetTransactionID(tid)
+	f.SetSourceID("source-id")
+	require.NoError(t, source.WriteFrame(f))
+}
+
+func TestSSE(t *testing.T) {
+	server, source := newZipper(t)
+	ts := httptest.NewServer(NewServer("localhost:0", server))
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/sse?tags=0x33,0x34&tid=tid-1")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// the data of other tags and transactions is not subscribed.
+	write(t, source, 0x35, "tid-1", "tag")
+	write(t, source, 0x33, "tid-2", "tid")
+	write(t, source, 0x34, "tid-1", "hello")
+
+	reader := bufio.NewReader(resp.Body)
+	var lines []string
+	for len(lines) < 3 {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+		lines = append(lines, strings.TrimSuffix(line, "\n"))
+	}
+	assert.Equal(t, []string{
+		"id: tid-1",
+		"event: data",
+		`data: {"tag":52,"tid":"tid-1","data":"aGVsbG8="}`,
+	}, lines)
+}
+
+func TestWebSocket(t *testing.T) {
+	server, source := newZipper(t)
+	ts := httptest.NewServer(NewServer("localhost:0", server))
+	defer ts.Close()
+
+	url := "ws" + strings.TrimPrefix(ts.URL, "http") + "/ws?tags=0x33"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	write(t, source, 0x33, "tid-1", "hello")
+
+	var msg Message
+	require.NoError(t, conn.ReadJSON(&msg))
+	assert.Equal(t, Message{Tag: 0x33, TransactionID: "tid-1", Data: []byte("hello")}, msg)
+
+	conn.Close()
+	// the subscription is closed with the connection.
+	assert.Eventually(t, func() bool { return len(server.Connector().GetConns()) == 1 }, time.Second, 10*time.Millisecond)
+}
+
+func TestSubscribeError(t *testing.T) {
+	server, _ := newZipper(t, core.WithAuth("token", "secret"))
+	handler := NewServer("localhost:0", server)
+
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		wantCode int
+		wantBody string
+	}{
+		{"method not allowed", http.MethodPost, "/sse?tags=0x33", http.StatusMethodNotAllowed, "method not allowed"},
+		{"no tags", http.MethodGet, "/sse", http.StatusBadRequest, "tags are required"},
+		{"invalid tag", http.MethodGet, "/ws?tags=0x33,yomo", http.StatusBadRequest, "invalid tag: yomo"},
+		{"unauthorized", http.MethodGet, "/sse?tags=0x33&credential=token:yomo", http.StatusUnauthorized, "handshake authentication fails, client credential name is token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(tt.method, tt.target, nil))
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			var body map[string]string
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			assert.Equal(t, tt.wantBody, body["error"])
+		})
+	}
+}
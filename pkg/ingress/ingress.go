@@ -0,0 +1,192 @@
+// Package ingress provides an embedded HTTP endpoint of YoMo-Zipper for the producers which cannot
+// embed yomo.Source, e.g. webhooks and scripts.
+//
+// The endpoint is:
+//
+//	POST /tags/{tag}    write the request body as a DataFrame of the tag, e.g. `/tags/51` or `/tags/0x33`
+//
+// The DataFrame is routed as the one written by a source, It responds 202 with the transaction id.
+// In the synchronous mode, `?wait={tag}&timeout=5s`, It waits for the backflow result of the stream
+// functions with the tag, then responds 200 with the result as the body, or 504 if it times out.
+//
+// The credential is authenticated by the auth plugins of zipper, It is read from the header
+// `X-Yomo-Credential: <name>:<payload>`, or `Authorization: Bearer <token>` for the token plugin,
+// see auth.NewHTTPCredential.
+package ingress
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yomorun/yomo/core"
+	"github.com/yomorun/yomo/core/auth"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/pkg/id"
+	"github.com/yomorun/yomo/pkg/logger"
+)
+
+const ingressLogPrefix = "\033[36m[yomo:ingress]\033[0m "
+
+const (
+	// HeaderTransactionID is the header of the transaction id in response.
+	HeaderTransactionID = "X-Yomo-Transaction-Id"
+	// MaxBodySize is the max size of the request body.
+	MaxBodySize = 4 << 20
+	// DefaultTimeout is the default timeout of the synchronous mode.
+	DefaultTimeout = 30 * time.Second
+)
+
+// Result is the response of the asynchronous mode.
+type Result struct {
+	TransactionID string `json:"tid"`
+}
+
+// Server is the HTTP ingress server of a YoMo-Zipper.
+type Server struct {
+	server     *core.Server
+	httpServer *http.Server
+	mux        *http.ServeMux
+}
+
+// NewServer creates an ingress server for the zipper's underlying server.
+func NewServer(addr string, server *core.Server) *Server {
+	s := &Server{
+		server: server,
+		mux:    http.NewServeMux(),
+	}
+	s.mux.HandleFunc("/tags/", s.handleTag)
+	s.httpServer = &http.Server{Addr: addr, Handler: s}
+
+	return s
+}
+
+// ListenAndServe starts the ingress server.
+func (s *Server) ListenAndServe() error {
+	logger.Printf("%s✅ HTTP ingress listening on: %s", ingressLogPrefix, s.httpServer.Addr)
+	err := s.httpServer.ListenAndServe()
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
+
+// Close shuts down the ingress server.
+func (s *Server) Close() error {
+	return s.httpServer.Shutdown(context.Background())
+}
+
+// ServeHTTP implements http.Handler interface.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleTag(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	tag, err := parseTag(strings.TrimPrefix(r.URL.Path, "/tags/"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// synchronous mode
+	var observed []frame.Tag
+	query := r.URL.Query()
+	wait := query.Get("wait")
+	if wait != "" {
+		waitTag, err := parseTag(wait)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		observed = []frame.Tag{waitTag}
+	}
+	timeout := DefaultTimeout
+	if v := query.Get("timeout"); v != "" {
+		if timeout, err = time.ParseDuration(v); err != nil || timeout <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid timeout: "+v)
+			return
+		}
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodySize))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, err.Error())
+		return
+	}
+
+	// each request is a source, so the backflow of its transaction is received by itself.
+	cred := auth.NewHTTPCredential(r)
+	sourceID := id.New()
+	results := make(chan *frame.BackflowFrame, 1)
+	handshake := frame.NewHandshakeFrame("ingress", sourceID, byte(core.ClientTypeSource), observed, cred.Name(), cred.Payload())
+	client, err := s.server.ConnectLocal(handshake, func(f frame.Frame) {
+		if bf, ok := f.(*frame.BackflowFrame); ok {
+			select {
+			case results <- bf:
+			default:
+			}
+		}
+	})
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	defer client.Close()
+
+	df := frame.NewDataFrame()
+	df.SetCarriage(tag, body)
+	df.SetSourceID(sourceID)
+	tid := df.TransactionID()
+	w.Header().Set(HeaderTransactionID, tid)
+	if err := client.WriteFrame(df); err != nil {
+		logger.Warnf("%s[%s] write tag %#x error: %v", ingressLogPrefix, tid, tag, err)
+		writeError(w, http.StatusForbidden, err.Error())
+		return
+	}
+	logger.Debugf("%s[%s] tag %#x is written, %d bytes", ingressLogPrefix, tid, tag, len(body))
+
+	if wait == "" {
+		writeJSON(w, http.StatusAccepted, Result{TransactionID: tid})
+		return
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case bf := <-results:
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.WriteHeader(http.StatusOK)
+		w.Write(bf.GetCarriage())
+	case <-timer.C:
+		writeError(w, http.StatusGatewayTimeout, fmt.Sprintf("no result of tag %#x in %s", observed[0], timeout))
+	case <-r.Context().Done():
+	}
+}
+
+// parseTag parses the data tag in decimal or hexadecimal with 0x prefix.
+func parseTag(s string) (frame.Tag, error) {
+	v, err := strconv.ParseUint(s, 0, 32)
+	if err != nil {
+		return 0, errors.New("invalid tag: " + s)
+	}
+	return frame.Tag(v), nil
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logger.Errorf("%swrite response error: %v", ingressLogPrefix, err)
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, map[string]string{"error": msg})
+}
@@ -0,0 +1,111 @@
+package ingress
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yomorun/yomo/core"
+	"github.com/yomorun/yomo/core/auth"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/core/router"
+	"github.com/yomorun/yomo/pkg/config"
+)
+
+func newZipper(t *testing.T, opts ...core.ServerOption) *core.Server {
+	server := core.NewServer("zipper", opts...)
+	server.ConfigMetadataBuilder(metadata.DefaultBuilder())
+	server.ConfigRouter(router.Default([]config.App{{Name: "sfn-1"}}))
+
+	// the stream function responds the upper case of the data of tag 0x33 with tag 0x34.
+	var sfn *core.LocalClient
+	handshake := frame.NewHandshakeFrame("sfn-1", "sfn-1-id", byte(core.ClientTypeStreamFunction), []frame.Tag{0x33}, "token", "secret")
+	sfn, err := server.ConnectLocal(handshake, func(f frame.Frame) {
+		if df, ok := f.(*frame.DataFrame); ok {
+			df.SetCarriage(0x34, bytes.ToUpper(df.GetCarriage()))
+			go sfn.WriteFrame(df)
+		}
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { sfn.Close() })
+
+	return server
+}
+
+func TestIngress(t *testing.T) {
+	handler := NewServer("localhost:0", newZipper(t))
+
+	tests := []struct {
+		name     string
+		target   string
+		wantCode int
+		wantBody string
+	}{
+		{"async", "/tags/0x33", http.StatusAccepted, ""},
+		{"sync", "/tags/51?wait=0x34&timeout=1s", http.StatusOK, "HELLO"},
+		{"sync timeout", "/tags/0x33?wait=0x35&timeout=100ms", http.StatusGatewayTimeout, "{\"error\":\"no result of tag 0x35 in 100ms\"}\n"},
+		{"invalid tag", "/tags/yomo", http.StatusBadRequest, "{\"error\":\"invalid tag: yomo\"}\n"},
+		{"invalid timeout", "/tags/0x33?wait=0x34&timeout=-1s", http.StatusBadRequest, "{\"error\":\"invalid timeout: -1s\"}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.target, bytes.NewBufferString("hello")))
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			if tt.wantCode == http.StatusAccepted {
+				tid := w.Header().Get(HeaderTransactionID)
+				assert.NotEmpty(t, tid)
+				assert.Equal(t, "{\"tid\":\""+tid+"\"}\n", w.Body.String())
+				return
+			}
+			assert.Equal(t, tt.wantBody, w.Body.String())
+		})
+	}
+
+	// the virtual sources are disconnected after the requests.
+	assert.Len(t, handler.server.Connector().GetConns(), 1)
+}
+
+func TestIngressMethodNotAllowed(t *testing.T) {
+	handler := NewServer("localhost:0", newZipper(t))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tags/0x33", nil))
+
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestIngressAuth(t *testing.T) {
+	handler := NewServer("localhost:0", newZipper(t, core.WithAuth("token", "secret")))
+
+	tests := []struct {
+		name     string
+		header   string
+		value    string
+		wantCode int
+	}{
+		{"no credential", "", "", http.StatusUnauthorized},
+		{"wrong token", "Authorization", "Bearer yomo", http.StatusUnauthorized},
+		{"bearer token", "Authorization", "Bearer secret", http.StatusAccepted},
+		{"credential", auth.HeaderCredential, "token:secret", http.StatusAccepted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/tags/0x33", bytes.NewBufferString("hello"))
+			if tt.header != "" {
+				r.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+		})
+	}
+}
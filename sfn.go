@@ -5,6 +5,7 @@ import (
 
 	"github.com/yomorun/yomo/core"
 	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/trace"
 )
 
 const (
@@ -28,6 +29,11 @@ type StreamFunction interface {
 	Close() error
 	// Send a data to zipper.
 	Write(tag frame.Tag, carriage []byte) error
+	// SetEncryptionKey set the key to seal the data written by the stream function end to end.
+	SetEncryptionKey(keyID string, key []byte) error
+	// AddDecryptionKey add the key to open the data sealed by it, the response is sealed
+	// by the key of the data if the encryption key is not set.
+	AddDecryptionKey(keyID string, key []byte) error
 }
 
 // NewStreamFunction create a stream function.
@@ -85,7 +91,7 @@ func (s *streamFunction) Connect() error {
 	// notify underlying network operations, when data with tag we observed arrived, invoke the func
 	s.client.SetDataFrameObserver(func(data *frame.DataFrame) {
 		s.client.Logger().Debugf("%sreceive DataFrame: %v", streamFunctionLogPrefix, data)
-		s.onDataFrame(data.GetCarriage(), data.GetMetaFrame())
+		s.onDataFrame(data)
 	})
 
 	if s.pfn != nil {
@@ -140,11 +146,15 @@ func (s *streamFunction) Close() error {
 }
 
 // when DataFrame we observed arrived, invoke the user's function
-func (s *streamFunction) onDataFrame(data []byte, metaFrame *frame.MetaFrame) {
+func (s *streamFunction) onDataFrame(f *frame.DataFrame) {
 	s.client.Logger().Infof("%sonDataFrame ->[%s]", streamFunctionLogPrefix, s.name)
+	data, metaFrame := f.GetCarriage(), f.GetMetaFrame()
+	// the span continues the trace of the DataFrame, and becomes the parent of the response.
+	span := s.client.Tracer().Start("sfn", f)
 
 	if s.fn != nil {
 		go func() {
+			defer span.End()
 			// invoke serverless
 			tag, resp := s.fn(data)
 			// if resp is not nil, means the user's function has returned something, we should send it to the zipper
@@ -156,16 +166,32 @@ func (s *streamFunction) onDataFrame(data []byte, metaFrame *frame.MetaFrame) {
 				frame.SetTransactionID(metaFrame.TransactionID())
 				// reuse sourceID
 				frame.SetSourceID(metaFrame.SourceID())
+				// the response is routed back to the zipper of the source
+				frame.GetMetaFrame().SetOrigin(metaFrame.Origin())
+				// continue the trace
+				trace.Inject(metaFrame, frame)
 				frame.SetCarriage(tag, resp)
+				// the response of sealed data is sealed by the same key, unless the encryption key is set.
+				if keyID := metaFrame.KeyID(); keyID != "" && s.client.EncryptionKeyID() == "" {
+					sealed, err := s.client.Seal(frame, keyID)
+					if err != nil {
+						s.client.Logger().Errorf("%sseal the response error: %v", streamFunctionLogPrefix, err)
+						span.SetError(err)
+						return
+					}
+					frame = sealed
+				}
 				s.client.Logger().Debugf("%sstart WriteFrame(): %v", streamFunctionLogPrefix, resp)
-				s.client.WriteFrame(frame)
+				span.SetError(s.client.WriteFrame(frame))
 			}
 		}()
 	} else if s.pfn != nil {
 		s.client.Logger().Debugf("%spipe fn receive: data[%d]=%# x", streamFunctionLogPrefix, len(data), data)
 		s.pIn <- data
+		span.End()
 	} else {
 		s.client.Logger().Warnf("%sStreamFunction is nil", streamFunctionLogPrefix)
+		span.End()
 	}
 }
 
@@ -180,3 +206,13 @@ func (s *streamFunction) Write(tag frame.Tag, carriage []byte) error {
 func (s *streamFunction) SetErrorHandler(fn func(err error)) {
 	s.client.SetErrorHandler(fn)
 }
+
+// SetEncryptionKey set the key to seal the data written by the stream function end to end.
+func (s *streamFunction) SetEncryptionKey(keyID string, key []byte) error {
+	return s.client.SetEncryptionKey(keyID, key)
+}
+
+// AddDecryptionKey add the key to open the data sealed by it.
+func (s *streamFunction) AddDecryptionKey(keyID string, key []byte) error {
+	return s.client.AddDecryptionKey(keyID, key)
+}
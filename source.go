@@ -2,6 +2,7 @@ package yomo
 
 import (
 	"context"
+	"sync"
 
 	"github.com/yomorun/yomo/core"
 	"github.com/yomorun/yomo/core/frame"
@@ -29,6 +30,16 @@ type Source interface {
 	SetReceiveHandler(fn func(tag frame.Tag, data []byte))
 	// Write the data to all downstream
 	Broadcast(data []byte) error
+	// WriteDatagram will write data with specified tag as an unreliable datagram, the data may be lost,
+	// It is written reliably if it is too large for a datagram.
+	WriteDatagram(tag frame.Tag, data []byte) error
+	// SetEncryptionKey set the key to seal the data end to end, only the stream functions
+	// holding the key of the id can open it.
+	SetEncryptionKey(keyID string, key []byte) error
+	// Request writes the data with specified tag and waits for the result of it, the result is the
+	// first backflow of the observed tags carrying the same transaction id. The tags of the result must
+	// be observed by `WithObserveDataTags`, It returns the error of ctx if no result in time.
+	Request(ctx context.Context, tag frame.Tag, data []byte) ([]byte, error)
 }
 
 // YoMo-Source
@@ -38,6 +49,8 @@ type yomoSource struct {
 	client         *core.Client
 	tag            frame.Tag
 	fn             func(frame.Tag, []byte)
+	// pending is the requests waiting for the result, tid -> chan []byte
+	pending sync.Map
 }
 
 var _ Source = &yomoSource{}
@@ -82,6 +95,11 @@ func (s *yomoSource) Close() error {
 func (s *yomoSource) Connect() error {
 	// set backflowframe handler
 	s.client.SetBackflowFrameObserver(func(frm *frame.BackflowFrame) {
+		// the results of the requests are not passed to the receive handler.
+		if ch, ok := s.pending.LoadAndDelete(frm.TransactionID()); ok {
+			ch.(chan []byte) <- frm.GetCarriage()
+			return
+		}
 		if s.fn != nil {
 			s.fn(frm.GetDataTag(), frm.GetCarriage())
 		}
@@ -100,7 +118,27 @@ func (s *yomoSource) WriteWithTag(tag frame.Tag, data []byte) error {
 	f.SetCarriage(tag, data)
 	f.SetSourceID(s.client.ClientID())
 	s.client.Logger().Debugf("%sWriteWithTag: %v", sourceLogPrefix, f)
-	return s.client.WriteFrame(f)
+	return s.writeFrame(f)
+}
+
+// WriteDatagram will write data with specified tag as an unreliable datagram.
+func (s *yomoSource) WriteDatagram(tag frame.Tag, data []byte) error {
+	f := frame.NewDataFrame()
+	f.SetCarriage(tag, data)
+	f.SetSourceID(s.client.ClientID())
+	f.SetDatagram(true)
+	s.client.Logger().Debugf("%sWriteDatagram: %v", sourceLogPrefix, f)
+	return s.writeFrame(f)
+}
+
+// writeFrame writes the DataFrame in a span, which starts the trace of the DataFrame.
+func (s *yomoSource) writeFrame(f *frame.DataFrame) error {
+	span := s.client.Tracer().Start("source", f)
+	defer span.End()
+
+	err := s.client.WriteFrame(f)
+	span.SetError(err)
+	return err
 }
 
 // SetErrorHandler set the error handler function when server error occurs
@@ -121,5 +159,34 @@ func (s *yomoSource) Broadcast(data []byte) error {
 	f.SetSourceID(s.client.ClientID())
 	f.SetBroadcast(true)
 	s.client.Logger().Debugf("%sBroadcast: %v", sourceLogPrefix, f)
-	return s.client.WriteFrame(f)
+	return s.writeFrame(f)
+}
+
+// Request writes the data with specified tag and waits for the result correlated by the transaction id.
+func (s *yomoSource) Request(ctx context.Context, tag frame.Tag, data []byte) ([]byte, error) {
+	f := frame.NewDataFrame()
+	f.SetCarriage(tag, data)
+	f.SetSourceID(s.client.ClientID())
+
+	tid := f.TransactionID()
+	ch := make(chan []byte, 1)
+	s.pending.Store(tid, ch)
+	defer s.pending.Delete(tid)
+
+	s.client.Logger().Debugf("%sRequest: %v", sourceLogPrefix, f)
+	if err := s.writeFrame(f); err != nil {
+		return nil, err
+	}
+
+	select {
+	case result := <-ch:
+		return result, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// SetEncryptionKey set the key to seal the data end to end, the responses sealed by it are opened too.
+func (s *yomoSource) SetEncryptionKey(keyID string, key []byte) error {
+	return s.client.SetEncryptionKey(keyID, key)
 }
@@ -1,9 +1,15 @@
 package yomo
 
 import (
+	"bytes"
+	"context"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yomorun/yomo/core/frame"
 )
 
 func TestSourceSendDataToServer(t *testing.T) {
@@ -19,3 +25,78 @@ func TestSourceSendDataToServer(t *testing.T) {
 	assert.Greater(t, n, 0, "[source.Write] expected n > 0, but got %d", n)
 	assert.Nil(t, err)
 }
+
+func TestSourceRequest(t *testing.T) {
+	sfn := NewStreamFunction(
+		"test-sfn",
+		WithZipperAddr("localhost:9000"),
+		WithObserveDataTags(0x21),
+	)
+	defer sfn.Close()
+
+	sfn.SetHandler(func(data []byte) (frame.Tag, []byte) {
+		return 0x22, bytes.ToUpper(data)
+	})
+	require.NoError(t, sfn.Connect())
+
+	source := NewSource("test-source-request", WithZipperAddr("localhost:9000"), WithObserveDataTags(0x22))
+	defer source.Close()
+
+	received := make(chan []byte, 10)
+	source.SetReceiveHandler(func(tag frame.Tag, data []byte) {
+		received <- data
+	})
+	require.NoError(t, source.Connect())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	for _, data := range []string{"hello", "yomo"} {
+		result, err := source.Request(ctx, 0x21, []byte(data))
+		assert.NoError(t, err)
+		assert.Equal(t, strings.ToUpper(data), string(result))
+	}
+	// the results of the requests are not passed to the receive handler.
+	assert.Empty(t, received)
+
+	// no result of tag 0x23
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err := source.Request(ctx, 0x23, []byte("timeout"))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestSourceRequestEncrypted(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 32)
+
+	sfn := NewStreamFunction(
+		"test-sfn",
+		WithZipperAddr("localhost:9000"),
+		WithObserveDataTags(0x24),
+	)
+	defer sfn.Close()
+
+	require.NoError(t, sfn.AddDecryptionKey("key-1", key))
+	sfn.SetHandler(func(data []byte) (frame.Tag, []byte) {
+		return 0x25, bytes.ToUpper(data)
+	})
+	require.NoError(t, sfn.Connect())
+
+	source := NewSource(
+		"test-source-encrypted",
+		WithZipperAddr("localhost:9000"),
+		WithObserveDataTags(0x25),
+		WithCompression("gzip", 0),
+	)
+	defer source.Close()
+
+	require.NoError(t, source.SetEncryptionKey("key-1", key))
+	require.NoError(t, source.Connect())
+
+	// the result is sealed by the key of the data, zipper backflows it as is.
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	result, err := source.Request(ctx, 0x24, []byte("hello"))
+	assert.NoError(t, err)
+	assert.Equal(t, "HELLO", string(result))
+}
@@ -2,16 +2,27 @@ package yomo
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"net/http"
+	"os"
+	"reflect"
+	"sync"
+	"time"
 
 	"github.com/yomorun/yomo/core"
+	"github.com/yomorun/yomo/core/auth"
+	"github.com/yomorun/yomo/core/frame"
 	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/core/ratelimit"
 	"github.com/yomorun/yomo/core/router"
+	"github.com/yomorun/yomo/pkg/admin"
+	"github.com/yomorun/yomo/pkg/bridge/mqtt"
 	"github.com/yomorun/yomo/pkg/config"
+	"github.com/yomorun/yomo/pkg/egress"
+	"github.com/yomorun/yomo/pkg/ingress"
 	"github.com/yomorun/yomo/pkg/logger"
+	"go.uber.org/multierr"
 )
 
 const (
@@ -26,9 +37,12 @@ type Zipper interface {
 	// ConfigWorkflow will register workflows from config files to zipper.
 	ConfigWorkflow(conf string) error
 
-	// ConfigMesh will register edge-mesh config URL
+	// ConfigMesh will register edge-mesh config URL or file path
 	ConfigMesh(url string) error
 
+	// RefreshMesh will re-load the edge-mesh config and update the downstream zippers.
+	RefreshMesh() error
+
 	// ListenAndServe start zipper as server.
 	ListenAndServe() error
 
@@ -63,9 +77,37 @@ type zipper struct {
 	server            *core.Server
 	client            *core.Client
 	downstreamZippers []Zipper
+	filter            *core.TagFilter // the tag filter of the downstream zipper
+	downstreamsMu     sync.Mutex
+	serving           bool
+	meshConfig        string
+	meshInterval      time.Duration
+	mesh              map[string]config.MeshZipper // addr -> the downstream zipper of mesh config
+	meshMu            sync.Mutex
 	wfc               *config.WorkflowConfig
+	wfcPath           string
+	router            router.Router
+	acl               *auth.ACL
+	limiter           *ratelimit.Limiter
+	limited           *core.Server      // the server the rate limit handler is installed on
+	tenants           map[string]string // identity -> tenant
+	tenantsMu         sync.RWMutex
+	reloadMu          sync.Mutex
+	adminAddr         string
+	adminToken        string
+	admin             *admin.Server
+	ingressAddr       string
+	ingress           *ingress.Server
+	egressAddr        string
+	egress            *egress.Server
+	bridge            *mqtt.Bridge
+	done              chan struct{}
+	closeOnce         sync.Once
 }
 
+// workflowWatchInterval is the interval of checking the workflow config file for changes.
+var workflowWatchInterval = 2 * time.Second
+
 var _ Zipper = &zipper{}
 
 // NewZipperWithOptions create a zipper instance.
@@ -90,6 +132,7 @@ func NewZipper(conf string) (Zipper, error) {
 	options := NewOptions()
 	options.ZipperAddr = listenAddr
 	zipper := createZipperServer(config.Name, options, config)
+	zipper.wfcPath = conf
 	// zipper workflow
 	err = zipper.configWorkflow(config)
 
@@ -105,6 +148,7 @@ func NewDownstreamZipper(name string, opts ...Option) Zipper {
 		name:   name,
 		addr:   options.ZipperAddr,
 		client: client,
+		filter: options.DownstreamTagFilter,
 	}
 }
 
@@ -114,10 +158,16 @@ func createZipperServer(name string, options *Options, cfg *config.WorkflowConfi
 	// create underlying QUIC server
 	srv := core.NewServer(name, options.ServerOptions...)
 	z := &zipper{
-		server: srv,
-		name:   name,
-		addr:   options.ZipperAddr,
-		wfc:    cfg,
+		server:       srv,
+		name:         name,
+		addr:         options.ZipperAddr,
+		wfc:          cfg,
+		adminAddr:    options.AdminAddr,
+		adminToken:   options.AdminToken,
+		ingressAddr:  options.IngressAddr,
+		egressAddr:   options.EgressAddr,
+		meshInterval: options.MeshConfigInterval,
+		done:         make(chan struct{}),
 	}
 	// initialize
 	z.init()
@@ -132,94 +182,438 @@ func (z *zipper) ConfigWorkflow(conf string) error {
 		return err
 	}
 	logger.Debugf("%sConfigWorkflow config=%+v", zipperLogPrefix, config)
+	z.wfcPath = conf
 	return z.configWorkflow(config)
 }
 
 func (z *zipper) configWorkflow(config *config.WorkflowConfig) error {
 	z.wfc = config
-	z.server.ConfigMetadataBuilder(metadata.DefaultBuilder())
-	z.server.ConfigRouter(router.Default(config.Functions))
+	if config.IsPipeline() {
+		for i, app := range config.Functions {
+			logger.Printf("%spipeline step %d: [%s] %v -> %v", zipperLogPrefix, i+1, app.Name, app.Input, app.Output)
+		}
+	}
+	if len(config.Tenants) > 0 {
+		z.configTenants(config.Tenants)
+		z.router = router.Tenant(config.Functions)
+		z.server.ConfigMetadataBuilder(metadata.TenantBuilder(z.tenantOf))
+	} else {
+		z.router = router.Default(config.Functions)
+		z.server.ConfigMetadataBuilder(metadata.DefaultBuilder())
+	}
+	z.server.ConfigRouter(z.router)
+	z.configACL(config.ACL)
+	z.configRateLimits(config.RateLimits)
+	z.server.ConfigStoreAndForward(storeAndForwardFunctions(config.Functions))
 	return nil
 }
 
-func (z *zipper) ConfigMesh(url string) error {
-	if url == "" {
-		return nil
+// storeAndForwardFunctions returns the input tags of the functions enabled store-and-forward.
+func storeAndForwardFunctions(functions []config.App) map[string][]frame.Tag {
+	result := make(map[string][]frame.Tag)
+	for _, app := range functions {
+		if app.StoreAndForward {
+			result[app.Name] = app.Input
+		}
 	}
+	return result
+}
 
-	logger.Printf("%sDownloading mesh config...", zipperLogPrefix)
-	// download mesh conf
-	res, err := http.Get(url)
-	if err != nil {
-		return err
+// configTenants sets the tenants of identities.
+func (z *zipper) configTenants(tenants []config.Tenant) {
+	identities := make(map[string]string)
+	for _, tenant := range tenants {
+		for _, identity := range tenant.Identities {
+			identities[identity] = tenant.Name
+		}
+	}
+	z.tenantsMu.Lock()
+	z.tenants = identities
+	z.tenantsMu.Unlock()
+}
+
+// tenantOf returns the tenant of the client by its credential,
+// the client whose identity is not in any tenant belongs to the default tenant.
+func (z *zipper) tenantOf(f *frame.HandshakeFrame) (string, error) {
+	identity := auth.Identify(z.server.Options().Auths, f)
+
+	z.tenantsMu.RLock()
+	defer z.tenantsMu.RUnlock()
+
+	if tenant, ok := z.tenants[identity]; ok {
+		return tenant, nil
+	}
+	return metadata.DefaultTenant, nil
+}
+
+// configACL sets the access control of data tags to server, It updates the rules in place
+// if the zipper already has an ACL, so the publish rules take effect on the live connections.
+func (z *zipper) configACL(rules []config.ACLRule) {
+	if len(rules) == 0 {
+		z.acl = nil
+		z.server.ConfigACL(nil)
+		return
+	}
+	perms := make(map[string]auth.Permission, len(rules))
+	for _, rule := range rules {
+		perms[rule.Identity] = auth.Permission{Publish: rule.Publish, Observe: rule.Observe}
 	}
-	defer res.Body.Close()
+	if z.acl == nil {
+		z.acl = auth.NewACL(perms)
+	} else {
+		z.acl.Update(perms)
+	}
+	z.server.ConfigACL(z.acl)
+}
+
+// configRateLimits sets the rate limits of DataFrames to server, the limiter is installed
+// as a before handler of server before serving, then the rate limits are updated in place.
+// The handler is installed again on the new server created by InitOptions.
+func (z *zipper) configRateLimits(limits []config.RateLimit) {
+	if z.limiter != nil {
+		z.limiter.Update(limits)
+	} else if len(limits) > 0 {
+		z.limiter = ratelimit.New(limits)
+	} else {
+		return
+	}
+	if z.limited != z.server {
+		z.server.SetBeforeHandlers(z.server.RateLimitHandler(z.limiter))
+		z.limited = z.server
+	}
+}
 
-	decoder := json.NewDecoder(res.Body)
-	var configs []config.MeshZipper
-	err = decoder.Decode(&configs)
+// reload re-reads the workflow config file and updates the stream functions of router,
+// the connections of removed stream functions are sent a GoawayFrame, others are kept.
+func (z *zipper) reload() error {
+	z.reloadMu.Lock()
+	defer z.reloadMu.Unlock()
+
+	if z.wfcPath == "" {
+		return errors.New("zipper: no workflow config to reload")
+	}
+	conf, err := config.ParseWorkflowConfig(z.wfcPath)
 	if err != nil {
-		logger.Errorf("%s✅ downloaded the Mesh config with err=%v", zipperLogPrefix, err)
 		return err
 	}
+	r, ok := z.router.(interface{ Update([]config.App) []string })
+	if !ok {
+		return fmt.Errorf("zipper: router %T does not support reloading", z.router)
+	}
+	if conf.Name != z.wfc.Name || conf.Host != z.wfc.Host || conf.Port != z.wfc.Port {
+		logger.Warnf("%sthe changes of name, host or port take effect after restarting", zipperLogPrefix)
+	}
+	if (len(conf.Tenants) > 0) != (len(z.wfc.Tenants) > 0) {
+		logger.Warnf("%senabling or disabling tenants takes effect after restarting", zipperLogPrefix)
+	} else if len(conf.Tenants) > 0 {
+		// the new tenants apply to the new connections.
+		z.configTenants(conf.Tenants)
+		z.wfc.Tenants = conf.Tenants
+	}
+
+	removed := r.Update(conf.Functions)
+	z.wfc.Functions = conf.Functions
+	z.wfc.ACL = conf.ACL
+	z.configACL(conf.ACL)
+	if z.limiter == nil && len(conf.RateLimits) > 0 {
+		logger.Warnf("%senabling rate limits takes effect after restarting", zipperLogPrefix)
+	} else {
+		z.wfc.RateLimits = conf.RateLimits
+		z.configRateLimits(conf.RateLimits)
+	}
+	z.server.ConfigStoreAndForward(storeAndForwardFunctions(conf.Functions))
+
+	for _, connID := range removed {
+		conn := z.server.Connector().Get(connID)
+		if conn == nil {
+			continue
+		}
+		logger.Printf("%s[%s](%s) is removed from workflow, send GoawayFrame", zipperLogPrefix, conn.Name(), connID)
+		if err := conn.Write(frame.NewGoawayFrame("removed from workflow")); err != nil {
+			logger.Errorf("%swrite to [%s](%s) GoawayFrame error: %v", zipperLogPrefix, conn.Name(), connID, err)
+		}
+	}
+	logger.Printf("%s✅ Reloaded workflow config: %s, functions=%d, removed connections=%d", zipperLogPrefix, z.wfcPath, len(conf.Functions), len(removed))
+
+	return nil
+}
 
-	logger.Printf("%s✅ Successfully downloaded the Mesh config. ", zipperLogPrefix)
+// watchWorkflow reloads the workflow config when the file is changed.
+func (z *zipper) watchWorkflow() {
+	modTime := func() time.Time {
+		info, err := os.Stat(z.wfcPath)
+		if err != nil {
+			return time.Time{}
+		}
+		return info.ModTime()
+	}
+	last := modTime()
+
+	ticker := time.NewTicker(workflowWatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-z.done:
+			return
+		case <-ticker.C:
+			t := modTime()
+			if t.IsZero() || t.Equal(last) {
+				continue
+			}
+			last = t
+			logger.Printf("%sworkflow config is changed, reloading...", zipperLogPrefix)
+			if err := z.reload(); err != nil {
+				logger.Errorf("%sreload workflow config: %v", zipperLogPrefix, err)
+			}
+		}
+	}
+}
 
-	if len(configs) == 0 {
+// ConfigMesh loads the edge-mesh config from an HTTP(S) URL or a local file path, and adds the zippers
+// of it as downstreams. The config is re-loaded on the interval of `WithMeshConfigInterval`, or by RefreshMesh.
+func (z *zipper) ConfigMesh(url string) error {
+	if url == "" {
 		return nil
 	}
+	z.meshMu.Lock()
+	z.meshConfig = url
+	z.meshMu.Unlock()
+
+	return z.RefreshMesh()
+}
+
+// RefreshMesh re-loads the edge-mesh config and diffs it against the current downstream zippers,
+// the new zippers are connected, the removed or changed ones are disconnected, others are kept.
+// The downstream zippers are not changed if the config fails to load.
+func (z *zipper) RefreshMesh() error {
+	z.meshMu.Lock()
+	defer z.meshMu.Unlock()
+
+	if z.meshConfig == "" {
+		return errors.New("zipper: no mesh config to refresh")
+	}
+
+	logger.Printf("%sLoading mesh config %s...", zipperLogPrefix, z.meshConfig)
+	configs, err := config.LoadMeshConfig(z.meshConfig)
+	if err != nil {
+		logger.Errorf("%sload mesh config with err=%v", zipperLogPrefix, err)
+		return err
+	}
 
+	latest := make(map[string]config.MeshZipper, len(configs))
 	for _, downstream := range configs {
 		if downstream.Name == z.name {
 			continue
 		}
-		addr := fmt.Sprintf("%s:%d", downstream.Host, downstream.Port)
+		latest[downstream.Addr()] = downstream
+	}
+
+	var added, removed int
+	for addr, downstream := range z.mesh {
+		if current, ok := latest[addr]; ok && reflect.DeepEqual(current, downstream) {
+			continue
+		}
+		if err := z.removeDownstreamZipper(addr); err != nil {
+			logger.Errorf("%sremove downstream zipper: %v", zipperLogPrefix, err)
+			continue
+		}
+		removed++
+	}
+	for addr, downstream := range latest {
+		if current, ok := z.mesh[addr]; ok && reflect.DeepEqual(current, downstream) {
+			continue
+		}
 		opts := []Option{WithZipperAddr(addr)}
 		if downstream.Credential != "" {
 			opts = append(opts, WithCredential(downstream.Credential))
 		}
-		z.AddDownstreamZipper(NewDownstreamZipper(downstream.Name, opts...))
+		if len(downstream.Allow) > 0 || len(downstream.Deny) > 0 {
+			opts = append(opts, WithDownstreamTagFilter(downstream.Allow, downstream.Deny))
+		}
+		if err := z.AddDownstreamZipper(NewDownstreamZipper(downstream.Name, opts...)); err != nil {
+			logger.Errorf("%sadd downstream zipper: %v", zipperLogPrefix, err)
+			continue
+		}
+		added++
 	}
+	z.mesh = latest
 
+	logger.Printf("%s✅ Successfully loaded the Mesh config, downstreams=%d, added=%d, removed=%d", zipperLogPrefix, len(latest), added, removed)
 	return nil
 }
 
+// watchMesh re-loads the edge-mesh config on the interval.
+func (z *zipper) watchMesh(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-z.done:
+			return
+		case <-ticker.C:
+			// the error is logged, the current downstreams are kept.
+			z.RefreshMesh()
+		}
+	}
+}
+
 // ListenAndServe will start zipper service.
 func (z *zipper) ListenAndServe() error {
 	logger.Debugf("%sCreating Zipper Server ...", zipperLogPrefix)
-	// check downstream zippers
+	// check downstream zippers, the ones added after serving are connected when added.
+	z.downstreamsMu.Lock()
+	z.serving = true
 	for _, ds := range z.downstreamZippers {
-		if dsZipper, ok := ds.(*zipper); ok {
-			go func(dsZipper *zipper) {
-				dsZipper.client.Connect(context.Background(), dsZipper.addr)
-				z.server.AddDownstreamServer(dsZipper.addr, dsZipper.client)
-			}(dsZipper)
+		z.connectDownstreamZipper(ds)
+	}
+	z.downstreamsMu.Unlock()
+	// refresh the mesh config on the interval
+	if z.meshConfig != "" && z.meshInterval > 0 {
+		go z.watchMesh(z.meshInterval)
+	}
+	// reload the workflow config on change
+	if z.wfcPath != "" {
+		go z.watchWorkflow()
+	}
+	// admin API
+	if z.adminAddr != "" {
+		z.admin = admin.NewServer(z.adminAddr, z.server, z.adminToken)
+		go func() {
+			if err := z.admin.ListenAndServe(); err != nil {
+				logger.Errorf("%sadmin ListenAndServe: %v", zipperLogPrefix, err)
+			}
+		}()
+	}
+	// HTTP ingress
+	if z.ingressAddr != "" {
+		z.ingress = ingress.NewServer(z.ingressAddr, z.server)
+		go func() {
+			if err := z.ingress.ListenAndServe(); err != nil {
+				logger.Errorf("%singress ListenAndServe: %v", zipperLogPrefix, err)
+			}
+		}()
+	}
+	// WebSocket/SSE egress
+	if z.egressAddr != "" {
+		z.egress = egress.NewServer(z.egressAddr, z.server)
+		go func() {
+			if err := z.egress.ListenAndServe(); err != nil {
+				logger.Errorf("%segress ListenAndServe: %v", zipperLogPrefix, err)
+			}
+		}()
+	}
+	// MQTT bridge
+	if z.wfc != nil && z.wfc.MQTT != nil {
+		if err := z.startMQTTBridge(z.wfc.MQTT); err != nil {
+			logger.Errorf("%sMQTT bridge: %v", zipperLogPrefix, err)
 		}
 	}
 	return z.server.ListenAndServe(context.Background(), z.addr)
 }
 
-// AddDownstreamZipper will add downstream zipper.
+// startMQTTBridge connects the MQTT bridge to the zipper in process, the broker is connected in background.
+func (z *zipper) startMQTTBridge(cfg *config.MQTTBridge) error {
+	bridge := mqtt.New(cfg)
+	conn, err := mqtt.DialLocal(z.server, z.name+"-mqtt", bridge)
+	if err != nil {
+		return err
+	}
+	z.bridge = bridge
+	go func() {
+		if err := bridge.Start(conn); err != nil {
+			logger.Errorf("%sMQTT bridge start: %v", zipperLogPrefix, err)
+		}
+	}()
+	return nil
+}
+
+// AddDownstreamZipper will add downstream zipper, It is connected at once if the zipper is serving.
+// The broadcast DataFrames are forwarded to it by the tag filter of `WithDownstreamTagFilter`, and only
+// the ones of the data tags observed by it if it advertises them.
 func (z *zipper) AddDownstreamZipper(downstream Zipper) error {
 	logger.Debugf("%sAddDownstreamZipper: %v", zipperLogPrefix, downstream)
+	z.downstreamsMu.Lock()
+	defer z.downstreamsMu.Unlock()
+
+	for _, ds := range z.downstreamZippers {
+		if ds.Addr() == downstream.Addr() {
+			return fmt.Errorf("zipper: downstream zipper %s already exists", downstream.Addr())
+		}
+	}
 	z.downstreamZippers = append(z.downstreamZippers, downstream)
 	z.hasDownstreams = true
+	if z.serving {
+		z.connectDownstreamZipper(downstream)
+	}
 	logger.Debugf("%scurrent downstreams: %d", zipperLogPrefix, len(z.downstreamZippers))
 	return nil
 }
 
-// RemoveDownstreamZipper remove downstream zipper.
+// connectDownstreamZipper connects the downstream zipper in background and adds it to server,
+// It must be called with downstreamsMu held.
+func (z *zipper) connectDownstreamZipper(ds Zipper) {
+	dsZipper, ok := ds.(*zipper)
+	if !ok {
+		return
+	}
+	// the results of the DataFrames dispatched to the downstream are routed back to the sources.
+	dsZipper.client.SetDataFrameObserver(z.server.HandleBackflow)
+	go func() {
+		// the client keeps reconnecting if it fails to connect.
+		if err := dsZipper.client.Connect(context.Background(), dsZipper.addr); err != nil {
+			logger.Errorf("%sconnect to downstream zipper %s: %v", zipperLogPrefix, dsZipper.addr, err)
+		}
+
+		z.downstreamsMu.Lock()
+		defer z.downstreamsMu.Unlock()
+		// the downstream is removed while connecting.
+		if !z.hasDownstreamZipper(dsZipper) {
+			dsZipper.Close()
+			return
+		}
+		z.server.ConfigDownstreamFilter(dsZipper.addr, dsZipper.filter)
+		z.server.AddDownstreamServer(dsZipper.addr, dsZipper.client)
+	}()
+}
+
+func (z *zipper) hasDownstreamZipper(downstream Zipper) bool {
+	for _, ds := range z.downstreamZippers {
+		if ds == downstream {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveDownstreamZipper remove downstream zipper, the connection to it is closed.
 func (z *zipper) RemoveDownstreamZipper(downstream Zipper) error {
+	return z.removeDownstreamZipper(downstream.Addr())
+}
+
+func (z *zipper) removeDownstreamZipper(addr string) error {
+	z.downstreamsMu.Lock()
+	defer z.downstreamsMu.Unlock()
+
 	index := -1
 	for i, v := range z.downstreamZippers {
-		if v.Addr() == downstream.Addr() {
+		if v.Addr() == addr {
 			index = i
 			break
 		}
 	}
+	if index == -1 {
+		return fmt.Errorf("zipper: downstream zipper %s not found", addr)
+	}
+	ds := z.downstreamZippers[index]
 
 	// remove from slice
 	z.downstreamZippers = append(z.downstreamZippers[:index], z.downstreamZippers[index+1:]...)
+	z.hasDownstreams = len(z.downstreamZippers) > 0
+
+	// the server closes the client of the connected downstream, otherwise it is closed here.
+	if z.server == nil || !z.server.RemoveDownstreamServer(addr) {
+		return ds.Close()
+	}
 	return nil
 }
 
@@ -229,22 +623,54 @@ func (z *zipper) Addr() string {
 }
 
 // Close will close a connection. If zipper is Server, close the server. If zipper is Client, close the client.
-func (z *zipper) Close() error {
+func (z *zipper) Close() (err error) {
+	if z.done != nil {
+		z.closeOnce.Do(func() { close(z.done) })
+	}
+	// all of them are closed even though some failed, the errors are combined.
+	if z.admin != nil {
+		logger.Debugf("%sadmin close()", zipperLogPrefix)
+		if e := z.admin.Close(); e != nil {
+			logger.Errorf("%sadmin close(): %v", zipperLogPrefix, e)
+			err = multierr.Append(err, e)
+		}
+	}
+	if z.ingress != nil {
+		logger.Debugf("%singress close()", zipperLogPrefix)
+		if e := z.ingress.Close(); e != nil {
+			logger.Errorf("%singress close(): %v", zipperLogPrefix, e)
+			err = multierr.Append(err, e)
+		}
+	}
+	if z.bridge != nil {
+		logger.Debugf("%sMQTT bridge close()", zipperLogPrefix)
+		if e := z.bridge.Close(); e != nil {
+			logger.Errorf("%sMQTT bridge close(): %v", zipperLogPrefix, e)
+			err = multierr.Append(err, e)
+		}
+	}
+	if z.egress != nil {
+		logger.Debugf("%segress close()", zipperLogPrefix)
+		if e := z.egress.Close(); e != nil {
+			logger.Errorf("%segress close(): %v", zipperLogPrefix, e)
+			err = multierr.Append(err, e)
+		}
+	}
 	if z.server != nil {
 		logger.Debugf("%sserver close()", zipperLogPrefix)
-		if err := z.server.Close(); err != nil {
-			logger.Errorf("%sserver close(): %v", zipperLogPrefix, err)
-			return err
+		if e := z.server.Close(); e != nil {
+			logger.Errorf("%sserver close(): %v", zipperLogPrefix, e)
+			err = multierr.Append(err, e)
 		}
 	}
 	if z.client != nil {
 		logger.Debugf("%sclient close()", zipperLogPrefix)
-		if err := z.client.Close(); err != nil {
-			logger.Errorf("%sclient close(): %v", zipperLogPrefix, err)
-			return err
+		if e := z.client.Close(); e != nil {
+			logger.Errorf("%sclient close(): %v", zipperLogPrefix, e)
+			err = multierr.Append(err, e)
 		}
 	}
-	return nil
+	return err
 }
 
 // Stats inspects current server.
@@ -260,6 +686,7 @@ func (z *zipper) Stats() int {
 	}
 
 	log.Printf("[%s] total DataFrames received: %d", z.name, z.server.StatsCounter())
+	log.Printf("[%s] total DataFrames dropped across zippers: %d", z.name, z.server.StatsMeshDropped())
 
 	return len(z.server.StatsFunctions())
 }
@@ -271,6 +698,21 @@ func (z *zipper) InitOptions(opts ...Option) {
 	}
 	srv := core.NewServer(z.name, options.ServerOptions...)
 	z.server = srv
+	if options.AdminAddr != "" {
+		z.adminAddr = options.AdminAddr
+	}
+	if options.AdminToken != "" {
+		z.adminToken = options.AdminToken
+	}
+	if options.IngressAddr != "" {
+		z.ingressAddr = options.IngressAddr
+	}
+	if options.EgressAddr != "" {
+		z.egressAddr = options.EgressAddr
+	}
+	if options.MeshConfigInterval > 0 {
+		z.meshInterval = options.MeshConfigInterval
+	}
 	if z.wfc != nil {
 		z.configWorkflow(z.wfc)
 	}
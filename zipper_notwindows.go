@@ -17,11 +17,12 @@ import (
 // - `kill -SIGUSR1 <pid>` inspect state()
 // - `kill -SIGTERM <pid>` graceful shutdown
 // - `kill -SIGUSR2 <pid>` inspect golang GC
+// - `kill -SIGHUP <pid>` reload workflow config
 func (z *zipper) init() {
 	go func() {
 		c := make(chan os.Signal, 1)
-		signal.Notify(c, syscall.SIGTERM, syscall.SIGUSR2, syscall.SIGUSR1, syscall.SIGINT)
-		logger.Printf("%sListening SIGUSR1, SIGUSR2, SIGTERM/SIGINT...", zipperLogPrefix)
+		signal.Notify(c, syscall.SIGTERM, syscall.SIGUSR2, syscall.SIGUSR1, syscall.SIGINT, syscall.SIGHUP)
+		logger.Printf("%sListening SIGUSR1, SIGUSR2, SIGHUP, SIGTERM/SIGINT...", zipperLogPrefix)
 		for p1 := range c {
 			logger.Printf("Received signal: %s", p1)
 			if p1 == syscall.SIGTERM || p1 == syscall.SIGINT {
@@ -36,6 +37,10 @@ func (z *zipper) init() {
 				fmt.Printf("\tNumGC = %v\n", m.NumGC)
 			} else if p1 == syscall.SIGUSR1 {
 				logger.Printf("print zipper stats(): %d", z.Stats())
+			} else if p1 == syscall.SIGHUP {
+				if err := z.reload(); err != nil {
+					logger.Errorf("%sreload workflow config: %v", zipperLogPrefix, err)
+				}
 			}
 		}
 	}()
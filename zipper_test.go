@@ -1,10 +1,17 @@
 package yomo
 
 import (
+	"os"
+	"path/filepath"
+	"sort"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/yomorun/yomo/core"
+	"github.com/yomorun/yomo/core/frame"
+	"github.com/yomorun/yomo/core/metadata"
+	"github.com/yomorun/yomo/core/router"
 )
 
 func TestZipperRun(t *testing.T) {
@@ -15,3 +22,166 @@ func TestZipperRun(t *testing.T) {
 	time.Sleep(time.Second)
 	assert.Nil(t, err)
 }
+
+func TestZipperReload(t *testing.T) {
+	conf := filepath.Join(t.TempDir(), "workflow.yaml")
+	writeWorkflow := func(functions string) {
+		data := "name: zipper\nhost: localhost\nport: 9002\nfunctions:\n" + functions
+		assert.NoError(t, os.WriteFile(conf, []byte(data), 0o644))
+	}
+	writeWorkflow("  - name: sfn-1\n  - name: sfn-2\n")
+
+	z, err := NewZipper(conf)
+	assert.NoError(t, err)
+	defer z.Close()
+
+	route := z.(*zipper).router.Route(&metadata.Default{})
+	assert.NoError(t, route.Add("conn-1", "sfn-1", []frame.Tag{frame.Tag(1)}))
+	assert.NoError(t, route.Add("conn-2", "sfn-2", []frame.Tag{frame.Tag(1)}))
+
+	writeWorkflow("  - name: sfn-2\n  - name: sfn-3\n")
+	assert.NoError(t, z.(*zipper).reload())
+
+	assert.Equal(t, map[frame.Tag]map[string]string{frame.Tag(1): {"conn-2": "sfn-2"}}, route.(router.SnapshotRoute).GetSnapshot())
+	assert.NoError(t, route.Add("conn-3", "sfn-3", []frame.Tag{frame.Tag(1)}))
+
+	// the invalid config is not applied.
+	writeWorkflow("  - name: sfn-4\n    load_balance: unknown\n")
+	assert.Error(t, z.(*zipper).reload())
+	assert.NoError(t, route.Remove("conn-3"))
+	assert.NoError(t, route.Add("conn-4", "sfn-3", []frame.Tag{frame.Tag(1)}))
+}
+
+func TestZipperReloadLoadBalance(t *testing.T) {
+	conf := filepath.Join(t.TempDir(), "workflow.yaml")
+	writeWorkflow := func(functions string) {
+		data := "name: zipper\nhost: localhost\nport: 9005\nfunctions:\n" + functions
+		assert.NoError(t, os.WriteFile(conf, []byte(data), 0o644))
+	}
+	writeWorkflow("  - name: sfn-1\n    load_balance: round-robin\n")
+
+	z, err := NewZipper(conf)
+	assert.NoError(t, err)
+	defer z.Close()
+
+	route := z.(*zipper).router.Route(&metadata.Default{})
+	assert.NoError(t, route.Add("conn-1", "sfn-1", []frame.Tag{frame.Tag(1)}))
+	assert.NoError(t, route.Add("conn-2", "sfn-1", []frame.Tag{frame.Tag(1)}))
+
+	// disabling the load balancing keeps one instance of the stream function.
+	writeWorkflow("  - name: sfn-1\n")
+	assert.NoError(t, z.(*zipper).reload())
+	assert.Equal(t, map[frame.Tag]map[string]string{frame.Tag(1): {"conn-1": "sfn-1"}}, route.(router.SnapshotRoute).GetSnapshot())
+	assert.Equal(t, []string{"conn-1"}, route.GetForwardRoutes(frame.Tag(1)))
+}
+
+func TestZipperRateLimitsInitOptions(t *testing.T) {
+	conf := filepath.Join(t.TempDir(), "workflow.yaml")
+	data := `name: zipper
+host: localhost
+port: 9004
+functions:
+  - name: sfn-1
+rate_limits:
+  - by: name
+    value: source-1
+    rate: 1
+    policy: disconnect
+`
+	assert.NoError(t, os.WriteFile(conf, []byte(data), 0o644))
+
+	z, err := NewZipper(conf)
+	assert.NoError(t, err)
+	defer z.Close()
+
+	// InitOptions creates a new server, the rate limits still apply to it.
+	z.InitOptions()
+
+	handshake := frame.NewHandshakeFrame("source-1", "source-id-1", byte(core.ClientTypeSource), []frame.Tag{}, "", "")
+	source, err := z.(*zipper).server.ConnectLocal(handshake, func(frame.Frame) {})
+	assert.NoError(t, err)
+
+	write := func() error {
+		f := frame.NewDataFrame()
+		f.SetCarriage(1, []byte("hello yomo"))
+		return source.WriteFrame(f)
+	}
+	assert.NoError(t, write())
+	assert.Error(t, write())
+}
+
+func TestZipperTenants(t *testing.T) {
+	conf := filepath.Join(t.TempDir(), "workflow.yaml")
+	data := `name: zipper
+host: localhost
+port: 9003
+functions:
+  - name: sfn-1
+tenants:
+  - name: team-a
+    identities: [token-a]
+`
+	assert.NoError(t, os.WriteFile(conf, []byte(data), 0o644))
+
+	z, err := NewZipper(conf)
+	assert.NoError(t, err)
+	defer z.Close()
+
+	tenant, err := z.(*zipper).tenantOf(frame.NewHandshakeFrame("sfn-1", "sfn-id", byte(0x5D), []frame.Tag{}, "token", "token-a"))
+	assert.NoError(t, err)
+	assert.Equal(t, "team-a", tenant)
+
+	tenant, err = z.(*zipper).tenantOf(frame.NewHandshakeFrame("sfn-1", "sfn-id", byte(0x5D), []frame.Tag{}, "token", "token-b"))
+	assert.NoError(t, err)
+	assert.Equal(t, metadata.DefaultTenant, tenant)
+
+	routeA := z.(*zipper).router.Route(metadata.NewTenant("team-a"))
+	routeB := z.(*zipper).router.Route(metadata.NewTenant(metadata.DefaultTenant))
+	assert.NotSame(t, routeA, routeB)
+}
+
+func TestZipperRefreshMesh(t *testing.T) {
+	conf := filepath.Join(t.TempDir(), "mesh.json")
+	writeMesh := func(data string) {
+		assert.NoError(t, os.WriteFile(conf, []byte(data), 0o644))
+	}
+	downstreams := func(z *zipper) []string {
+		var addrs []string
+		for _, ds := range z.downstreamZippers {
+			addrs = append(addrs, ds.Addr())
+		}
+		sort.Strings(addrs)
+		return addrs
+	}
+	writeMesh(`[
+  {"name": "zipper", "host": "localhost", "port": 9004},
+  {"name": "us", "host": "localhost", "port": 19001},
+  {"name": "eu", "host": "localhost", "port": 19002}
+]`)
+
+	z := NewZipperWithOptions("zipper", WithZipperAddr("localhost:9004"), WithMeshConfigURL(conf)).(*zipper)
+	defer z.Close()
+	assert.Equal(t, []string{"localhost:19001", "localhost:19002"}, downstreams(z))
+	var eu Zipper
+	for _, ds := range z.downstreamZippers {
+		if ds.Addr() == "localhost:19002" {
+			eu = ds
+		}
+	}
+
+	// us is removed, the credential of eu is not changed, asia is added.
+	writeMesh(`[
+  {"name": "eu", "host": "localhost", "port": 19002},
+  {"name": "asia", "host": "localhost", "port": 19003}
+]`)
+	assert.NoError(t, z.RefreshMesh())
+	assert.Equal(t, []string{"localhost:19002", "localhost:19003"}, downstreams(z))
+	assert.True(t, z.hasDownstreamZipper(eu))
+
+	// the downstreams are kept if the config is invalid.
+	writeMesh(`{`)
+	assert.Error(t, z.RefreshMesh())
+	assert.Equal(t, []string{"localhost:19002", "localhost:19003"}, downstreams(z))
+
+	assert.Error(t, z.RemoveDownstreamZipper(NewDownstreamZipper("us", WithZipperAddr("localhost:19001"))))
+}